@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSignupThenLogin(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodPost, "/auth/register", gin.H{
+		"name":     "New Patient",
+		"email":    "new@example.com",
+		"password": "hunter22",
+	}, "")
+	expectStatus(t, rec, http.StatusCreated)
+
+	var created struct {
+		Data struct {
+			Email string          `json:"email"`
+			Role  models.UserRole `json:"role"`
+		} `json:"data"`
+	}
+	decode(t, rec, &created)
+	if created.Data.Email != "new@example.com" || created.Data.Role != models.RolePatient {
+		t.Fatalf("unexpected user %+v", created.Data)
+	}
+
+	token := s.login("new@example.com", "hunter22")
+	rec = s.request(http.MethodGet, "/me", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestSignupProvider(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodPost, "/auth/register", gin.H{
+		"name":              "New Provider",
+		"email":             "doctor@example.com",
+		"password":          "hunter22",
+		"role":              "provider",
+		"specialization_id": s.fixtures.Specialization.ID,
+	}, "")
+	expectStatus(t, rec, http.StatusCreated)
+
+	var user models.User
+	if err := s.db.Where("email = ?", "doctor@example.com").First(&user).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	var count int64
+	s.db.Model(&models.Provider{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("expected a provider profile for the new provider, found %d", count)
+	}
+}
+
+func TestSignupRejectsDuplicateEmail(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodPost, "/auth/register", gin.H{
+		"name":     "Someone",
+		"email":    s.fixtures.Patient.Email,
+		"password": "hunter22",
+	}, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodPost, "/auth/login", gin.H{
+		"email":    s.fixtures.Patient.Email,
+		"password": "not-the-password",
+	}, "")
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestProfileRequiresToken(t *testing.T) {
+	s := newTestServer(t)
+
+	expectStatus(t, s.request(http.MethodGet, "/me", nil, ""), http.StatusUnauthorized)
+	expectStatus(t, s.request(http.MethodGet, "/me", nil, "not-a-jwt"), http.StatusUnauthorized)
+}
+
+func TestProfileOfEachRole(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, role := range []models.UserRole{models.RolePatient, models.RoleProvider, models.RoleAdmin} {
+		rec := s.requestAs(role, http.MethodGet, "/me", nil)
+		expectStatus(t, rec, http.StatusOK)
+
+		var body struct {
+			User struct {
+				Role models.UserRole `json:"role"`
+			} `json:"user"`
+		}
+		decode(t, rec, &body)
+		if body.User.Role != role {
+			t.Errorf("logged in as %s, /me returned %s", role, body.User.Role)
+		}
+	}
+}
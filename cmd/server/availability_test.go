@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/controllers"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// createAvailability posts a one-off availability of the fixture provider and returns its id
+func (s *testServer) createAvailability(date, start, end string) uint {
+	s.t.Helper()
+	rec := s.request(http.MethodPost, "/availabilities/", gin.H{
+		"provider_id": s.fixtures.Provider.ID,
+		"date":        date,
+		"start_time":  start,
+		"end_time":    end,
+	}, "")
+	expectStatus(s.t, rec, http.StatusCreated)
+
+	var body struct {
+		Data struct {
+			Availability models.Availability `json:"availability"`
+		} `json:"data"`
+	}
+	decode(s.t, rec, &body)
+	return body.Data.Availability.ID
+}
+
+func TestCreateAvailabilityGeneratesSlots(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createAvailability("2030-01-07", "09:00", "10:00")
+
+	rec := s.request(http.MethodGet, path("/availabilities/", id), nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	var body struct {
+		Data controllers.AvailabilityResponse `json:"data"`
+	}
+	decode(t, rec, &body)
+	if body.Data.StartTime != "09:00" || body.Data.EndTime != "10:00" {
+		t.Fatalf("unexpected hours %s-%s", body.Data.StartTime, body.Data.EndTime)
+	}
+	if len(body.Data.Slots) != 2 {
+		t.Fatalf("expected 2 slots of 30 minutes, got %+v", body.Data.Slots)
+	}
+}
+
+func TestCreateAvailabilityRejectsOverlap(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+
+	rec := s.request(http.MethodPost, "/availabilities/", gin.H{
+		"provider_id": s.fixtures.Provider.ID,
+		"date":        "2030-01-07",
+		"start_time":  "11:00",
+		"end_time":    "13:00",
+	}, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// the same hours on another day are fine
+	s.createAvailability("2030-01-08", "11:00", "13:00")
+}
+
+func TestCreateAvailabilityUnknownProvider(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodPost, "/availabilities/", gin.H{
+		"provider_id": 999,
+		"date":        "2030-01-07",
+		"start_time":  "09:00",
+		"end_time":    "10:00",
+	}, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestListAvailabilities(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "10:00")
+	s.createAvailability("2030-01-08", "09:00", "10:00")
+
+	rec := s.request(http.MethodGet, path("/availabilities/?provider_id=", s.fixtures.Provider.ID), nil, "")
+	expectStatus(t, rec, http.StatusOK)
+	var all struct {
+		Data []controllers.AvailabilityResponse `json:"data"`
+	}
+	decode(t, rec, &all)
+	if len(all.Data) != 2 {
+		t.Fatalf("expected 2 availabilities, got %d", len(all.Data))
+	}
+
+	rec = s.request(http.MethodGet, "/availabilities/?date=2030-01-08", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+	var onDate struct {
+		Data []controllers.AvailabilityResponse `json:"data"`
+	}
+	decode(t, rec, &onDate)
+	if len(onDate.Data) != 1 {
+		t.Fatalf("expected 1 availability on 2030-01-08, got %d", len(onDate.Data))
+	}
+
+	expectStatus(t, s.request(http.MethodGet, "/availabilities/?provider_id=abc", nil, ""), http.StatusBadRequest)
+}
+
+func TestUpdateAvailabilityReplacesSlots(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createAvailability("2030-01-07", "09:00", "10:00")
+
+	rec := s.request(http.MethodPut, path("/availabilities/", id), gin.H{
+		"provider_id": s.fixtures.Provider.ID,
+		"date":        "2030-01-07T00:00:00Z",
+		"start_time":  "14:00",
+		"end_time":    "16:00",
+	}, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	var slots []models.AvailabilitySlot
+	if err := s.db.Where("availability_id = ?", id).Order("start_time").Find(&slots).Error; err != nil {
+		t.Fatalf("load slots: %v", err)
+	}
+	if len(slots) == 0 || slots[0].StartTime != "14:00" {
+		t.Fatalf("slots were not regenerated: %+v", slots)
+	}
+}
+
+func TestDeleteAvailability(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createAvailability("2030-01-07", "09:00", "10:00")
+
+	expectStatus(t, s.request(http.MethodDelete, path("/availabilities/", id), nil, ""), http.StatusOK)
+	expectStatus(t, s.request(http.MethodGet, path("/availabilities/", id), nil, ""), http.StatusNotFound)
+	expectStatus(t, s.request(http.MethodDelete, path("/availabilities/", id), nil, ""), http.StatusNotFound)
+}
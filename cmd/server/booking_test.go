@@ -0,0 +1,347 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/payments"
+	"github.com/gin-gonic/gin"
+)
+
+// bookingStart is the start of a fixture booking: Monday 2030-01-07 at 09:00 UTC
+const bookingStart = "2030-01-07T09:00:00Z"
+
+// bookingResponse decodes the booking in the data of a response
+type bookingResponse struct {
+	Data models.Booking `json:"data"`
+}
+
+// createBooking books the fixture service for the patient and returns the response
+func (s *testServer) createBooking(start string) *bookingResponse {
+	s.t.Helper()
+	rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/", s.bookingInput(start))
+	expectStatus(s.t, rec, http.StatusCreated)
+
+	var body bookingResponse
+	decode(s.t, rec, &body)
+	return &body
+}
+
+// bookingInput is the body of POST /bookings for the fixture patient and service
+func (s *testServer) bookingInput(start string) gin.H {
+	return gin.H{
+		"patient_id":  s.fixtures.Patient.ID,
+		"provider_id": s.fixtures.Provider.ID,
+		"service_id":  s.fixtures.Service.ID,
+		"start_time":  start,
+	}
+}
+
+func TestCreateBooking(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+
+	booking := s.createBooking(bookingStart).Data
+	if booking.Status != models.Pending || booking.PaymentStatus != models.PaymentUnpaid {
+		t.Fatalf("expected a pending unpaid booking, got %s/%s", booking.Status, booking.PaymentStatus)
+	}
+	if want := booking.StartTime.Add(30 * time.Minute); !booking.EndTime.Equal(want) {
+		t.Fatalf("expected the booking to last the 30 minutes of the service, ends at %s", booking.EndTime)
+	}
+	if booking.Amount != s.fixtures.Service.Price {
+		t.Fatalf("expected amount %v, got %v", s.fixtures.Service.Price, booking.Amount)
+	}
+}
+
+func TestCreateBookingRequiresAuth(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+
+	rec := s.request(http.MethodPost, "/bookings/", s.bookingInput(bookingStart), "")
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestCreateBookingConflicts(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	s.createBooking(bookingStart)
+
+	cases := map[string]string{
+		"overlapping booking":    "2030-01-07T09:15:00Z",
+		"outside availabilities": "2030-01-07T15:00:00Z",
+	}
+	for name, start := range cases {
+		rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/", s.bookingInput(start))
+		if rec.Code != http.StatusConflict {
+			t.Errorf("%s: expected status %d, got %d: %s", name, http.StatusConflict, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestCreateBookingProviderBusyInExternalCalendar(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+
+	start, _ := time.Parse(time.RFC3339, bookingStart)
+	s.create(&models.ExternalBusyTime{
+		ExternalCalendarID: 1,
+		ProviderID:         s.fixtures.Provider.ID,
+		StartTime:          start,
+		EndTime:            start.Add(time.Hour),
+	})
+
+	rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/", s.bookingInput(bookingStart))
+	expectStatus(t, rec, http.StatusConflict)
+}
+
+func TestConfirmBooking(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	booking := s.createBooking(bookingStart).Data
+
+	rec := s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/confirm", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusOK)
+	var confirmed bookingResponse
+	decode(t, rec, &confirmed)
+	if confirmed.Data.Status != models.Confirmed {
+		t.Fatalf("expected a confirmed booking, got %s", confirmed.Data.Status)
+	}
+
+	// only pending bookings can be confirmed
+	rec = s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/confirm", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/confirm", gin.H{"id": 999})
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestCancelBookingFreesTheSlot(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	booking := s.createBooking(bookingStart).Data
+
+	rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID, "reason": "can't make it"})
+	expectStatus(t, rec, http.StatusOK)
+
+	var stored models.Booking
+	if err := s.db.First(&stored, booking.ID).Error; err != nil {
+		t.Fatalf("load booking: %v", err)
+	}
+	if stored.Status != models.Cancelled || stored.CancelledAt == nil {
+		t.Fatalf("expected a cancelled booking, got %s", stored.Status)
+	}
+
+	rec = s.requestAs(models.RolePatient, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// the slot can be booked again
+	s.createBooking(bookingStart)
+}
+
+// payBooking records a paid payment of the booking through the given gateway
+func (s *testServer) payBooking(booking models.Booking, gateway string) models.Payment {
+	s.t.Helper()
+	payment := models.Payment{
+		BookingID:  booking.ID,
+		Gateway:    gateway,
+		ExternalID: path("pi_", booking.ID),
+		Amount:     booking.Amount,
+		Status:     models.PaymentPaid,
+	}
+	s.create(&payment)
+	if err := s.db.Model(&booking).Update("payment_status", models.PaymentPaid).Error; err != nil {
+		s.t.Fatalf("pay booking: %v", err)
+	}
+	return payment
+}
+
+func TestCancelPaidBookingRefundsIt(t *testing.T) {
+	s := newTestServer(t)
+	payments.Register(payments.NewFakeGateway(""))
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+
+	// the gateway is gone: the refund fails and releases the amount it reserved
+	booking := s.createBooking(bookingStart).Data
+	payment := s.payBooking(booking, "retired")
+	expectStatus(t, s.requestAs(models.RolePatient, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID}), http.StatusOK)
+
+	var refunds []models.Refund
+	if err := s.db.Where("payment_id = ?", payment.ID).Find(&refunds).Error; err != nil {
+		t.Fatalf("load refunds: %v", err)
+	}
+	if err := s.db.First(&payment, payment.ID).Error; err != nil {
+		t.Fatalf("load payment: %v", err)
+	}
+	if len(refunds) != 1 || refunds[0].Status != models.RefundFailed || payment.RefundedAmount != 0 || payment.Status != models.PaymentPaid {
+		t.Fatalf("expected one failed refund and nothing refunded, got %+v and %v %s", refunds, payment.RefundedAmount, payment.Status)
+	}
+
+	booking = s.createBooking("2030-01-07T10:00:00Z").Data
+	payment = s.payBooking(booking, payments.FakeGatewayName)
+	expectStatus(t, s.requestAs(models.RolePatient, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID}), http.StatusOK)
+
+	if err := s.db.First(&payment, payment.ID).Error; err != nil {
+		t.Fatalf("load payment: %v", err)
+	}
+	if payment.Status != models.PaymentRefunded || payment.RefundedAmount != booking.Amount {
+		t.Fatalf("expected %v refunded, got %v (%s)", booking.Amount, payment.RefundedAmount, payment.Status)
+	}
+	var stored models.Booking
+	if err := s.db.First(&stored, booking.ID).Error; err != nil {
+		t.Fatalf("load booking: %v", err)
+	}
+	if stored.PaymentStatus != models.PaymentRefunded {
+		t.Fatalf("expected a refunded booking, got %s", stored.PaymentStatus)
+	}
+}
+
+func TestCancelBookingOfAnotherPatient(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	booking := s.createBooking(bookingStart).Data
+
+	other := s.createUser("Other Patient", "other@example.com", models.RolePatient)
+	rec := s.request(http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID}, s.login(other.Email, testPassword))
+	expectStatus(t, rec, http.StatusForbidden)
+
+	// the provider may cancel any of their bookings
+	rec = s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestCancelBookingAfterItStarted(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	booking := s.createBooking(bookingStart).Data
+
+	start := time.Now().Add(-10 * time.Minute)
+	if err := s.db.Model(&models.Booking{}).Where("id = ?", booking.ID).
+		Updates(map[string]interface{}{"start_time": start, "end_time": start.Add(30 * time.Minute)}).Error; err != nil {
+		t.Fatalf("move booking to the past: %v", err)
+	}
+
+	// too late for the patient: the provider reports a no-show instead
+	rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestListBookingsIsAdminOnly(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	s.createBooking(bookingStart)
+	s.createBooking("2030-01-07T10:00:00Z")
+
+	expectStatus(t, s.requestAs(models.RolePatient, http.MethodGet, "/bookings/", nil), http.StatusForbidden)
+	expectStatus(t, s.requestAs(models.RoleProvider, http.MethodGet, "/bookings/", nil), http.StatusForbidden)
+
+	rec := s.requestAs(models.RoleAdmin, http.MethodGet, "/bookings/?status=pending", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var body struct {
+		Data []models.Booking `json:"data"`
+	}
+	decode(t, rec, &body)
+	if len(body.Data) != 2 {
+		t.Fatalf("expected 2 pending bookings, got %d", len(body.Data))
+	}
+}
+
+func TestListBookingsCursors(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	first := s.createBooking(bookingStart).Data
+	second := s.createBooking("2030-01-07T10:00:00Z").Data
+
+	type page struct {
+		Data       []models.Booking `json:"data"`
+		Pagination struct {
+			NextCursor string `json:"next_cursor"`
+		} `json:"pagination"`
+	}
+	rec := s.requestAs(models.RoleAdmin, http.MethodGet, "/bookings/?limit=1", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var body page
+	decode(t, rec, &body)
+	if len(body.Data) != 1 || body.Data[0].ID != first.ID || body.Pagination.NextCursor == "" {
+		t.Fatalf("expected booking %d and a next cursor, got %+v", first.ID, body)
+	}
+
+	rec = s.requestAs(models.RoleAdmin, http.MethodGet, "/bookings/?limit=1&cursor="+body.Pagination.NextCursor, nil)
+	expectStatus(t, rec, http.StatusOK)
+	body = page{}
+	decode(t, rec, &body)
+	if len(body.Data) != 1 || body.Data[0].ID != second.ID {
+		t.Fatalf("expected booking %d on the second page, got %+v", second.ID, body.Data)
+	}
+
+	// an offset cursor, as ranked provider searches return, does not page bookings
+	rec = s.requestAs(models.RoleAdmin, http.MethodGet, "/bookings/?limit=1&cursor=eyJvIjoxfQ", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+	if !strings.Contains(rec.Body.String(), "invalid cursor") {
+		t.Fatalf("expected an invalid cursor error, got %s", rec.Body)
+	}
+
+	// a keyset cursor whose time value does not parse: {"s":"start_time","v":"x","t":true}
+	rec = s.requestAs(models.RoleAdmin, http.MethodGet, "/bookings/?limit=1&sort=start_time&cursor=eyJzIjoic3RhcnRfdGltZSIsInYiOiJ4IiwidCI6dHJ1ZX0", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+	if !strings.Contains(rec.Body.String(), "invalid cursor") {
+		t.Fatalf("expected an invalid cursor error, got %s", rec.Body)
+	}
+}
+
+func TestCompleteBooking(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	booking := s.createBooking(bookingStart).Data
+	expectStatus(t, s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/confirm", gin.H{"id": booking.ID}), http.StatusOK)
+
+	// only the provider (or an admin) may complete it
+	rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/complete", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	// not before the appointment has started
+	rec = s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/complete", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	start := time.Now().Add(-time.Hour)
+	if err := s.db.Model(&models.Booking{}).Where("id = ?", booking.ID).
+		Updates(map[string]interface{}{"start_time": start, "end_time": start.Add(30 * time.Minute)}).Error; err != nil {
+		t.Fatalf("move booking to the past: %v", err)
+	}
+
+	rec = s.requestAs(models.RoleProvider, http.MethodPost, "/bookings/complete", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusOK)
+	var completed bookingResponse
+	decode(t, rec, &completed)
+	if completed.Data.Status != models.Completed {
+		t.Fatalf("expected a completed booking, got %s", completed.Data.Status)
+	}
+
+	// completion issued the invoice; its payment status follows the booking's
+	invoice := func() models.Invoice {
+		t.Helper()
+		rec := s.requestAs(models.RolePatient, http.MethodGet, path("/bookings/", booking.ID)+"/invoice", nil)
+		expectStatus(t, rec, http.StatusOK)
+		var body struct {
+			Data models.Invoice `json:"data"`
+		}
+		decode(t, rec, &body)
+		return body.Data
+	}
+	issued := invoice()
+	if issued.PaymentStatus != models.PaymentUnpaid {
+		t.Fatalf("expected an unpaid invoice, got %s", issued.PaymentStatus)
+	}
+	if err := s.db.Model(&models.Booking{}).Where("id = ?", booking.ID).
+		Update("payment_status", models.PaymentPaid).Error; err != nil {
+		t.Fatalf("pay booking: %v", err)
+	}
+	if paid := invoice(); paid.PaymentStatus != models.PaymentPaid || paid.Number != issued.Number {
+		t.Fatalf("expected invoice %s marked paid, got %s %s", issued.Number, paid.Number, paid.PaymentStatus)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// countQueries counts the SELECTs run on the test database from now on
+func (s *testServer) countQueries() *atomic.Int64 {
+	s.t.Helper()
+	var count atomic.Int64
+	if err := s.db.Callback().Query().Before("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		count.Add(1)
+	}); err != nil {
+		s.t.Fatalf("count queries: %v", err)
+	}
+	return &count
+}
+
+func TestCalendarFeedLoadsNamesInBatches(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	s.createBooking(bookingStart)
+
+	token := "feed-token"
+	if err := s.db.Model(&models.User{}).Where("id = ?", s.fixtures.ProviderUser.ID).
+		Update("calendar_token", token).Error; err != nil {
+		t.Fatalf("set calendar token: %v", err)
+	}
+	queries := s.countQueries()
+
+	feed := func() string {
+		t.Helper()
+		queries.Store(0)
+		rec := s.request(http.MethodGet, "/calendar/"+token+".ics", nil, "")
+		expectStatus(t, rec, http.StatusOK)
+		return rec.Body.String()
+	}
+
+	body := feed()
+	single := queries.Load()
+	if !strings.Contains(body, "SUMMARY:Check-up – Patient") {
+		t.Fatalf("expected the service and patient in the summary, got:\n%s", body)
+	}
+
+	s.createBooking("2030-01-07T10:00:00Z")
+	s.createBooking("2030-01-07T11:00:00Z")
+	body = feed()
+	if events := strings.Count(body, "BEGIN:VEVENT"); events != 3 {
+		t.Fatalf("expected 3 events, got %d", events)
+	}
+	if queries.Load() != single {
+		t.Fatalf("expected the feed of 3 bookings to run the %d queries of 1 booking, ran %d", single, queries.Load())
+	}
+}
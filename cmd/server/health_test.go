@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+)
+
+func TestHealthz(t *testing.T) {
+	s := newTestServer(t)
+	expectStatus(t, s.request(http.MethodGet, "/healthz", nil, ""), http.StatusOK)
+}
+
+func TestReadyz(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodGet, "/readyz", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+	var body struct {
+		Data []health.Result `json:"data"`
+	}
+	decode(t, rec, &body)
+	if len(body.Data) != 2 || body.Data[0].Name != "database" || body.Data[0].Status != "ok" {
+		t.Fatalf("unexpected checks %+v", body.Data)
+	}
+}
+
+func TestReadyzReportsFailingChecks(t *testing.T) {
+	s := newTestServer(t)
+	router := newRouter(s.cfg, repository.NewGorm(s.db), []health.Check{
+		{Name: "database", Run: func(context.Context) error { return nil }},
+		{Name: "migrations", Run: func(context.Context) error { return errors.New("2 pending migrations") }},
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	expectStatus(t, rec, http.StatusServiceUnavailable)
+
+	var body struct {
+		Data []health.Result `json:"data"`
+	}
+	decode(t, rec, &body)
+	if body.Data[1].Status != "failing" || body.Data[1].Error != "2 pending migrations" {
+		t.Fatalf("expected the failing migrations check, got %+v", body.Data)
+	}
+}
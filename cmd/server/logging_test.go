@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	s := newTestServer(t)
+
+	serve := func(id string) string {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		if id != "" {
+			req.Header.Set(middleware.RequestIDHeader, id)
+		}
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		return rec.Header().Get(middleware.RequestIDHeader)
+	}
+
+	if got := serve("edge-42"); got != "edge-42" {
+		t.Errorf("expected the request ID of the proxy to be kept, got %q", got)
+	}
+	generated := serve("")
+	if generated == "" || generated == serve("") {
+		t.Errorf("expected a new request ID per request, got %q", generated)
+	}
+	if got := serve("bad id\nforged=line"); got == "" || got == "bad id\nforged=line" {
+		t.Errorf("expected an invalid request ID to be replaced, got %q", got)
+	}
+}
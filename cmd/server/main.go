@@ -1,129 +1,171 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/adriel-meb/appointly-backend/internal/calendar"
 	"github.com/adriel-meb/appointly-backend/internal/config"
 	"github.com/adriel-meb/appointly-backend/internal/controllers"
 	"github.com/adriel-meb/appointly-backend/internal/db"
-	"github.com/adriel-meb/appointly-backend/internal/middleware"
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"log"
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/adriel-meb/appointly-backend/internal/logging"
+	"github.com/adriel-meb/appointly-backend/internal/metrics"
+	"github.com/adriel-meb/appointly-backend/internal/notifications"
+	"github.com/adriel-meb/appointly-backend/internal/payments"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/adriel-meb/appointly-backend/internal/tracing"
+	"github.com/adriel-meb/appointly-backend/internal/webhooks"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 )
 
-func init() {
-	config.LoadEnvVariables()
-	db.DbConnect()
-	db.DbMigration()
+// setup loads and validates the configuration, connects and migrates the
+// database and registers the payment gateways. It runs from main rather than
+// init so tests can build the router against their own database.
+func setup() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+	logging.Setup(cfg.Log)
 
-}
+	// `server migrate ...` manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:], cfg.Database))
+	}
 
-func main() {
-	router := gin.Default()
-
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:4000", "http://localhost:3000", "http://192.168.13.133:4000"}, // frontend URL
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-
-	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
-		log.Printf("endpoint %v %v %v %v", httpMethod, absolutePath, handlerName, absolutePath)
+	if err := cfg.Validate(); err != nil {
+		fatal("invalid configuration", err)
 	}
 
-	router.GET("/", controllers.GetWelcome)
-	router.POST("/auth/register", controllers.Signup)
-	router.POST("/auth/login", controllers.Login)
-	router.POST("/auth/logout", controllers.Logout)
-	router.GET("/me", middleware.RequireAuthMiddleware(), controllers.GetProfile)
-
-	router.GET("/users", controllers.GetAllUsers)
-	router.DELETE("/users/:email", controllers.DeleteUser)
-
-	router.GET("/validate", middleware.RequireAuthMiddleware(), controllers.Validate)
-
-	// Provider routes
-	providers := router.Group("/providers")
-	{
-		providers.GET("/", controllers.GetAllProviders)
-		providers.GET("/:id", controllers.GetProviderByID)
-		providers.POST("/", controllers.CreateProvider)
-		providers.PUT("/:id", controllers.UpdateProvider)
-		providers.DELETE("/:id", middleware.RequireAuthMiddleware(), controllers.DeleteProvider)
+	db.DbConnect(cfg.Database)
+	// DB_AUTO_MIGRATE=false leaves migrations to a separate `migrate up` step
+	if cfg.Database.AutoMigrate {
+		db.DbMigration()
 	}
 
-	// Specialization routes - ADD AUTHORIZATION LATER
-	specializations := router.Group("/specializations")
-	{
-		specializations.GET("/", controllers.GetAllSpecializations)
-		specializations.POST("/", controllers.CreateSpecialization)
-		specializations.PUT("/:id", controllers.UpdateSpecialization)
-		specializations.DELETE("/:id", controllers.DeleteSpecialization)
+	// Payment gateway; PAYMENT_GATEWAY picks the one used for new payments
+	if err := registerGateway(cfg.Payments); err != nil {
+		fatal("invalid configuration", err)
 	}
+	return cfg
+}
 
-	// Service route
-	services := router.Group("/services")
-	{
-		services.POST("/", controllers.CreateService)
-		services.GET("/", controllers.GetAllServices)
-		services.GET("/:id", controllers.GetServiceByID)
-		services.PUT("/", controllers.UpdateServices)
-		services.DELETE("/", controllers.DeleteServices)
+// registerGateway registers the configured payment gateway only, so no other
+// gateway accepts webhooks
+func registerGateway(cfg config.PaymentsConfig) error {
+	switch cfg.Gateway {
+	case payments.FakeGatewayName:
+		payments.Register(payments.NewFakeGateway(cfg.WebhookSecret))
+	default:
+		return fmt.Errorf("unknown payment gateway: %s", cfg.Gateway)
 	}
+	return payments.SetDefault(cfg.Gateway)
+}
 
-	// Availability routes
-	availabilities := router.Group("/availabilities")
-	{
-		// 1️⃣ Create a new availability
-		// POST /availabilities/
-		availabilities.POST("/", controllers.CreateAvailability)
+func main() {
+	cfg := setup()
+
+	// SIGINT/SIGTERM start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		// 2️⃣ Get all availabilities with optional filters
-		// GET /availabilities/?provider_id=3&date=2025-09-20&start_date=2025-09-20&end_date=2025-09-30
-		availabilities.GET("/", controllers.GetAllAvailability)
+	// TRACING_EXPORTER=none leaves tracing off
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
 
-		// 3️⃣ Get a specific availability by ID
-		// GET /availabilities/:id
-		availabilities.GET("/:id", controllers.GetAvailabilityByID)
+	readiness := []health.Check{
+		{Name: "database", Run: db.Ping},
+		{Name: "migrations", Run: db.CheckMigrations},
+		health.Workers(),
+	}
+	if sqlDB, err := db.DB.DB(); err == nil {
+		metrics.RegisterDB(sqlDB)
+	}
+	router := newRouter(cfg, repository.NewGorm(db.DB), readiness)
 
-		// 4️⃣ Update an existing availability
-		// PUT /availabilities/:id
-		availabilities.PUT("/:id", controllers.UpdateAvailability)
+	// Background workers run until the server has drained its requests, so
+	// webhooks queued by the last ones are still sent
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
 
-		// 5️⃣ Delete an availability
-		// DELETE /availabilities/:id
-		availabilities.DELETE("/:id", controllers.DeleteAvailability)
+	// Deliver notifications held back by quiet hours
+	workers.Go(func() { notifications.RunScheduler(workersCtx, time.Minute) })
+
+	// Refresh busy times imported from providers' external calendars
+	workers.Go(func() { calendar.RunSync(workersCtx, 30*time.Minute) })
+
+	// Send the webhooks queued with booking/availability changes and retry failures
+	workers.Go(func() { webhooks.RunWorker(workersCtx, 30*time.Second) })
+
+	server := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	// Event streams never end on their own: close them so the drain can finish
+	server.RegisterOnShutdown(controllers.CloseEventStreams)
+
+	// Start the server
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server failed", "error", err)
+		}
+		stop()
+	case <-ctx.Done():
+		slog.Info("shutting down: draining requests and stopping workers")
 	}
 
-	bookings := router.Group("/bookings").Use(middleware.RequireAuthMiddleware())
-	{
-		bookings.POST("/", controllers.CreateBooking)
-		bookings.GET("/", controllers.GetAllBooking)
-		bookings.POST("/confirm", controllers.ConfirmBooking)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting requests and wait for those in flight
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("shutdown: requests still running", "timeout", cfg.Server.ShutdownTimeout, "error", err)
 	}
 
-	// Insurance
-	insurances := router.Group("/insurances")
-	{
-		insurances.POST("/", controllers.CreateInsurance)
-		insurances.GET("/", controllers.GetAllInsurances)
-		insurances.GET("/:id", controllers.GetInsuranceByID)
-		insurances.PUT("/:id", controllers.UpdateInsurance)
-		insurances.DELETE("/:id", controllers.DeleteInsurance)
+	// Stop the workers and wait for their current run to finish
+	stopWorkers()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		slog.Warn("shutdown: workers still running, exiting anyway")
 	}
 
-	cities := router.Group("/cities")
-	{
-		cities.POST("/", controllers.CreateCity)
-		cities.GET("/", controllers.GetAllCities)
-		cities.GET("/:id", controllers.GetCityByID)
-		cities.PUT("/:id", controllers.UpdateCity)
-		cities.DELETE("/:id", controllers.DeleteCity)
+	if err := db.Close(); err != nil {
+		slog.Error("shutdown: closing database", "error", err)
+	}
+	// Export the spans of the last requests and jobs
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("shutdown: flushing traces", "error", err)
 	}
+	slog.Info("server stopped")
+}
 
-	// Start the server
-	router.Run()
+// fatal logs an error preventing the server from starting and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
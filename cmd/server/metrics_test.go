@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/metrics"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCountRequestsAndBookings(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+
+	created := metrics.Bookings.WithLabelValues(metrics.BookingCreated, "Dentist")
+	requests := metrics.HTTPRequests.WithLabelValues(http.MethodPost, "/bookings/", "201")
+	before, requestsBefore := testutil.ToFloat64(created), testutil.ToFloat64(requests)
+
+	booking := s.createBooking(bookingStart).Data
+	rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusOK)
+
+	if got := testutil.ToFloat64(created) - before; got != 1 {
+		t.Errorf("expected 1 booking created in Dentist, counted %v", got)
+	}
+	if got := testutil.ToFloat64(requests) - requestsBefore; got != 1 {
+		t.Errorf("expected the request to be counted under its route, counted %v", got)
+	}
+
+	rec = s.request(http.MethodGet, "/metrics", nil, s.cfg.Metrics.Token)
+	expectStatus(t, rec, http.StatusOK)
+	for _, series := range []string{
+		`appointly_bookings_total{event="cancelled",specialization="Dentist"}`,
+		`appointly_http_request_duration_seconds_bucket{method="POST",route="/bookings/",status="201"`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(rec.Body.String(), series) {
+			t.Errorf("expected %s in the metrics", series)
+		}
+	}
+}
+
+func TestMetricsToken(t *testing.T) {
+	s := newTestServer(t)
+	s.cfg.Metrics.Token = "scraper-token"
+	router := newRouter(s.cfg, repository.NewGorm(s.db), nil)
+
+	scrape := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := scrape(""); code != http.StatusUnauthorized {
+		t.Errorf("expected %d without a token, got %d", http.StatusUnauthorized, code)
+	}
+	if code := scrape("wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected %d with a wrong token, got %d", http.StatusUnauthorized, code)
+	}
+	if code := scrape("scraper-token"); code != http.StatusOK {
+		t.Errorf("expected %d with the token, got %d", http.StatusOK, code)
+	}
+
+	// without a token configured, nobody gets in
+	s.cfg.Metrics.Token = ""
+	router = newRouter(s.cfg, repository.NewGorm(s.db), nil)
+	if code := scrape(""); code != http.StatusUnauthorized {
+		t.Errorf("expected %d when no token is configured, got %d", http.StatusUnauthorized, code)
+	}
+}
+
+func TestMetricsLabelUnknownMethodsAsOther(t *testing.T) {
+	s := newTestServer(t)
+	other := metrics.HTTPRequests.WithLabelValues("other", "unmatched", "404")
+	before := testutil.ToFloat64(other)
+
+	for _, method := range []string{"PROPFIND", "X-RANDOM-1", "X-RANDOM-2"} {
+		s.request(method, "/nowhere", nil, "")
+	}
+
+	if got := testutil.ToFloat64(other) - before; got != 3 {
+		t.Errorf("expected the 3 requests counted under method \"other\", counted %v", got)
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	s := newTestServer(t)
+	s.cfg.Metrics.Enabled = false
+	router := newRouter(s.cfg, repository.NewGorm(s.db), nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	expectStatus(t, rec, http.StatusNotFound)
+}
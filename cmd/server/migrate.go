@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/adriel-meb/appointly-backend/internal/config"
+	"github.com/adriel-meb/appointly-backend/internal/db"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+  up             apply every pending migration
+  down [n]       revert the last n applied migrations (default 1)
+  status         list migrations and when they were applied
+  create <name>  add empty up/down files to ` + db.MigrationsDir
+
+// runMigrate runs a migrate subcommand against the database of cfg and returns
+// the process exit code
+func runMigrate(args []string, cfg config.DatabaseConfig) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		up, down, err := db.CreateMigration(db.MigrationsDir, strings.Join(args[1:], "_"))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "create failed:", err)
+			return 1
+		}
+		fmt.Println("created", up)
+		fmt.Println("created", down)
+		return 0
+
+	case "up":
+		if !connect(cfg) {
+			return 1
+		}
+		applied, err := db.MigrateUp(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "up failed:", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return 0
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fmt.Fprintln(os.Stderr, "down: n must be a positive number")
+				return 2
+			}
+			steps = n
+		}
+		if !connect(cfg) {
+			return 1
+		}
+		reverted, err := db.MigrateDown(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "down failed:", err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+		return 0
+
+	case "status":
+		if !connect(cfg) {
+			return 1
+		}
+		statuses, err := db.MigrationStatuses(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "status failed:", err)
+			return 1
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, applied)
+		}
+		return 0
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+}
+
+// connect opens the database, or reports an incomplete configuration
+func connect(cfg config.DatabaseConfig) bool {
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		return false
+	}
+	db.DbConnect(cfg)
+	return true
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/controllers"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+// searchAvailable lists the providers with a free slot for the query
+func (s *testServer) searchAvailable(query string) []controllers.ProviderResponse {
+	s.t.Helper()
+	rec := s.request(http.MethodGet, "/providers/?"+query, nil, "")
+	expectStatus(s.t, rec, http.StatusOK)
+
+	var body struct {
+		Data []controllers.ProviderResponse `json:"data"`
+	}
+	decode(s.t, rec, &body)
+	return body.Data
+}
+
+// expectEarliestSlot checks the fixture provider is the only one found, free at start
+func expectEarliestSlot(t *testing.T, providers []controllers.ProviderResponse, start time.Time) {
+	t.Helper()
+	if len(providers) != 1 || providers[0].EarliestSlot == nil {
+		t.Fatalf("expected the provider with a free slot, got %+v", providers)
+	}
+	if slot := providers[0].EarliestSlot; !slot.StartTime.Equal(start) || !slot.EndTime.Equal(start.Add(30*time.Minute)) {
+		t.Fatalf("expected the slot at %s, got %s-%s", start, slot.StartTime, slot.EndTime)
+	}
+}
+
+// Availability times are in Libreville time: 09:00 is 08:00 UTC
+var searchSlot = time.Date(2030, 1, 7, 8, 0, 0, 0, time.UTC)
+
+func TestSearchProvidersByAvailability(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "09:30")
+
+	expectEarliestSlot(t, s.searchAvailable("available_from=2030-01-07&available_to=2030-01-07"), searchSlot)
+	expectEarliestSlot(t, s.searchAvailable("available_from=2030-01-07T07:00:00Z&available_to=2030-01-07T12:00:00Z"), searchSlot)
+	expectEarliestSlot(t, s.searchAvailable("available_from=2030-01-07T09:00&available_to=2030-01-07T09:30"), searchSlot)
+
+	if providers := s.searchAvailable("available_from=2030-01-08&available_to=2030-01-09"); len(providers) != 0 {
+		t.Fatalf("expected no provider on other days, got %+v", providers)
+	}
+	if providers := s.searchAvailable("available_from=2030-01-07T08:15:00Z&available_to=2030-01-07T12:00:00Z"); len(providers) != 0 {
+		t.Fatalf("expected no provider once the slot has started, got %+v", providers)
+	}
+}
+
+func TestSearchProvidersByAvailabilityWindowLimit(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodGet, "/providers/?available_from=2030-01-01&available_to=2030-02-15", nil, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = s.request(http.MethodGet, "/providers/?available_from=2030-01-08&available_to=2030-01-07", nil, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestSearchProvidersByAvailabilitySkipsBookedSlot(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "09:30")
+	s.create(&models.Booking{
+		PatientID:  s.fixtures.Patient.ID,
+		ProviderID: s.fixtures.Provider.ID,
+		ServiceID:  s.fixtures.Service.ID,
+		StartTime:  searchSlot,
+		EndTime:    searchSlot.Add(30 * time.Minute),
+		Status:     models.Confirmed,
+	})
+
+	if providers := s.searchAvailable("available_from=2030-01-07&available_to=2030-01-07"); len(providers) != 0 {
+		t.Fatalf("expected the booked provider to be left out, got %+v", providers)
+	}
+}
+
+func TestSearchProvidersByAvailabilitySkipsExternalBusyTime(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-08", "09:00", "09:30")
+	slot := searchSlot.AddDate(0, 0, 1)
+	s.create(&models.ExternalBusyTime{
+		ExternalCalendarID: 1,
+		ProviderID:         s.fixtures.Provider.ID,
+		StartTime:          slot.Add(-time.Hour),
+		EndTime:            slot.Add(time.Hour),
+	})
+
+	if providers := s.searchAvailable("available_from=2030-01-08&available_to=2030-01-08"); len(providers) != 0 {
+		t.Fatalf("expected the provider busy elsewhere to be left out, got %+v", providers)
+	}
+}
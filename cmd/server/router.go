@@ -0,0 +1,222 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/config"
+	"github.com/adriel-meb/appointly-backend/internal/controllers"
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/adriel-meb/appointly-backend/internal/metrics"
+	"github.com/adriel-meb/appointly-backend/internal/middleware"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedPaths are polled by probes and Prometheus: their traces would bury the others
+var untracedPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+func traced(r *http.Request) bool {
+	return !untracedPaths[r.URL.Path]
+}
+
+// newRouter builds the API: middleware, controllers wired to repos, and every route.
+// GET /readyz runs the readiness checks.
+func newRouter(cfg *config.Config, repos repository.Repositories, readiness []health.Check) *gin.Engine {
+	// Each request gets a trace and an ID, carried by its log lines, before anything else runs
+	router := gin.New()
+	router.Use(
+		otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(traced)),
+		middleware.RequestID(), middleware.RequestLogger(), middleware.Recovery(), middleware.Metrics(),
+	)
+
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CORS.AllowOrigins, // frontend URLs
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.CSRFHeader, middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	router.Use(middleware.SecurityHeaders(cfg.Security))
+	if cfg.Security.CSRF {
+		router.Use(middleware.CSRFProtection())
+	}
+
+	gin.DebugPrintRouteFunc = func(httpMethod, absolutePath, handlerName string, nuHandlers int) {
+		slog.Debug("endpoint", "method", httpMethod, "route", absolutePath, "handler", handlerName)
+	}
+
+	requireAuth := middleware.RequireAuthMiddleware(cfg.Auth.JWTSecret)
+
+	// Controllers get their data access injected
+	authController := controllers.NewAuthController(repos.Users, cfg.Auth)
+	userController := controllers.NewUserController(repos.Users)
+	providerController := controllers.NewProviderController(repos.Providers, repos.Users, repos.Insurances)
+	availabilityController := controllers.NewAvailabilityController(repos.Availabilities, repos.Providers)
+	bookingController := controllers.NewBookingController(repos.Bookings, repos.Availabilities, repos.Providers,
+		controllers.NewBookingEffects())
+	insuranceController := controllers.NewInsuranceController(repos.Insurances)
+	cityController := controllers.NewCityController(repos.Cities)
+	healthController := controllers.NewHealthController(readiness)
+
+	router.GET("/", controllers.GetWelcome)
+
+	// Probes: liveness and readiness, and the Prometheus metrics
+	router.GET("/healthz", controllers.Healthz)
+	router.GET("/readyz", healthController.Readyz)
+	if cfg.Metrics.Enabled {
+		router.GET("/metrics", middleware.RequireMetricsToken(cfg.Metrics.Token), gin.WrapH(metrics.Handler()))
+	}
+
+	router.POST("/auth/register", authController.Signup)
+	router.POST("/auth/login", authController.Login)
+	router.POST("/auth/logout", authController.Logout)
+	router.GET("/me", requireAuth, controllers.GetProfile)
+	router.GET("/me/notification-preferences", requireAuth, controllers.GetNotificationPreferences)
+	router.PUT("/me/notification-preferences", requireAuth, controllers.UpdateNotificationPreferences)
+	router.GET("/me/bookings", requireAuth, bookingController.GetMyBookings)
+	router.GET("/me/insurances", requireAuth, controllers.GetMyInsurances)
+	router.POST("/me/insurances", requireAuth, controllers.AddMyInsurance)
+	router.DELETE("/me/insurances/:id", requireAuth, controllers.DeleteMyInsurance)
+	router.GET("/me/calendar", requireAuth, controllers.GetMyCalendarFeed)
+	router.POST("/me/calendar/rotate", requireAuth, controllers.RotateMyCalendarFeed)
+
+	// iCalendar feed, authenticated by its secret token (/calendar/<token>.ics)
+	router.GET("/calendar/:token", controllers.GetCalendarFeed)
+
+	router.GET("/users", userController.GetAllUsers)
+	router.DELETE("/users/:email", userController.DeleteUser)
+
+	router.GET("/validate", requireAuth, controllers.Validate)
+
+	// Live booking and slot updates (Server-Sent Events)
+	router.GET("/events", requireAuth, controllers.StreamEvents)
+
+	// Provider routes
+	providers := router.Group("/providers")
+	{
+		providers.GET("/", providerController.GetAllProviders)
+		providers.GET("/:id", providerController.GetProviderByID)
+		providers.POST("/", requireAuth, providerController.CreateProvider)
+		providers.PUT("/:id", requireAuth, providerController.UpdateProvider)
+		providers.DELETE("/:id", requireAuth, providerController.DeleteProvider)
+		providers.GET("/:id/cancellation-policy", controllers.GetCancellationPolicy)
+		providers.PUT("/:id/cancellation-policy", requireAuth, controllers.UpdateCancellationPolicy)
+		providers.GET("/:id/reviews", controllers.GetProviderReviews)
+		providers.GET("/:id/bookings", requireAuth, bookingController.GetProviderBookings)
+		providers.PUT("/:id/insurances/:insuranceId", requireAuth, providerController.AddProviderInsurance)
+		providers.DELETE("/:id/insurances/:insuranceId", requireAuth, providerController.RemoveProviderInsurance)
+		providers.GET("/:id/calendars", requireAuth, controllers.GetExternalCalendars)
+		providers.POST("/:id/calendars", requireAuth, controllers.CreateExternalCalendar)
+		providers.POST("/:id/calendars/:calendarId/sync", requireAuth, controllers.SyncExternalCalendar)
+		providers.DELETE("/:id/calendars/:calendarId", requireAuth, controllers.DeleteExternalCalendar)
+	}
+
+	// Review routes
+	reviews := router.Group("/reviews").Use(requireAuth)
+	{
+		reviews.GET("/", middleware.RequireRole(models.RoleAdmin), controllers.GetAllReviews)
+		reviews.PUT("/:id/reply", controllers.ReplyToReview)
+		reviews.PUT("/:id/moderation", middleware.RequireRole(models.RoleAdmin), controllers.ModerateReview)
+	}
+
+	// Specialization routes - ADD AUTHORIZATION LATER
+	specializations := router.Group("/specializations")
+	{
+		specializations.GET("/", controllers.GetAllSpecializations)
+		specializations.POST("/", controllers.CreateSpecialization)
+		specializations.PUT("/:id", controllers.UpdateSpecialization)
+		specializations.DELETE("/:id", controllers.DeleteSpecialization)
+	}
+
+	// Service route
+	services := router.Group("/services")
+	{
+		services.POST("/", controllers.CreateService)
+		services.GET("/", controllers.GetAllServices)
+		services.GET("/:id", controllers.GetServiceByID)
+		services.PUT("/", controllers.UpdateServices)
+		services.DELETE("/", controllers.DeleteServices)
+	}
+
+	// Availability routes
+	availabilities := router.Group("/availabilities")
+	{
+		// 1️⃣ Create a new availability
+		// POST /availabilities/
+		availabilities.POST("/", availabilityController.CreateAvailability)
+
+		// 2️⃣ Get all availabilities with optional filters
+		// GET /availabilities/?provider_id=3&date=2025-09-20&start_date=2025-09-20&end_date=2025-09-30
+		availabilities.GET("/", availabilityController.GetAllAvailability)
+
+		// 3️⃣ Get a specific availability by ID
+		// GET /availabilities/:id
+		availabilities.GET("/:id", availabilityController.GetAvailabilityByID)
+
+		// 4️⃣ Update an existing availability
+		// PUT /availabilities/:id
+		availabilities.PUT("/:id", availabilityController.UpdateAvailability)
+
+		// 5️⃣ Delete an availability
+		// DELETE /availabilities/:id
+		availabilities.DELETE("/:id", availabilityController.DeleteAvailability)
+	}
+
+	bookings := router.Group("/bookings").Use(requireAuth)
+	{
+		bookings.POST("/", bookingController.CreateBooking)
+		bookings.GET("/", middleware.RequireRole(models.RoleAdmin), bookingController.GetAllBooking)
+		bookings.POST("/confirm", bookingController.ConfirmBooking)
+		// only the booking's patient, its provider or an admin may cancel (checked by the handler)
+		bookings.POST("/cancel", bookingController.CancelBooking)
+		bookings.POST("/no-show", bookingController.MarkNoShow)
+		bookings.POST("/complete", bookingController.CompleteBooking)
+		bookings.POST("/:id/pay", controllers.PayBooking)
+		bookings.GET("/:id/invoice", controllers.GetBookingInvoice)
+		bookings.GET("/:id/ics", controllers.GetBookingCalendarFile)
+		bookings.POST("/:id/review", controllers.CreateReview)
+	}
+
+	// Payment gateway callbacks (authenticated by the gateway signature)
+	router.POST("/payments/webhook/:gateway", controllers.HandlePaymentWebhook)
+
+	// Insurance
+	insurances := router.Group("/insurances")
+	{
+		insurances.POST("/", insuranceController.CreateInsurance)
+		insurances.GET("/", insuranceController.GetAllInsurances)
+		insurances.GET("/:id", insuranceController.GetInsuranceByID)
+		insurances.PUT("/:id", insuranceController.UpdateInsurance)
+		insurances.DELETE("/:id", insuranceController.DeleteInsurance)
+	}
+
+	cities := router.Group("/cities")
+	{
+		cities.POST("/", cityController.CreateCity)
+		cities.GET("/", cityController.GetAllCities)
+		cities.GET("/:id", cityController.GetCityByID)
+		cities.PUT("/:id", cityController.UpdateCity)
+		cities.DELETE("/:id", cityController.DeleteCity)
+	}
+
+	// Outbound webhooks (admins, and providers for their own events)
+	webhookRoutes := router.Group("/webhooks").Use(requireAuth, middleware.RequireRole(models.RoleAdmin, models.RoleProvider))
+	{
+		webhookRoutes.POST("/", controllers.CreateWebhook)
+		webhookRoutes.GET("/", controllers.GetAllWebhooks)
+		webhookRoutes.GET("/:id", controllers.GetWebhookByID)
+		webhookRoutes.PUT("/:id", controllers.UpdateWebhook)
+		webhookRoutes.DELETE("/:id", controllers.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", controllers.GetWebhookDeliveries)
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/redeliver", controllers.RedeliverWebhook)
+	}
+
+	return router
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/middleware"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := s.request(http.MethodGet, "/", nil, "")
+	for header, want := range map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         "DENY",
+		"Referrer-Policy":         "no-referrer",
+		"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s: expected %q, got %q", header, want, got)
+		}
+	}
+	// HSTS is off by default, for local HTTP development
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("unexpected HSTS header %q", got)
+	}
+}
+
+// cookieLogin logs in as the patient and returns the cookies set by the API
+func (s *testServer) cookieLogin() map[string]*http.Cookie {
+	s.t.Helper()
+	rec := s.request(http.MethodPost, "/auth/login", map[string]string{
+		"email":    s.fixtures.Patient.Email,
+		"password": testPassword,
+	}, "")
+	expectStatus(s.t, rec, http.StatusOK)
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	if cookies[middleware.AuthCookie] == nil || cookies[middleware.CSRFCookie] == nil {
+		s.t.Fatalf("login did not set the auth and CSRF cookies: %v", rec.Result().Cookies())
+	}
+	return cookies
+}
+
+// cookieRequest serves a request authenticated by the login cookies
+func (s *testServer) cookieRequest(method, path, body string, cookies map[string]*http.Cookie, csrfToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	if csrfToken != "" {
+		req.Header.Set(middleware.CSRFHeader, csrfToken)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLoginCookies(t *testing.T) {
+	s := newTestServer(t)
+	cookies := s.cookieLogin()
+
+	if auth := cookies[middleware.AuthCookie]; !auth.HttpOnly || auth.SameSite != http.SameSiteLaxMode {
+		t.Errorf("the auth cookie must be HttpOnly and SameSite=Lax: %+v", auth)
+	}
+	if csrf := cookies[middleware.CSRFCookie]; csrf.HttpOnly || csrf.Value == "" {
+		t.Errorf("the CSRF cookie must be readable by the frontend: %+v", csrf)
+	}
+}
+
+func TestCSRFProtection(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	cookies := s.cookieLogin()
+	body := fmt.Sprintf(`{"patient_id": %d, "provider_id": %d, "service_id": %d, "start_time": %q}`,
+		s.fixtures.Patient.ID, s.fixtures.Provider.ID, s.fixtures.Service.ID, bookingStart)
+
+	// reads need no token
+	rec := s.cookieRequest(http.MethodGet, "/me", "", cookies, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = s.cookieRequest(http.MethodPost, "/bookings/", body, cookies, "")
+	expectStatus(t, rec, http.StatusForbidden)
+	rec = s.cookieRequest(http.MethodPost, "/bookings/", body, cookies, "forged")
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = s.cookieRequest(http.MethodPost, "/bookings/", body, cookies, cookies[middleware.CSRFCookie].Value)
+	expectStatus(t, rec, http.StatusCreated)
+
+	// bearer tokens are not sent automatically by browsers: no CSRF token needed
+	rec = s.requestAs(models.RolePatient, http.MethodPost, "/bookings/", s.bookingInput("2030-01-07T10:00:00Z"))
+	expectStatus(t, rec, http.StatusCreated)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/config"
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/adriel-meb/appointly-backend/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The integration tests boot the full router against a throwaway SQLite database.
+// Routes relying on Postgres-only SQL (provider search, search indexing on
+// provider create/update) are not exercised here.
+
+// testPassword is the password of every fixture user
+const testPassword = "secret123"
+
+// schema lists the tables created in the test database
+var schema = []interface{}{
+	&models.User{},
+	&models.Provider{},
+	&models.Specialization{},
+	&models.Service{},
+	&models.Availability{},
+	&models.AvailabilitySlot{},
+	&models.Booking{},
+	&models.Payment{},
+	&models.Refund{},
+	&models.CancellationPolicy{},
+	&models.Invoice{},
+	&models.Review{},
+	&models.City{},
+	&models.Notification{},
+	&models.NotificationPreference{},
+	&models.NotificationEventPreference{},
+	&models.Insurance{},
+	&models.InsuranceMembership{},
+	&models.ExternalCalendar{},
+	&models.ExternalBusyTime{},
+	&models.WebhookSubscription{},
+	&models.WebhookDelivery{},
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// fixtures are the records every test server starts with
+type fixtures struct {
+	Patient        models.User
+	ProviderUser   models.User
+	Admin          models.User
+	Specialization models.Specialization
+	City           models.City
+	Provider       models.Provider
+	Service        models.Service
+}
+
+// testServer is the API served from a fresh database
+type testServer struct {
+	t        *testing.T
+	cfg      *config.Config
+	router   *gin.Engine
+	db       *gorm.DB
+	fixtures fixtures
+	tokens   map[models.UserRole]string
+}
+
+// newTestServer creates a database in the test's temporary directory, seeds the
+// fixtures and builds the router on it. The handlers still using the global
+// connection see the same database for the duration of the test.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "appointly.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := conn.AutoMigrate(schema...); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	if err := conn.Use(tracing.GormPlugin{}); err != nil {
+		t.Fatalf("trace queries: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = "test-secret"
+	cfg.Metrics.Token = "metrics-token"
+
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		db.DB = previous
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	readiness := []health.Check{{Name: "database", Run: db.Ping}, health.Workers()}
+	s := &testServer{
+		t:      t,
+		cfg:    cfg,
+		router: newRouter(cfg, repository.NewGorm(conn), readiness),
+		db:     conn,
+		tokens: map[models.UserRole]string{},
+	}
+	s.seed()
+	return s
+}
+
+// seed creates a patient, a provider and an admin, plus the provider's profile,
+// specialization, city and a 30-minute service
+func (s *testServer) seed() {
+	s.t.Helper()
+	f := &s.fixtures
+
+	f.Specialization = models.Specialization{Name: "Dentist"}
+	s.create(&f.Specialization)
+	f.City = models.City{Name: "Libreville"}
+	s.create(&f.City)
+
+	f.Patient = s.createUser("Patient", "patient@example.com", models.RolePatient)
+	f.ProviderUser = s.createUser("Provider", "provider@example.com", models.RoleProvider)
+	f.Admin = s.createUser("Admin", "admin@example.com", models.RoleAdmin)
+
+	f.Provider = models.Provider{
+		UserID:           f.ProviderUser.ID,
+		SpecializationID: f.Specialization.ID,
+		CityID:           f.City.ID,
+		Bio:              "Dentist in Libreville",
+	}
+	s.create(&f.Provider)
+
+	f.Service = models.Service{
+		Title:           "Check-up",
+		ProviderID:      f.Provider.ID,
+		DurationMinutes: 30,
+		Price:           15000,
+	}
+	s.create(&f.Service)
+}
+
+// create inserts a record or fails the test
+func (s *testServer) create(value interface{}) {
+	s.t.Helper()
+	if err := s.db.Create(value).Error; err != nil {
+		s.t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// createUser inserts a user whose password is testPassword
+func (s *testServer) createUser(name, email string, role models.UserRole) models.User {
+	s.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		s.t.Fatalf("hash password: %v", err)
+	}
+	user := models.User{Name: name, Email: email, PasswordHash: string(hash), Role: role}
+	s.create(&user)
+	return user
+}
+
+// loginAs logs in as the fixture user of a role and returns their token
+func (s *testServer) loginAs(role models.UserRole) string {
+	s.t.Helper()
+	if token, ok := s.tokens[role]; ok {
+		return token
+	}
+
+	var user models.User
+	switch role {
+	case models.RolePatient:
+		user = s.fixtures.Patient
+	case models.RoleProvider:
+		user = s.fixtures.ProviderUser
+	case models.RoleAdmin:
+		user = s.fixtures.Admin
+	default:
+		s.t.Fatalf("no fixture user with role %q", role)
+	}
+
+	token := s.login(user.Email, testPassword)
+	s.tokens[role] = token
+	return token
+}
+
+// login posts credentials to /auth/login and returns the token
+func (s *testServer) login(email, password string) string {
+	s.t.Helper()
+	rec := s.request(http.MethodPost, "/auth/login", gin.H{"email": email, "password": password}, "")
+	expectStatus(s.t, rec, http.StatusOK)
+
+	var body struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	decode(s.t, rec, &body)
+	if body.Data.Token == "" {
+		s.t.Fatalf("login %s: no token in %s", email, rec.Body)
+	}
+	return body.Data.Token
+}
+
+// request serves a request with an optional JSON body and bearer token
+func (s *testServer) request(method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// requestAs is request authenticated as the fixture user of a role
+func (s *testServer) requestAs(role models.UserRole, method, path string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.request(method, path, body, s.loginAs(role))
+}
+
+// expectStatus fails the test unless the response has the given status
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, rec.Code, rec.Body)
+	}
+}
+
+// decode unmarshals a JSON response body
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode response %s: %v", rec.Body, err)
+	}
+}
+
+// path joins a route with an id, as in path("/availabilities/", 3)
+func path(route string, id uint) string {
+	return fmt.Sprintf("%s%d", route, id)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans makes the global tracer provider keep the ended spans in memory
+// until the test ends. Call it before newTestServer, whose router takes the
+// provider when it is built.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(t.Context())
+	})
+	return exporter
+}
+
+func TestTraceOfBookingCreation(t *testing.T) {
+	exporter := recordSpans(t)
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+	exporter.Reset()
+
+	s.createBooking(bookingStart)
+
+	spans := exporter.GetSpans()
+	var request *tracetest.SpanStub
+	for i := range spans {
+		if spans[i].Name == "POST /bookings/" {
+			request = &spans[i]
+		}
+	}
+	if request == nil {
+		t.Fatalf("no span for the request among %d spans", len(spans))
+	}
+
+	// Every step of the request belongs to its trace: the availability and
+	// overlap queries, the insert and the notification
+	var queries, overlap, dispatch int
+	for _, span := range spans {
+		if span.SpanContext.TraceID() != request.SpanContext.TraceID() {
+			continue
+		}
+		switch span.Name {
+		case "gorm.query", "gorm.create", "gorm.update", "gorm.row":
+			queries++
+			for _, attr := range span.Attributes {
+				if attr.Key != "db.statement" {
+					continue
+				}
+				if strings.Contains(attr.Value.AsString(), "2030-01-07") {
+					t.Errorf("query values must not be recorded: %s", attr.Value.AsString())
+				}
+				if strings.Contains(attr.Value.AsString(), "count(*)") && strings.Contains(attr.Value.AsString(), "bookings") {
+					overlap++
+				}
+			}
+		case "notifications.dispatch":
+			dispatch++
+		}
+	}
+	if queries < 3 || overlap != 1 || dispatch != 1 {
+		t.Fatalf("expected the queries (%d, overlap check %d) and the notification (%d) in the request trace", queries, overlap, dispatch)
+	}
+}
+
+func TestProbesAreNotTraced(t *testing.T) {
+	exporter := recordSpans(t)
+	s := newTestServer(t)
+
+	expectStatus(t, s.request(http.MethodGet, "/healthz", nil, ""), http.StatusOK)
+	for _, span := range exporter.GetSpans() {
+		if strings.Contains(span.Name, "/healthz") {
+			t.Fatalf("unexpected span %s", span.Name)
+		}
+	}
+}
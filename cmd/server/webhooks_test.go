@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBookingChangesQueueWebhooks(t *testing.T) {
+	s := newTestServer(t)
+	s.createAvailability("2030-01-07", "09:00", "12:00")
+
+	subscription := models.WebhookSubscription{
+		URL:        "https://partner.example.com/appointly",
+		Secret:     "secret",
+		EventTypes: "booking.created,booking.cancelled",
+		Active:     true,
+	}
+	s.create(&subscription)
+
+	booking := s.createBooking(bookingStart).Data
+	rec := s.requestAs(models.RolePatient, http.MethodPost, "/bookings/cancel", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusOK)
+
+	// the deliveries were committed with the changes, for the worker to send
+	var deliveries []models.WebhookDelivery
+	if err := s.db.Where("subscription_id = ?", subscription.ID).Order("id").Find(&deliveries).Error; err != nil {
+		t.Fatalf("load deliveries: %v", err)
+	}
+	want := []events.Type{events.BookingCreated, events.BookingCancelled}
+	if len(deliveries) != len(want) {
+		t.Fatalf("expected %d deliveries, got %d", len(want), len(deliveries))
+	}
+	for i, delivery := range deliveries {
+		if events.Type(delivery.EventType) != want[i] || delivery.Status != models.DeliveryPending || delivery.NextAttemptAt == nil {
+			t.Errorf("delivery %d: expected a pending %s, got a %s %s", i, want[i], delivery.Status, delivery.EventType)
+		}
+
+		var payload struct {
+			ID   string         `json:"id"`
+			Data models.Booking `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(delivery.Payload), &payload); err != nil {
+			t.Fatalf("decode payload: %v", err)
+		}
+		if len(delivery.EventID) != 36 || payload.ID != delivery.EventID {
+			t.Errorf("delivery %d: expected the payload id %q to be the event UUID %q", i, payload.ID, delivery.EventID)
+		}
+		if payload.Data.ID != booking.ID {
+			t.Errorf("delivery %d: expected booking %d in the payload, got %d", i, booking.ID, payload.Data.ID)
+		}
+	}
+	if deliveries[0].EventID == deliveries[1].EventID {
+		t.Errorf("expected each event to get its own id, both are %s", deliveries[0].EventID)
+	}
+}
+
+func TestProviderManagesOwnWebhooks(t *testing.T) {
+	s := newTestServer(t)
+	global := models.WebhookSubscription{URL: "https://partner.example.com/all", Secret: "secret", EventTypes: "booking.created", Active: true}
+	s.create(&global)
+
+	rec := s.requestAs(models.RoleProvider, http.MethodPost, "/webhooks/", gin.H{
+		"url":         "https://clinic.example.com/appointly",
+		"event_types": []string{"booking.created", "booking.cancelled", "booking.created"},
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Data struct {
+			Webhook models.WebhookSubscription `json:"webhook"`
+		} `json:"data"`
+	}
+	decode(t, rec, &created)
+	own := created.Data.Webhook
+	if own.ProviderID == nil || *own.ProviderID != s.fixtures.Provider.ID {
+		t.Fatalf("expected the subscription scoped to provider %d, got %v", s.fixtures.Provider.ID, own.ProviderID)
+	}
+	if own.EventTypes != "booking.created,booking.cancelled" {
+		t.Fatalf("expected the event types without duplicates, got %q", own.EventTypes)
+	}
+
+	// only its own subscriptions are listed and reachable
+	rec = s.requestAs(models.RoleProvider, http.MethodGet, "/webhooks/", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var listed struct {
+		Data []models.WebhookSubscription `json:"data"`
+	}
+	decode(t, rec, &listed)
+	if len(listed.Data) != 1 || listed.Data[0].ID != own.ID {
+		t.Fatalf("expected only subscription %d, got %+v", own.ID, listed.Data)
+	}
+	expectStatus(t, s.requestAs(models.RoleProvider, http.MethodGet, path("/webhooks/", own.ID), nil), http.StatusOK)
+	expectStatus(t, s.requestAs(models.RoleProvider, http.MethodGet, path("/webhooks/", global.ID), nil), http.StatusNotFound)
+	expectStatus(t, s.requestAs(models.RoleProvider, http.MethodDelete, path("/webhooks/", global.ID), nil), http.StatusNotFound)
+
+	// it can neither widen nor move its subscription
+	other := s.fixtures.Provider.ID + 1
+	rec = s.requestAs(models.RoleProvider, http.MethodPut, path("/webhooks/", own.ID), gin.H{
+		"url":         "https://clinic.example.com/appointly",
+		"event_types": []string{"booking.created"},
+		"provider_id": other,
+	})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	// the admin sees both
+	rec = s.requestAs(models.RoleAdmin, http.MethodGet, "/webhooks/", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &listed)
+	if len(listed.Data) != 2 {
+		t.Fatalf("expected the admin to see 2 subscriptions, got %d", len(listed.Data))
+	}
+}
+
+func TestPatientCannotManageWebhooks(t *testing.T) {
+	s := newTestServer(t)
+	rec := s.requestAs(models.RolePatient, http.MethodGet, "/webhooks/", nil)
+	expectStatus(t, rec, http.StatusForbidden)
+}
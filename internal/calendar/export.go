@@ -0,0 +1,226 @@
+// Package calendar renders bookings as iCalendar (RFC 5545) feeds and files.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	ics "github.com/arran4/golang-ical"
+)
+
+// ProductID identifies Appointly as the producer of the calendars
+const ProductID = "-//Appointly//Appointly Calendar//FR"
+
+// ContentType of .ics responses and attachments
+const ContentType = "text/calendar; charset=utf-8"
+
+// feedHistory is how far back a feed goes; older bookings are left out
+const feedHistory = 90 * 24 * time.Hour
+
+// entry is a booking with the names its event shows
+type entry struct {
+	booking        models.Booking
+	patientName    string
+	providerName   string
+	providerUserID uint
+	serviceTitle   string
+	address        string
+	city           string
+}
+
+// UID is the stable identifier of a booking's event, so updates replace it in calendars
+func UID(bookingID uint) string {
+	return fmt.Sprintf("booking-%d@appointly", bookingID)
+}
+
+// status maps a booking status to an event status
+func status(s models.StatusBooking) ics.ObjectStatus {
+	switch s {
+	case models.Pending:
+		return ics.ObjectStatusTentative
+	case models.Cancelled, models.NoShow:
+		return ics.ObjectStatusCancelled
+	default:
+		return ics.ObjectStatusConfirmed
+	}
+}
+
+// sequence grows with each state change so clients apply updates in order
+func sequence(s models.StatusBooking) int {
+	switch s {
+	case models.Pending:
+		return 0
+	case models.Confirmed:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// loadEntries fetches the names shown in the events of the bookings, with one query
+// for each of the patients, providers and services however many bookings there are
+func loadEntries(bookings []models.Booking) ([]entry, error) {
+	entries := make([]entry, len(bookings))
+	if len(bookings) == 0 {
+		return entries, nil
+	}
+
+	patientIDs := map[uint]bool{}
+	providerIDs := map[uint]bool{}
+	serviceIDs := map[uint]bool{}
+	for _, b := range bookings {
+		patientIDs[b.PatientID] = true
+		providerIDs[b.ProviderID] = true
+		serviceIDs[b.ServiceID] = true
+	}
+
+	var patients []models.User
+	if err := db.DB.Select("id", "name").Where("id IN ?", keys(patientIDs)).Find(&patients).Error; err != nil {
+		return nil, err
+	}
+	var providers []models.Provider
+	if err := db.DB.Select("id", "user_id", "city_id", "address").Preload("User").Preload("City").
+		Where("id IN ?", keys(providerIDs)).Find(&providers).Error; err != nil {
+		return nil, err
+	}
+	var services []models.Service
+	if err := db.DB.Select("id", "title").Where("id IN ?", keys(serviceIDs)).Find(&services).Error; err != nil {
+		return nil, err
+	}
+
+	patientNames := map[uint]string{}
+	for _, p := range patients {
+		patientNames[p.ID] = p.Name
+	}
+	providerByID := map[uint]models.Provider{}
+	for _, p := range providers {
+		providerByID[p.ID] = p
+	}
+	serviceTitles := map[uint]string{}
+	for _, s := range services {
+		serviceTitles[s.ID] = s.Title
+	}
+
+	for i, b := range bookings {
+		provider := providerByID[b.ProviderID]
+		entries[i] = entry{
+			booking:        b,
+			patientName:    patientNames[b.PatientID],
+			providerUserID: provider.UserID,
+			serviceTitle:   serviceTitles[b.ServiceID],
+			address:        provider.Address,
+		}
+		if provider.User != nil {
+			entries[i].providerName = provider.User.Name
+		}
+		if provider.City != nil {
+			entries[i].city = provider.City.Name
+		}
+	}
+	return entries, nil
+}
+
+func keys(set map[uint]bool) []uint {
+	ids := make([]uint, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// render builds the calendar; asProvider chooses whose point of view the summaries take
+func render(name string, entries []entry, method ics.Method, asProvider func(models.Booking) bool) []byte {
+	cal := ics.NewCalendar()
+	cal.SetProductId(ProductID)
+	cal.SetMethod(method)
+	cal.SetCalscale("GREGORIAN")
+	cal.SetXWRCalName(name)
+	cal.SetName(name)
+	cal.SetXWRTimezone(scripts.BusinessTimezone)
+	cal.SetRefreshInterval("PT1H")
+	cal.SetXPublishedTTL("PT1H")
+
+	for _, e := range entries {
+		b := e.booking
+		event := cal.AddEvent(UID(b.ID))
+		event.SetDtStampTime(b.UpdatedAt)
+		event.SetCreatedTime(b.CreatedAt)
+		event.SetModifiedAt(b.UpdatedAt)
+		event.SetSequence(sequence(b.Status))
+		event.SetStartAt(b.StartTime) // UTC: rendered in each attendee's own time zone
+		event.SetEndAt(b.EndTime)
+		event.SetStatus(status(b.Status))
+
+		if asProvider(b) {
+			event.SetSummary(strings.TrimSpace(e.serviceTitle + " – " + e.patientName))
+		} else {
+			event.SetSummary(strings.TrimSpace("RDV " + e.providerName + " – " + e.serviceTitle))
+		}
+
+		location := strings.Trim(e.address+", "+e.city, ", ")
+		if location != "" {
+			event.SetLocation(location)
+		}
+
+		description := fmt.Sprintf("Réservation #%d", b.ID)
+		if b.Notes != "" {
+			description += "\n" + b.Notes
+		}
+		if b.Status == models.Cancelled {
+			description += "\nRendez-vous annulé"
+		}
+		event.SetDescription(description)
+	}
+
+	return []byte(cal.Serialize())
+}
+
+// UserFeed renders the subscription feed of a user: their own appointments as a patient,
+// plus their agenda when they are a provider.
+func UserFeed(user models.User) ([]byte, error) {
+	var provider models.Provider
+	providerID := uint(0)
+	if err := db.DB.Select("id").Where("user_id = ?", user.ID).First(&provider).Error; err == nil {
+		providerID = provider.ID
+	}
+
+	var bookings []models.Booking
+	if err := db.DB.Where("patient_id = ? OR provider_id = ?", user.ID, providerID).
+		Where("start_time >= ?", time.Now().Add(-feedHistory)).
+		Order("start_time").Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+
+	entries, err := loadEntries(bookings)
+	if err != nil {
+		return nil, err
+	}
+	asProvider := func(b models.Booking) bool { return providerID != 0 && b.ProviderID == providerID }
+	return render("Appointly – "+user.Name, entries, ics.MethodPublish, asProvider), nil
+}
+
+// BookingFile renders a single booking as an .ics file for the given viewer.
+// Cancelled bookings use METHOD:CANCEL so importing the file removes the event.
+func BookingFile(booking models.Booking, viewer models.User) ([]byte, error) {
+	entries, err := loadEntries([]models.Booking{booking})
+	if err != nil {
+		return nil, err
+	}
+	providerUserID := entries[0].providerUserID
+	asProvider := func(models.Booking) bool { return viewer.ID == providerUserID && viewer.ID != booking.PatientID }
+
+	method := ics.MethodPublish
+	if booking.Status == models.Cancelled {
+		method = ics.MethodCancel
+	}
+	return render("Appointly", entries, method, asProvider), nil
+}
+
+// Filename of a booking's .ics file
+func Filename(bookingID uint) string {
+	return fmt.Sprintf("rendez-vous-%d.ics", bookingID)
+}
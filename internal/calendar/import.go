@@ -0,0 +1,255 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/scripts"
+	ics "github.com/arran4/golang-ical"
+	"github.com/teambition/rrule-go"
+)
+
+// maxOccurrences caps the busy times produced by a single recurring event
+const maxOccurrences = 5000
+
+// maxIterations caps the occurrences walked through for a single recurring event,
+// including those before the window (a daily event since 1900 still fits)
+const maxIterations = 50000
+
+var isoDuration = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseBusy reads an iCalendar file and returns its busy periods overlapping [from, to).
+// Recurring events are expanded (RRULE, RDATE, EXDATE); modified occurrences
+// (RECURRENCE-ID) replace the instance they override. Cancelled and transparent
+// ("free") events are ignored, as are events whose dates cannot be read and
+// rules repeating every second or minute.
+// Floating times and unknown TZIDs are read in the business time zone.
+func ParseBusy(r io.Reader, from, to time.Time) ([]scripts.Interval, error) {
+	cal, err := ics.ParseCalendar(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar: %w", err)
+	}
+	loc := scripts.BusinessLocation()
+
+	// Modified occurrences, by UID of the recurring event they belong to
+	overrides := map[string][]*ics.VEvent{}
+	var masters []*ics.VEvent
+	for _, event := range cal.Events() {
+		if event.GetProperty(ics.ComponentPropertyRecurrenceId) != nil {
+			uid := event.Id()
+			overrides[uid] = append(overrides[uid], event)
+			continue
+		}
+		masters = append(masters, event)
+	}
+
+	var busy []scripts.Interval
+	add := func(start time.Time, duration time.Duration) {
+		end := start.Add(duration)
+		if start.Before(to) && end.After(from) {
+			busy = append(busy, scripts.Interval{Start: start.UTC(), End: end.UTC()})
+		}
+	}
+
+	for _, event := range masters {
+		if !isBusy(event) {
+			continue
+		}
+		start, duration, ok := eventSpan(event, loc)
+		if !ok {
+			continue
+		}
+
+		rule := event.GetProperty(ics.ComponentPropertyRrule)
+		rdates := event.GetProperties(ics.ComponentPropertyRdate)
+		if rule == nil && len(rdates) == 0 {
+			add(start, duration)
+			continue
+		}
+
+		set := rrule.Set{}
+		set.DTStart(start)
+		if rule != nil {
+			option, err := rrule.StrToROptionInLocation(rule.Value, start.Location())
+			if err != nil {
+				continue
+			}
+			if option.Freq == rrule.SECONDLY || option.Freq == rrule.MINUTELY {
+				continue
+			}
+			option.Dtstart = start
+			r, err := rrule.NewRRule(*option)
+			if err != nil {
+				continue
+			}
+			set.RRule(r)
+		} else {
+			set.RDate(start) // RDATE-only events still occur at DTSTART
+		}
+		for _, prop := range rdates {
+			for _, t := range propTimes(prop, loc) {
+				set.RDate(t)
+			}
+		}
+		for _, prop := range event.GetProperties(ics.ComponentPropertyExdate) {
+			for _, t := range propTimes(prop, loc) {
+				set.ExDate(t)
+			}
+		}
+		for _, override := range overrides[event.Id()] {
+			for _, t := range propTimes(override.GetProperty(ics.ComponentPropertyRecurrenceId), loc) {
+				set.ExDate(t)
+			}
+		}
+
+		// Walk the occurrences in order rather than expanding them all: an unbounded
+		// rule only costs the iterations up to the end of the window
+		next, windowStart := set.Iterator(), from.Add(-duration)
+		for i, count := 0, 0; i < maxIterations && count < maxOccurrences; i++ {
+			occurrence, ok := next()
+			if !ok || !occurrence.Before(to) {
+				break
+			}
+			if occurrence.Before(windowStart) {
+				continue
+			}
+			add(occurrence, duration)
+			count++
+		}
+	}
+
+	// Modified occurrences take place at their own time, unless cancelled
+	for _, events := range overrides {
+		for _, event := range events {
+			if !isBusy(event) {
+				continue
+			}
+			if start, duration, ok := eventSpan(event, loc); ok {
+				add(start, duration)
+			}
+		}
+	}
+
+	return busy, nil
+}
+
+// isBusy tells whether an event blocks time: not cancelled and not marked free
+func isBusy(event *ics.VEvent) bool {
+	if p := event.GetProperty(ics.ComponentPropertyStatus); p != nil &&
+		strings.EqualFold(p.Value, string(ics.ObjectStatusCancelled)) {
+		return false
+	}
+	if p := event.GetProperty(ics.ComponentPropertyTransp); p != nil &&
+		strings.EqualFold(p.Value, string(ics.TransparencyTransparent)) {
+		return false
+	}
+	return true
+}
+
+// eventSpan returns the start and duration of an event from DTSTART and DTEND or DURATION.
+// All-day events without an end last one day.
+func eventSpan(event *ics.VEvent, loc *time.Location) (time.Time, time.Duration, bool) {
+	start, allDay, err := propTime(event.GetProperty(ics.ComponentPropertyDtStart), loc)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	var duration time.Duration
+	if p := event.GetProperty(ics.ComponentPropertyDtEnd); p != nil {
+		end, _, err := propTime(p, loc)
+		if err != nil {
+			return time.Time{}, 0, false
+		}
+		duration = end.Sub(start)
+	} else if p := event.GetProperty(ics.ComponentPropertyDuration); p != nil {
+		if duration, err = parseDuration(p.Value); err != nil {
+			return time.Time{}, 0, false
+		}
+	} else if allDay {
+		duration = 24 * time.Hour
+	}
+
+	if duration <= 0 {
+		return time.Time{}, 0, false
+	}
+	return start, duration, true
+}
+
+// propTime reads a DATE or DATE-TIME property, reporting whether it is a whole day
+func propTime(prop *ics.IANAProperty, loc *time.Location) (time.Time, bool, error) {
+	if prop == nil {
+		return time.Time{}, false, errors.New("missing date")
+	}
+	return parseTime(strings.SplitN(prop.Value, ",", 2)[0], propLocation(prop, loc))
+}
+
+// propTimes reads the comma-separated values of an RDATE, EXDATE or RECURRENCE-ID
+func propTimes(prop *ics.IANAProperty, loc *time.Location) []time.Time {
+	if prop == nil {
+		return nil
+	}
+	loc = propLocation(prop, loc)
+
+	var times []time.Time
+	for _, value := range strings.Split(prop.Value, ",") {
+		if t, _, err := parseTime(strings.TrimSpace(value), loc); err == nil {
+			times = append(times, t)
+		}
+	}
+	return times
+}
+
+// propLocation is the TZID of a property, or loc when absent or unknown (e.g. Windows zone names)
+func propLocation(prop *ics.IANAProperty, loc *time.Location) *time.Location {
+	if tzid, ok := prop.ICalParameters[string(ics.ParameterTzid)]; ok && len(tzid) == 1 {
+		if l, err := time.LoadLocation(strings.Trim(tzid[0], `"/`)); err == nil {
+			return l
+		}
+	}
+	return loc
+}
+
+// parseTime parses 20261019, 20261019T090000 (in loc) or 20261019T090000Z
+func parseTime(value string, loc *time.Location) (time.Time, bool, error) {
+	switch {
+	case len(value) == 8:
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	case strings.HasSuffix(value, "Z"):
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	default:
+		t, err := time.ParseInLocation("20060102T150405", value, loc)
+		return t, false, err
+	}
+}
+
+// parseDuration parses an iCalendar duration such as PT1H30M, P1D or P2W
+func parseDuration(value string) (time.Duration, error) {
+	m := isoDuration.FindStringSubmatch(value)
+	if m == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
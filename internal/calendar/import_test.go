@@ -0,0 +1,63 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// calendarWith wraps events in a VCALENDAR
+func calendarWith(events ...string) string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n")
+	for _, event := range events {
+		b.WriteString("BEGIN:VEVENT\r\n" + event + "END:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	return b.String()
+}
+
+func TestParseBusyExpandsUnboundedRulesWithinTheWindow(t *testing.T) {
+	ics := calendarWith("UID:daily\r\nDTSTART:20000103T090000Z\r\nDTEND:20000103T100000Z\r\nRRULE:FREQ=DAILY\r\n")
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	busy, err := ParseBusy(strings.NewReader(ics), from, from.Add(7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(busy) != 7 {
+		t.Fatalf("expected 7 daily occurrences in the week, got %d", len(busy))
+	}
+	if want := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC); !busy[0].Start.Equal(want) {
+		t.Errorf("expected the first occurrence at %s, got %s", want, busy[0].Start)
+	}
+}
+
+func TestParseBusyCapsOccurrences(t *testing.T) {
+	ics := calendarWith("UID:hourly\r\nDTSTART:20300101T000000Z\r\nDURATION:PT30M\r\nRRULE:FREQ=HOURLY\r\n")
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	busy, err := ParseBusy(strings.NewReader(ics), from, from.Add(SyncHorizon*2))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(busy) != maxOccurrences {
+		t.Fatalf("expected %d occurrences, got %d", maxOccurrences, len(busy))
+	}
+}
+
+func TestParseBusyIgnoresSecondlyAndMinutelyRules(t *testing.T) {
+	ics := calendarWith(
+		"UID:secondly\r\nDTSTART:20300101T000000Z\r\nDURATION:PT1S\r\nRRULE:FREQ=SECONDLY\r\n",
+		"UID:minutely\r\nDTSTART:20300101T000000Z\r\nDURATION:PT1M\r\nRRULE:FREQ=MINUTELY;INTERVAL=15\r\n",
+	)
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	busy, err := ParseBusy(strings.NewReader(ics), from, from.Add(SyncHorizon))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(busy) != 0 {
+		t.Fatalf("expected the rules to be ignored, got %d busy times", len(busy))
+	}
+}
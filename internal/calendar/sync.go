@@ -0,0 +1,298 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/tracing"
+	"github.com/adriel-meb/appointly-backend/internal/webhooks"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"gorm.io/gorm"
+)
+
+// SyncHorizon is how far ahead imported recurrences are expanded
+const SyncHorizon = 180 * 24 * time.Hour
+
+// MaxCalendarSize bounds fetched and uploaded .ics files
+const MaxCalendarSize = 5 << 20
+
+// maxRedirects bounds the redirects followed when fetching a calendar
+const maxRedirects = 5
+
+// httpClient fetches subscribed calendars. Its dialer refuses non-public addresses once
+// the host is resolved, so a calendar URL (or a redirect) cannot reach the loopback,
+// the private network or the cloud metadata endpoint.
+var httpClient = &http.Client{
+	Timeout: 20 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: refuseNonPublic,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("too many redirects")
+		}
+		if req.URL.Scheme != "https" && req.URL.Scheme != "http" {
+			return errors.New("redirect to an unsupported scheme")
+		}
+		return nil
+	},
+}
+
+// errNonPublicAddress is returned when a calendar URL resolves to a non-public address
+var errNonPublicAddress = errors.New("calendar URL must resolve to a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), not covered by IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublic reports whether ip is a globally routable unicast address
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// refuseNonPublic is the dialer's Control hook: address is the resolved ip:port
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !isPublic(ip) {
+		return errNonPublicAddress
+	}
+	return nil
+}
+
+// NormalizeURL turns webcal:// links into https:// and rejects other schemes, as well
+// as hosts that are obviously not public (localhost, private IP literals). Hostnames
+// are checked again against their resolved addresses when the calendar is fetched.
+func NormalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if rest, ok := strings.CutPrefix(raw, "webcal://"); ok {
+		raw = "https://" + rest
+	}
+	if !strings.HasPrefix(raw, "https://") && !strings.HasPrefix(raw, "http://") {
+		return "", errors.New("calendar URL must use http, https or webcal")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return "", errors.New("calendar URL is not valid")
+	}
+	if u.User != nil {
+		return "", errors.New("calendar URL must not contain credentials")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "", errNonPublicAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !isPublic(ip) {
+		return "", errNonPublicAddress
+	}
+	return raw, nil
+}
+
+// fetch downloads an ICS calendar. The errors it returns are recorded on the calendar
+// and shown to its owner, so they never carry the underlying network error: that
+// would tell apart closed ports, unknown hosts and timeouts.
+func fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, errors.New("calendar URL is not valid")
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		slog.WarnContext(ctx, "calendar fetch", "error", err)
+		if errors.Is(err, errNonPublicAddress) {
+			return nil, errNonPublicAddress
+		}
+		return nil, errors.New("calendar URL could not be fetched")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("calendar URL answered with status %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, MaxCalendarSize+1))
+	if err != nil {
+		return nil, errors.New("calendar URL could not be fetched")
+	}
+	if len(body) > MaxCalendarSize {
+		return nil, errors.New("calendar is larger than 5 MB")
+	}
+	return body, nil
+}
+
+// Sync reads the calendar (fetching its URL, or its uploaded content) and replaces its
+// busy times from yesterday to SyncHorizon. On failure the previous busy times are kept
+// and the error is recorded on the calendar.
+func Sync(ctx context.Context, cal *models.ExternalCalendar) error {
+	busy, err := readBusy(ctx, cal)
+
+	now := time.Now()
+	cal.LastSyncedAt = &now
+	if err != nil {
+		cal.LastError = err.Error()
+		if saveErr := db.DB.Model(cal).Updates(map[string]interface{}{
+			"last_synced_at": now,
+			"last_error":     cal.LastError,
+		}).Error; saveErr != nil {
+			return saveErr
+		}
+		return err
+	}
+
+	rows := make([]models.ExternalBusyTime, len(busy))
+	for i, b := range busy {
+		rows[i] = models.ExternalBusyTime{
+			ExternalCalendarID: cal.ID,
+			ProviderID:         cal.ProviderID,
+			StartTime:          b.Start,
+			EndTime:            b.End,
+		}
+	}
+
+	changed := events.Event{
+		Type: events.AvailabilityChanged,
+		Data: map[string]interface{}{
+			"action":               "calendar_synced",
+			"provider_id":          cal.ProviderID,
+			"external_calendar_id": cal.ID,
+		},
+		ProviderID: cal.ProviderID,
+		Public:     true,
+	}
+
+	cal.LastError = ""
+	cal.BusyCount = len(rows)
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("external_calendar_id = ?", cal.ID).Delete(&models.ExternalBusyTime{}).Error; err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			if err := tx.CreateInBatches(&rows, 500).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(cal).Updates(map[string]interface{}{
+			"last_synced_at": now,
+			"last_error":     "",
+			"busy_count":     cal.BusyCount,
+		}).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, changed)
+	})
+	if err != nil {
+		return err
+	}
+
+	webhooks.Wake()
+	events.Publish(changed)
+	return nil
+}
+
+// readBusy parses the calendar's busy periods within the sync window
+func readBusy(ctx context.Context, cal *models.ExternalCalendar) ([]scripts.Interval, error) {
+	content := []byte(cal.Source)
+	if cal.URL != "" {
+		var err error
+		if content, err = fetch(ctx, cal.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	from := time.Now().Add(-24 * time.Hour)
+	return ParseBusy(bytes.NewReader(content), from, from.Add(SyncHorizon))
+}
+
+// RunSync re-syncs every external calendar not synced for interval.
+// It blocks until ctx is cancelled.
+func RunSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	health.Beat("calendar-sync", interval)
+	defer health.Stop("calendar-sync")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			health.Beat("calendar-sync", interval)
+			run, span := tracing.StartJob(ctx, "calendar.sync_stale")
+			syncStale(run, time.Now().Add(-interval))
+			span.End()
+		}
+	}
+}
+
+// syncStale syncs the calendars last synced before cutoff
+func syncStale(ctx context.Context, cutoff time.Time) {
+	var calendars []models.ExternalCalendar
+	if err := db.DB.WithContext(ctx).Where("last_synced_at IS NULL OR last_synced_at < ?", cutoff).
+		Find(&calendars).Error; err != nil {
+		slog.ErrorContext(ctx, "calendar sync", "error", err)
+		return
+	}
+
+	for i := range calendars {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := Sync(ctx, &calendars[i]); err != nil {
+			slog.ErrorContext(ctx, "calendar sync", "calendar_id", calendars[i].ID, "provider_id", calendars[i].ProviderID, "error", err)
+		}
+	}
+}
+
+// BusyIntervals returns the imported busy times of the providers overlapping the window
+func BusyIntervals(providerIDs []uint, window scripts.Interval) (map[uint][]scripts.Interval, error) {
+	var rows []models.ExternalBusyTime
+	if err := db.DB.Where("provider_id IN ?", providerIDs).
+		Where("start_time < ? AND end_time > ?", window.End, window.Start).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	busy := map[uint][]scripts.Interval{}
+	for _, r := range rows {
+		busy[r.ProviderID] = append(busy[r.ProviderID], scripts.Interval{Start: r.StartTime, End: r.EndTime})
+	}
+	return busy, nil
+}
+
+// IsBusy reports whether an imported busy time of the provider overlaps [start, end)
+func IsBusy(ctx context.Context, providerID uint, start, end time.Time) (bool, error) {
+	var count int64
+	err := db.DB.WithContext(ctx).Model(&models.ExternalBusyTime{}).
+		Where("provider_id = ?", providerID).
+		Where("start_time < ? AND end_time > ?", end, start).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,318 @@
+// Package config loads the server settings.
+//
+// Settings come, by increasing priority, from the defaults below, an optional
+// YAML file named by CONFIG_FILE, an optional .env file and the environment.
+// A .env file never overrides variables already set in the environment.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the server
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Security SecurityConfig `yaml:"security"`
+	Payments PaymentsConfig `yaml:"payments"`
+	Log      LogConfig      `yaml:"log"`
+	Metrics  MetricsConfig  `yaml:"metrics"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+}
+
+// ServerConfig is the HTTP listener
+type ServerConfig struct {
+	Port string `yaml:"port"` // PORT
+
+	ReadTimeout  time.Duration `yaml:"read_timeout"`  // SERVER_READ_TIMEOUT, to read a whole request
+	WriteTimeout time.Duration `yaml:"write_timeout"` // SERVER_WRITE_TIMEOUT, to write a response (event streams excepted)
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`  // SERVER_IDLE_TIMEOUT, between keep-alive requests
+
+	// ShutdownTimeout bounds the wait for in-flight requests and workers on
+	// SIGINT/SIGTERM (SERVER_SHUTDOWN_TIMEOUT)
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// DatabaseConfig is the Postgres connection
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`      // DB_HOST
+	Port     string `yaml:"port"`      // DB_PORT
+	User     string `yaml:"user"`      // DB_USER
+	Password string `yaml:"password"`  // DB_PASSWORD
+	Name     string `yaml:"name"`      // DB_NAME
+	SSLMode  string `yaml:"ssl_mode"`  // DB_SSLMODE
+	TimeZone string `yaml:"time_zone"` // DB_TIMEZONE
+
+	// AutoMigrate applies pending migrations at startup (DB_AUTO_MIGRATE)
+	AutoMigrate bool `yaml:"auto_migrate"`
+
+	// SlowQuery logs the queries taking longer as warnings; 0 disables it (DB_SLOW_QUERY)
+	SlowQuery time.Duration `yaml:"slow_query"`
+}
+
+// DSN returns the connection string of the database
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode, d.TimeZone)
+}
+
+// AuthConfig covers the JWTs and the cookie carrying them
+type AuthConfig struct {
+	JWTSecret      string        `yaml:"jwt_secret"`      // JWT_SECRET
+	TokenTTL       time.Duration `yaml:"token_ttl"`       // JWT_EXPIRES_IN, e.g. "720h"
+	CookieDomain   string        `yaml:"cookie_domain"`   // COOKIE_DOMAIN
+	CookieSecure   bool          `yaml:"cookie_secure"`   // COOKIE_SECURE, HTTPS only
+	CookieSameSite string        `yaml:"cookie_samesite"` // COOKIE_SAMESITE: lax, strict or none
+}
+
+// sameSiteModes maps the COOKIE_SAMESITE values to their mode
+var sameSiteModes = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+// SameSite returns the SameSite mode of the auth cookies
+func (a AuthConfig) SameSite() http.SameSite {
+	if mode, ok := sameSiteModes[strings.ToLower(a.CookieSameSite)]; ok {
+		return mode
+	}
+	return http.SameSiteLaxMode
+}
+
+// CORSConfig lists the frontends allowed to call the API from a browser
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"` // CORS_ALLOW_ORIGINS, comma separated
+}
+
+// SecurityConfig sets the security headers of every response and the CSRF check
+type SecurityConfig struct {
+	// HSTSMaxAge tells browsers to only use HTTPS for that long; 0 sends no HSTS header,
+	// which suits local HTTP development (HSTS_MAX_AGE, e.g. "8760h")
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy"` // CONTENT_SECURITY_POLICY
+	FrameOptions          string        `yaml:"frame_options"`           // FRAME_OPTIONS
+	ReferrerPolicy        string        `yaml:"referrer_policy"`         // REFERRER_POLICY
+
+	// CSRF requires the X-CSRF-Token header on mutating requests authenticated
+	// by the auth cookie (CSRF_PROTECTION)
+	CSRF bool `yaml:"csrf"`
+}
+
+// PaymentsConfig selects the payment gateway
+type PaymentsConfig struct {
+	Gateway       string `yaml:"gateway"`        // PAYMENT_GATEWAY
+	WebhookSecret string `yaml:"webhook_secret"` // PAYMENT_WEBHOOK_SECRET
+}
+
+// LogConfig sets the server logs
+type LogConfig struct {
+	Level  string `yaml:"level"`  // LOG_LEVEL: debug, info, warn or error
+	Format string `yaml:"format"` // LOG_FORMAT: text, or json in production
+}
+
+// MetricsConfig exposes the Prometheus metrics on GET /metrics
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled"` // METRICS_ENABLED
+
+	// Token is required from scrapers as "Authorization: Bearer <token>", and must be
+	// set when metrics are enabled (METRICS_TOKEN)
+	Token string `yaml:"token"`
+}
+
+// TracingConfig exports OpenTelemetry traces of requests, queries and background jobs
+type TracingConfig struct {
+	// Exporter is "none" (spans are not recorded), "stdout" for local use, or
+	// "otlp" to send them to a collector over OTLP/HTTP (TRACING_EXPORTER)
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`     // TRACING_ENDPOINT, host:port of the OTLP collector
+	Insecure    bool    `yaml:"insecure"`     // TRACING_INSECURE, plain HTTP to the collector
+	SampleRatio float64 `yaml:"sample_ratio"` // TRACING_SAMPLE_RATIO, share of traces kept, 0 to 1
+	ServiceName string  `yaml:"service_name"` // TRACING_SERVICE_NAME
+}
+
+// tracingExporters are the accepted TRACING_EXPORTER values
+var tracingExporters = []string{"none", "stdout", "otlp"}
+
+// logLevels are the accepted LOG_LEVEL values
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// Default returns the settings used when nothing overrides them
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Port:        "5432",
+			SSLMode:     "disable",
+			TimeZone:    "Africa/Libreville",
+			AutoMigrate: true,
+			SlowQuery:   200 * time.Millisecond,
+		},
+		Auth: AuthConfig{
+			TokenTTL:       30 * 24 * time.Hour,
+			CookieDomain:   "localhost",
+			CookieSameSite: "lax",
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"http://localhost:4000", "http://localhost:3000"},
+		},
+		Security: SecurityConfig{
+			// The API only serves JSON and files: nothing may run or be framed
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			FrameOptions:          "DENY",
+			ReferrerPolicy:        "no-referrer",
+			CSRF:                  true,
+		},
+		Payments: PaymentsConfig{Gateway: "fake"},
+		Log:      LogConfig{Level: "info", Format: "text"},
+		Metrics:  MetricsConfig{Enabled: true},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "appointly-backend",
+		},
+	}
+}
+
+// Load reads the configuration; it does not validate it
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read .env: %w", err)
+	}
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overrides the settings present in a YAML file.
+// Unknown keys are rejected, so a typo does not silently fall back to a default.
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a port number, got %q", c.Server.Port))
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", timeout.name, timeout.value))
+		}
+	}
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if strings.TrimSpace(c.Auth.JWTSecret) == "" {
+		errs = append(errs, errors.New("JWT_SECRET is required"))
+	}
+	if c.Auth.TokenTTL <= 0 {
+		errs = append(errs, fmt.Errorf("JWT_EXPIRES_IN must be positive, got %s", c.Auth.TokenTTL))
+	}
+	if _, ok := sameSiteModes[strings.ToLower(c.Auth.CookieSameSite)]; !ok {
+		errs = append(errs, fmt.Errorf("COOKIE_SAMESITE must be lax, strict or none, got %q", c.Auth.CookieSameSite))
+	} else if c.Auth.SameSite() == http.SameSiteNoneMode && !c.Auth.CookieSecure {
+		errs = append(errs, errors.New("COOKIE_SAMESITE=none requires COOKIE_SECURE=true"))
+	}
+	for _, origin := range c.CORS.AllowOrigins {
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			errs = append(errs, fmt.Errorf("CORS_ALLOW_ORIGINS: %q is not an http(s) origin", origin))
+		}
+	}
+	if c.Security.HSTSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("HSTS_MAX_AGE must not be negative, got %s", c.Security.HSTSMaxAge))
+	}
+	if c.Payments.Gateway == "" {
+		errs = append(errs, errors.New("PAYMENT_GATEWAY is required"))
+	}
+	if strings.TrimSpace(c.Payments.WebhookSecret) == "" {
+		errs = append(errs, errors.New("PAYMENT_WEBHOOK_SECRET is required"))
+	}
+	if c.Metrics.Enabled && strings.TrimSpace(c.Metrics.Token) == "" {
+		errs = append(errs, errors.New("METRICS_TOKEN is required when METRICS_ENABLED is true"))
+	}
+	if !slices.Contains(logLevels, strings.ToLower(c.Log.Level)) {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	if format := strings.ToLower(c.Log.Format); format != "text" && format != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be text or json, got %q", c.Log.Format))
+	}
+	if !slices.Contains(tracingExporters, strings.ToLower(c.Tracing.Exporter)) {
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER must be none, stdout or otlp, got %q", c.Tracing.Exporter))
+	} else if strings.EqualFold(c.Tracing.Exporter, "otlp") && c.Tracing.Endpoint == "" {
+		errs = append(errs, errors.New("TRACING_ENDPOINT is required with TRACING_EXPORTER=otlp"))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the settings needed to connect. It is enough for the
+// migrate command, which does not serve requests.
+func (d DatabaseConfig) Validate() error {
+	var errs []error
+	required := []struct{ name, value string }{{"DB_HOST", d.Host}, {"DB_USER", d.User}, {"DB_NAME", d.Name}}
+	for _, setting := range required {
+		if setting.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", setting.name))
+		}
+	}
+	if port, err := strconv.Atoi(d.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("DB_PORT must be a port number, got %q", d.Port))
+	}
+	if d.SlowQuery < 0 {
+		errs = append(errs, fmt.Errorf("DB_SLOW_QUERY must not be negative, got %s", d.SlowQuery))
+	}
+	return errors.Join(errs...)
+}
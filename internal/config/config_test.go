@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig passes Validate
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.Host, cfg.Database.User, cfg.Database.Name = "localhost", "postgres", "appointly"
+	cfg.Auth.JWTSecret = "secret"
+	cfg.Payments.WebhookSecret = "webhook-secret"
+	cfg.Metrics.Token = "metrics-token"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("expected a valid configuration, got %v", err)
+	}
+
+	cases := map[string]func(*Config){
+		"JWT_SECRET":             func(c *Config) { c.Auth.JWTSecret = " " },
+		"PORT":                   func(c *Config) { c.Server.Port = "http" },
+		"DB_HOST":                func(c *Config) { c.Database.Host = "" },
+		"DB_PORT":                func(c *Config) { c.Database.Port = "0" },
+		"JWT_EXPIRES_IN":         func(c *Config) { c.Auth.TokenTTL = 0 },
+		"CORS_ALLOW_ORIGINS":     func(c *Config) { c.CORS.AllowOrigins = []string{"localhost:3000"} },
+		"PAYMENT_GATEWAY":        func(c *Config) { c.Payments.Gateway = "" },
+		"PAYMENT_WEBHOOK_SECRET": func(c *Config) { c.Payments.WebhookSecret = "" },
+		"COOKIE_SAMESITE":        func(c *Config) { c.Auth.CookieSameSite = "always" },
+		"COOKIE_SECURE":          func(c *Config) { c.Auth.CookieSameSite, c.Auth.CookieSecure = "none", false },
+		"HSTS_MAX_AGE":           func(c *Config) { c.Security.HSTSMaxAge = -time.Hour },
+		"SERVER_WRITE_TIMEOUT":   func(c *Config) { c.Server.WriteTimeout = 0 },
+		"LOG_LEVEL":              func(c *Config) { c.Log.Level = "verbose" },
+		"LOG_FORMAT":             func(c *Config) { c.Log.Format = "logfmt" },
+		"DB_SLOW_QUERY":          func(c *Config) { c.Database.SlowQuery = -time.Second },
+		"TRACING_EXPORTER":       func(c *Config) { c.Tracing.Exporter = "jaeger" },
+		"TRACING_ENDPOINT":       func(c *Config) { c.Tracing.Exporter, c.Tracing.Endpoint = "otlp", "" },
+		"TRACING_SAMPLE_RATIO":   func(c *Config) { c.Tracing.SampleRatio = 2 },
+		"METRICS_TOKEN":          func(c *Config) { c.Metrics.Token = "" },
+	}
+	for setting, breakIt := range cases {
+		cfg := validConfig()
+		breakIt(cfg)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), setting) {
+			t.Errorf("%s: expected an error naming the setting, got %v", setting, err)
+		}
+	}
+}
+
+func TestLoadWithoutDotEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("JWT_SECRET", "from-env")
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://app.example.com, https://admin.example.com")
+	t.Setenv("DB_AUTO_MIGRATE", "false")
+	t.Setenv("JWT_EXPIRES_IN", "1h")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("a missing .env must not be an error: %v", err)
+	}
+	if cfg.Auth.JWTSecret != "from-env" || cfg.Auth.TokenTTL != time.Hour || cfg.Database.AutoMigrate {
+		t.Fatalf("environment not applied: %+v", cfg)
+	}
+	if len(cfg.CORS.AllowOrigins) != 2 || cfg.CORS.AllowOrigins[1] != "https://admin.example.com" {
+		t.Fatalf("unexpected origins %q", cfg.CORS.AllowOrigins)
+	}
+}
+
+func TestLoadYAMLThenEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	file := filepath.Join(dir, "config.yaml")
+	yaml := "server:\n  port: \"9000\"\nauth:\n  jwt_secret: from-file\n  token_ttl: 2h\ndatabase:\n  host: db\n"
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("PORT", "9100")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != "9100" {
+		t.Errorf("the environment must override the file, got port %s", cfg.Server.Port)
+	}
+	if cfg.Auth.JWTSecret != "from-file" || cfg.Auth.TokenTTL != 2*time.Hour || cfg.Database.Host != "db" {
+		t.Errorf("file not applied: %+v", cfg)
+	}
+	if cfg.Database.Port != "5432" {
+		t.Errorf("settings missing from the file keep their default, got DB port %s", cfg.Database.Port)
+	}
+}
+
+func TestLoadRejectsUnknownYAMLKeys(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("auth:\n  jwt_secrett: typo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+
+	if _, err := Load(); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+}
+
+func TestLoadRejectsInvalidEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("COOKIE_SECURE", "sometimes")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "COOKIE_SECURE") {
+		t.Fatalf("expected an error naming COOKIE_SECURE, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadEnv overrides the settings whose environment variable is set
+func (c *Config) loadEnv() error {
+	setString(&c.Server.Port, "PORT")
+
+	setString(&c.Database.Host, "DB_HOST")
+	setString(&c.Database.Port, "DB_PORT")
+	setString(&c.Database.User, "DB_USER")
+	setString(&c.Database.Password, "DB_PASSWORD")
+	setString(&c.Database.Name, "DB_NAME")
+	setString(&c.Database.SSLMode, "DB_SSLMODE")
+	setString(&c.Database.TimeZone, "DB_TIMEZONE")
+
+	setString(&c.Auth.JWTSecret, "JWT_SECRET")
+	setString(&c.Auth.CookieDomain, "COOKIE_DOMAIN")
+	setString(&c.Auth.CookieSameSite, "COOKIE_SAMESITE")
+	setList(&c.CORS.AllowOrigins, "CORS_ALLOW_ORIGINS")
+
+	setString(&c.Security.ContentSecurityPolicy, "CONTENT_SECURITY_POLICY")
+	setString(&c.Security.FrameOptions, "FRAME_OPTIONS")
+	setString(&c.Security.ReferrerPolicy, "REFERRER_POLICY")
+
+	setString(&c.Payments.Gateway, "PAYMENT_GATEWAY")
+	setString(&c.Payments.WebhookSecret, "PAYMENT_WEBHOOK_SECRET")
+
+	setString(&c.Log.Level, "LOG_LEVEL")
+	setString(&c.Log.Format, "LOG_FORMAT")
+	setString(&c.Metrics.Token, "METRICS_TOKEN")
+	setString(&c.Tracing.Exporter, "TRACING_EXPORTER")
+	setString(&c.Tracing.Endpoint, "TRACING_ENDPOINT")
+	setString(&c.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+
+	if err := setBool(&c.Database.AutoMigrate, "DB_AUTO_MIGRATE"); err != nil {
+		return err
+	}
+	if err := setBool(&c.Auth.CookieSecure, "COOKIE_SECURE"); err != nil {
+		return err
+	}
+	if err := setBool(&c.Security.CSRF, "CSRF_PROTECTION"); err != nil {
+		return err
+	}
+	if err := setBool(&c.Metrics.Enabled, "METRICS_ENABLED"); err != nil {
+		return err
+	}
+	if err := setBool(&c.Tracing.Insecure, "TRACING_INSECURE"); err != nil {
+		return err
+	}
+	if err := setFloat(&c.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Security.HSTSMaxAge, "HSTS_MAX_AGE"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Database.SlowQuery, "DB_SLOW_QUERY"); err != nil {
+		return err
+	}
+	durations := map[string]*time.Duration{
+		"SERVER_READ_TIMEOUT":     &c.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":    &c.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":     &c.Server.IdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT": &c.Server.ShutdownTimeout,
+	}
+	for name, target := range durations {
+		if err := setDuration(target, name); err != nil {
+			return err
+		}
+	}
+	return setDuration(&c.Auth.TokenTTL, "JWT_EXPIRES_IN")
+}
+
+func setString(target *string, name string) {
+	if v, ok := os.LookupEnv(name); ok {
+		*target = v
+	}
+}
+
+// setList splits a comma separated variable, dropping empty items
+func setList(target *[]string, name string) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	list := []string{}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*target = list
+}
+
+func setBool(target *bool, name string) error {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s must be true or false, got %q", name, v)
+	}
+	*target = b
+	return nil
+}
+
+func setFloat(target *float64, name string) error {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a number, got %q", name, v)
+	}
+	*target = f
+	return nil
+}
+
+func setDuration(target *time.Duration, name string) error {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s must be a duration such as 720h, got %q", name, v)
+	}
+	*target = d
+	return nil
+}
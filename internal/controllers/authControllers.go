@@ -1,14 +1,14 @@
 package controllers
 
 import (
-	"fmt"
-	"gorm.io/gorm"
+	"log/slog"
 	"net/http"
-	"os"
 	"time"
 
-	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/config"
+	"github.com/adriel-meb/appointly-backend/internal/middleware"
 	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
@@ -18,10 +18,22 @@ import (
 
 // ---------------------- AUTH HANDLERS ---------------------- //
 
+// AuthController serves sign-up, login and logout
+type AuthController struct {
+	users repository.UserRepository
+	auth  config.AuthConfig
+}
+
+// NewAuthController returns the sign-up, login and logout handlers.
+// Tokens are signed and their cookie set according to auth.
+func NewAuthController(users repository.UserRepository, auth config.AuthConfig) *AuthController {
+	return &AuthController{users: users, auth: auth}
+}
+
 // Signup handles POST /signup
 // Creates a new user account
 // Signup handles POST /signup
-func Signup(c *gin.Context) {
+func (ctl *AuthController) Signup(c *gin.Context) {
 	type SignupInput struct {
 		Name             string  `json:"name" binding:"required"`
 		Email            string  `json:"email" binding:"required,email"`
@@ -35,13 +47,11 @@ func Signup(c *gin.Context) {
 	var input SignupInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Error: err.Error()})
-		fmt.Println(err.Error())
 		return
 	}
 
 	// Check if email exists
-	var existing models.User
-	if err := db.DB.Where("email = ?", input.Email).First(&existing).Error; err == nil {
+	if _, err := ctl.users.FindByEmail(c.Request.Context(), input.Email); err == nil {
 		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Error: "Email already registered"})
 		return
 	}
@@ -67,38 +77,28 @@ func Signup(c *gin.Context) {
 		PhoneNumber:  input.PhoneNumber,
 	}
 
-	// Transaction: user + provider (if needed)
-	err = db.DB.Transaction(func(tx *gorm.DB) error {
-		// Save user
-		if err := tx.Create(&user).Error; err != nil {
-			return err
+	// If provider, also save specialization + bio
+	var provider *models.Provider
+	if role == string(models.RoleProvider) {
+		// Ensure specialization is provided
+		if input.SpecializationID == nil {
+			c.JSON(http.StatusInternalServerError, APIResponse{Status: "error", Error: "specialization_id is required for providers"})
+			return
 		}
 
-		// If provider, also save specialization + bio
-		if role == string(models.RoleProvider) {
-			// Ensure specialization is provided
-			if input.SpecializationID == nil {
-				return fmt.Errorf("specialization_id is required for providers")
-			}
-
-			provider := models.Provider{
-				UserID:           user.ID,
-				SpecializationID: *input.SpecializationID,
-				Bio:              input.Bio,
-			}
-			if err := tx.Create(&provider).Error; err != nil {
-				return err
-			}
+		provider = &models.Provider{
+			SpecializationID: *input.SpecializationID,
+			Bio:              input.Bio,
 		}
-		return nil
-	})
+	}
 
-	if err != nil {
+	// Saved together: user + provider (if needed)
+	if err := ctl.users.Create(c.Request.Context(), &user, provider); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{Status: "error", Error: err.Error()})
 		return
 	}
 
-	fmt.Println("User created successfully")
+	slog.InfoContext(c.Request.Context(), "user created", "user_id", user.ID, "role", user.Role)
 	// Success response
 	c.JSON(http.StatusCreated, APIResponse{
 		Status:  "success",
@@ -115,7 +115,7 @@ func Signup(c *gin.Context) {
 
 // Login handles POST /login
 // Authenticates a user and returns a JWT
-func Login(c *gin.Context) {
+func (ctl *AuthController) Login(c *gin.Context) {
 	// 1️⃣ Bind request body
 	var input struct {
 		Email    string `json:"email" binding:"required,email"` // Must be valid email
@@ -127,8 +127,8 @@ func Login(c *gin.Context) {
 	}
 
 	// 2️⃣ Fetch user from database by email
-	var user models.User
-	if err := db.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+	user, err := ctl.users.FindByEmail(c.Request.Context(), input.Email)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Invalid email or password"})
 		return
 	}
@@ -144,29 +144,24 @@ func Login(c *gin.Context) {
 		"sub":   user.ID, // Subject = User ID
 		"email": user.Email,
 		"role":  user.Role,
-		"iat":   time.Now().Unix(),                          // Issued at
-		"exp":   time.Now().Add(time.Hour * 24 * 30).Unix(), // Expiration 30 days
+		"iat":   time.Now().Unix(),                        // Issued at
+		"exp":   time.Now().Add(ctl.auth.TokenTTL).Unix(), // Expiration
 	})
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokenString, err := token.SignedString([]byte(ctl.auth.JWTSecret))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{Status: "error", Error: "Failed to generate token"})
 		return
 	}
 
-	// 5️⃣ Set auth cookie for browser clients (optional)
-	//c.SetSameSite(http.SameSiteNoneMode) // 👈 Allow cross-site
-	c.SetSameSite(http.SameSiteLaxMode) // works for localhost
-	c.SetCookie(
-		"jwt_token", // name
-		tokenString, // value
-		3600*24*30,  // maxAge: 30 days
-		"/",         // path
-		"localhost", // domain (use your frontend domain if cross-site)
-		false,
-		//gin.Mode() == gin.ReleaseMode, // secure only in prod
-		true, // httpOnly
-	)
+	// 5️⃣ Set auth cookie for browser clients (optional), with the CSRF token
+	// their mutating requests must echo
+	csrfToken, err := middleware.NewCSRFToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{Status: "error", Error: "Failed to generate token"})
+		return
+	}
+	ctl.setAuthCookies(c, tokenString, csrfToken, int(ctl.auth.TokenTTL.Seconds()))
 
 	// 6️⃣ Return JSON with token and user info
 	c.Header("Authorization", "Bearer "+tokenString)
@@ -174,7 +169,8 @@ func Login(c *gin.Context) {
 		Status:  "success",
 		Message: "Login successful",
 		Data: gin.H{
-			"token": tokenString,
+			"token":      tokenString,
+			"csrf_token": csrfToken,
 			"user": gin.H{
 				"id":    user.ID,
 				"name":  user.Name,
@@ -223,21 +219,19 @@ func GetProfile(c *gin.Context) {
 	})
 }
 
+// setAuthCookies sets the JWT cookie, hidden from scripts, and the CSRF cookie
+// the frontend reads to fill the X-CSRF-Token header
+func (ctl *AuthController) setAuthCookies(c *gin.Context, token, csrfToken string, maxAge int) {
+	c.SetSameSite(ctl.auth.SameSite())
+	c.SetCookie(middleware.AuthCookie, token, maxAge, "/", ctl.auth.CookieDomain, ctl.auth.CookieSecure, true)
+	c.SetCookie(middleware.CSRFCookie, csrfToken, maxAge, "/", ctl.auth.CookieDomain, ctl.auth.CookieSecure, false)
+}
+
 // Logout handles POST /logout
 // Clears the JWT cookie to log the user out
-func Logout(c *gin.Context) {
-	fmt.Println("----------- logout ------------")
-
-	// Clear the JWT cookie by setting MaxAge to -1
-	c.SetCookie(
-		"jwt_token", // cookie name
-		"",          // empty value
-		-1,          // MaxAge -1 deletes the cookie
-		"/",         // path
-		"localhost", // domain (your frontend domain)
-		false,       // secure false for localhost
-		true,        // httpOnly
-	)
+func (ctl *AuthController) Logout(c *gin.Context) {
+	// Clear the JWT and CSRF cookies by setting MaxAge to -1
+	ctl.setAuthCookies(c, "", "", -1)
 
 	// Optionally, also clear Authorization header if used
 	c.Header("Authorization", "")
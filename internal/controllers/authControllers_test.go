@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSignupThenLogin(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewAuthController(s.repos.Users, config.AuthConfig{JWTSecret: "test-secret", TokenTTL: time.Hour})
+
+	signup := gin.H{"name": "New Patient", "email": "new@example.com", "password": "secret123"}
+	expectStatus(t, serve(t, "/auth/register", ctl.Signup, nil, http.MethodPost, "/auth/register", signup), http.StatusCreated)
+	// the email is taken now
+	expectStatus(t, serve(t, "/auth/register", ctl.Signup, nil, http.MethodPost, "/auth/register", signup), http.StatusBadRequest)
+
+	rec := serve(t, "/auth/login", ctl.Login, nil, http.MethodPost, "/auth/login",
+		gin.H{"email": "new@example.com", "password": "secret123"})
+	expectStatus(t, rec, http.StatusOK)
+	var body struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	decode(t, rec, &body)
+	if body.Data.Token == "" {
+		t.Fatal("expected a token")
+	}
+
+	rec = serve(t, "/auth/login", ctl.Login, nil, http.MethodPost, "/auth/login",
+		gin.H{"email": "new@example.com", "password": "wrong-password"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
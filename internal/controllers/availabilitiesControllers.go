@@ -6,8 +6,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/adriel-meb/appointly-backend/internal/db"
 	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
 	"github.com/adriel-meb/appointly-backend/scripts"
 	"github.com/gin-gonic/gin"
 )
@@ -30,10 +30,39 @@ type AvailabilityResponse struct {
 	Slots       []AvailabilitySlotResponse `json:"slots"`
 }
 
+// AvailabilityController serves /availabilities
+type AvailabilityController struct {
+	availabilities repository.AvailabilityRepository
+	providers      repository.ProviderRepository
+}
+
+// NewAvailabilityController returns the availabilities handlers
+func NewAvailabilityController(availabilities repository.AvailabilityRepository, providers repository.ProviderRepository) *AvailabilityController {
+	return &AvailabilityController{availabilities: availabilities, providers: providers}
+}
+
+// availabilitySlots splits an availability's hours into slots of slotMinutes
+func availabilitySlots(startTime, endTime string, slotMinutes int, includeEnd bool) ([]models.AvailabilitySlot, error) {
+	slotTimes, err := scripts.GenerateTimeSlots(startTime, endTime, slotMinutes, true, includeEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []models.AvailabilitySlot
+	for i := 0; i < len(slotTimes)-1; i++ {
+		slots = append(slots, models.AvailabilitySlot{
+			StartTime: slotTimes[i],
+			EndTime:   slotTimes[i+1],
+			IsBooked:  false,
+		})
+	}
+	return slots, nil
+}
+
 // -----------------------------
 // 1️⃣ CREATE AVAILABILITY
 // -----------------------------
-func CreateAvailability(c *gin.Context) {
+func (ctl *AvailabilityController) CreateAvailability(c *gin.Context) {
 	type AvailabilityInput struct {
 		ProviderID  uint                  `json:"provider_id" binding:"required"`
 		DayOfWeek   *models.DayOfWeekEnum `json:"day_of_week"`
@@ -82,8 +111,7 @@ func CreateAvailability(c *gin.Context) {
 	}
 
 	// ✅ Check provider exists
-	var provider models.Provider
-	if err := db.DB.First(&provider, input.ProviderID).Error; err != nil {
+	if _, err := ctl.providers.FindByID(c.Request.Context(), input.ProviderID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "Provider not found",
@@ -91,16 +119,6 @@ func CreateAvailability(c *gin.Context) {
 		return
 	}
 
-	// ✅ Check for existing overlaps
-	var existing []models.Availability
-	query := db.DB.Where("provider_id = ?", input.ProviderID)
-	if input.IsRecurring {
-		query = query.Where("day_of_week = ? AND is_recurring = true", input.DayOfWeek)
-	} else {
-		query = query.Where("date = ? AND is_recurring = false", parsedDate)
-	}
-	query.Find(&existing)
-
 	newAvail := models.Availability{
 		ProviderID:  input.ProviderID,
 		DayOfWeek:   input.DayOfWeek,
@@ -110,32 +128,28 @@ func CreateAvailability(c *gin.Context) {
 		EndTime:     input.EndTime,
 	}
 
-	if err := scripts.ValidateAvailabilityInput(newAvail, existing); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+	// ✅ Check for existing overlaps
+	existing, err := ctl.availabilities.FindSameDay(c.Request.Context(), newAvail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
-			"message": err.Error(),
+			"message": "Failed to check existing availabilities",
+			"error":   err.Error(),
 		})
 		return
 	}
 
-	// ✅ Begin transaction
-	tx := db.DB.Begin()
-
-	// Create availability record
-	if err := tx.Create(&newAvail).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
+	if err := scripts.ValidateAvailabilityInput(newAvail, existing); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
-			"message": "Failed to create availability",
-			"error":   err.Error(),
+			"message": err.Error(),
 		})
 		return
 	}
 
 	// ✅ Generate slots
-	slotTimes, err := scripts.GenerateTimeSlots(input.StartTime, input.EndTime, input.SlotMinutes, true, true)
+	slots, err := availabilitySlots(input.StartTime, input.EndTime, input.SlotMinutes, true)
 	if err != nil {
-		tx.Rollback()
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "Failed to generate slots",
@@ -144,31 +158,17 @@ func CreateAvailability(c *gin.Context) {
 		return
 	}
 
-	// Build slot models
-	var slots []models.AvailabilitySlot
-	for i := 0; i < len(slotTimes)-1; i++ {
-		slots = append(slots, models.AvailabilitySlot{
-			AvailabilityID: newAvail.ID,
-			StartTime:      slotTimes[i],
-			EndTime:        slotTimes[i+1],
-			IsBooked:       false,
+	// ✅ Save availability and slots together
+	if err := ctl.availabilities.Create(c.Request.Context(), &newAvail, slots); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to create availability",
+			"error":   err.Error(),
 		})
+		return
 	}
 
-	// ✅ Save slots
-	if len(slots) > 0 {
-		if err := tx.Create(&slots).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status":  "error",
-				"message": "Failed to save slots",
-				"error":   err.Error(),
-			})
-			return
-		}
-	}
-
-	tx.Commit()
+	publishAvailabilityEvent(newAvail, "created")
 
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
@@ -183,13 +183,17 @@ func CreateAvailability(c *gin.Context) {
 // -----------------------------
 // 2️⃣ GET ALL AVAILABILITIES
 // -----------------------------
-func GetAllAvailability(c *gin.Context) {
-	var availabilities []models.Availability
-	dbQuery := db.DB.Preload("Provider").Preload("Slots")
+func (ctl *AvailabilityController) GetAllAvailability(c *gin.Context) {
+	var filter repository.AvailabilityFilter
 
 	// Optional filters
 	if providerID := c.Query("provider_id"); providerID != "" {
-		dbQuery = dbQuery.Where("provider_id = ?", providerID)
+		id, err := strconv.ParseUint(providerID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid provider_id"})
+			return
+		}
+		filter.ProviderID = uint(id)
 	}
 	if dateStr := c.Query("date"); dateStr != "" {
 		date, err := time.Parse("2006-01-02", dateStr)
@@ -197,7 +201,7 @@ func GetAllAvailability(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid date format. Use YYYY-MM-DD."})
 			return
 		}
-		dbQuery = dbQuery.Where("date = ?", date)
+		filter.Date = &date
 	}
 	if startStr := c.Query("start_date"); startStr != "" {
 		start, err := time.Parse("2006-01-02", startStr)
@@ -205,7 +209,7 @@ func GetAllAvailability(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid start_date format. Use YYYY-MM-DD."})
 			return
 		}
-		dbQuery = dbQuery.Where("date >= ?", start)
+		filter.From = &start
 	}
 	if endStr := c.Query("end_date"); endStr != "" {
 		end, err := time.Parse("2006-01-02", endStr)
@@ -213,10 +217,11 @@ func GetAllAvailability(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid end_date format. Use YYYY-MM-DD."})
 			return
 		}
-		dbQuery = dbQuery.Where("date <= ?", end)
+		filter.To = &end
 	}
 
-	if err := dbQuery.Find(&availabilities).Error; err != nil {
+	availabilities, err := ctl.availabilities.List(c.Request.Context(), filter)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to fetch availabilities", "error": err.Error()})
 		return
 	}
@@ -260,15 +265,15 @@ func GetAllAvailability(c *gin.Context) {
 // -----------------------------
 // 3️⃣ GET AVAILABILITY BY ID
 // -----------------------------
-func GetAvailabilityByID(c *gin.Context) {
+func (ctl *AvailabilityController) GetAvailabilityByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid availability ID", "error": err.Error()})
 		return
 	}
 
-	var availability models.Availability
-	if err := db.DB.Preload("Provider").Preload("Slots").First(&availability, id).Error; err != nil {
+	availability, err := ctl.availabilities.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Availability not found", "error": err.Error()})
 		return
 	}
@@ -304,15 +309,15 @@ func GetAvailabilityByID(c *gin.Context) {
 // -----------------------------
 // 4️⃣ UPDATE AVAILABILITY
 // -----------------------------
-func UpdateAvailability(c *gin.Context) {
+func (ctl *AvailabilityController) UpdateAvailability(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid availability ID", "error": err.Error()})
 		return
 	}
 
-	var availability models.Availability
-	if err := db.DB.Preload("Slots").First(&availability, id).Error; err != nil {
+	availability, err := ctl.availabilities.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Availability not found", "error": err.Error()})
 		return
 	}
@@ -344,17 +349,8 @@ func UpdateAvailability(c *gin.Context) {
 		input.DayOfWeek = &enumDay
 	}
 
-	// Check existing availabilities excluding current one
-	var existing []models.Availability
-	query := db.DB.Where("provider_id = ? AND id != ?", input.ProviderID, availability.ID)
-	if input.IsRecurring && input.DayOfWeek != nil {
-		query = query.Where("day_of_week = ? AND is_recurring = true", input.DayOfWeek)
-	} else if !input.IsRecurring && input.Date != nil {
-		query = query.Where("date = ? AND is_recurring = false", input.Date)
-	}
-	query.Find(&existing)
-
 	updated := models.Availability{
+		ID:          availability.ID,
 		ProviderID:  input.ProviderID,
 		DayOfWeek:   input.DayOfWeek,
 		IsRecurring: input.IsRecurring,
@@ -363,13 +359,24 @@ func UpdateAvailability(c *gin.Context) {
 		EndTime:     input.EndTime,
 	}
 
+	// Check existing availabilities excluding current one
+	existing, err := ctl.availabilities.FindSameDay(c.Request.Context(), updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to check existing availabilities", "error": err.Error()})
+		return
+	}
+
 	if err := scripts.ValidateAvailabilityInput(updated, existing); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": err.Error()})
 		return
 	}
 
-	// Begin transaction
-	tx := db.DB.Begin()
+	// Generate new slots
+	newSlots, err := availabilitySlots(input.StartTime, input.EndTime, input.SlotMinutes, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Failed to generate slots", "error": err.Error()})
+		return
+	}
 
 	// Update availability fields
 	availability.ProviderID = input.ProviderID
@@ -379,48 +386,13 @@ func UpdateAvailability(c *gin.Context) {
 	availability.StartTime = input.StartTime
 	availability.EndTime = input.EndTime
 
-	if err := tx.Save(&availability).Error; err != nil {
-		tx.Rollback()
+	// Save the availability and replace its slots together
+	if err := ctl.availabilities.Update(c.Request.Context(), &availability, newSlots); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to update availability", "error": err.Error()})
 		return
 	}
 
-	// Delete old slots
-	if len(availability.Slots) > 0 {
-		if err := tx.Where("availability_id = ?", availability.ID).Delete(&models.AvailabilitySlot{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to delete old slots", "error": err.Error()})
-			return
-		}
-	}
-
-	// Generate new slots
-	slotTimes, err := scripts.GenerateTimeSlots(input.StartTime, input.EndTime, input.SlotMinutes, true, false)
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Failed to generate slots", "error": err.Error()})
-		return
-	}
-
-	var newSlots []models.AvailabilitySlot
-	for i := 0; i < len(slotTimes)-1; i++ {
-		newSlots = append(newSlots, models.AvailabilitySlot{
-			AvailabilityID: availability.ID,
-			StartTime:      slotTimes[i],
-			EndTime:        slotTimes[i+1],
-			IsBooked:       false,
-		})
-	}
-
-	if len(newSlots) > 0 {
-		if err := tx.Create(&newSlots).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to save new slots", "error": err.Error()})
-			return
-		}
-	}
-
-	tx.Commit()
+	publishAvailabilityEvent(availability, "updated")
 
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Availability updated with slots successfully", "data": availability})
 }
@@ -428,23 +400,25 @@ func UpdateAvailability(c *gin.Context) {
 // -----------------------------
 // 5️⃣ DELETE AVAILABILITY
 // -----------------------------
-func DeleteAvailability(c *gin.Context) {
+func (ctl *AvailabilityController) DeleteAvailability(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid availability ID", "error": err.Error()})
 		return
 	}
 
-	var availability models.Availability
-	if err := db.DB.First(&availability, id).Error; err != nil {
+	availability, err := ctl.availabilities.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Availability not found", "error": err.Error()})
 		return
 	}
 
-	if err := db.DB.Delete(&availability).Error; err != nil {
+	if err := ctl.availabilities.Delete(c.Request.Context(), availability); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to delete availability", "error": err.Error()})
 		return
 	}
 
+	publishAvailabilityEvent(availability, "deleted")
+
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Availability deleted successfully"})
 }
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateAvailabilityRejectsOverlaps(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewAvailabilityController(s.repos.Availabilities, s.repos.Providers)
+
+	input := gin.H{"provider_id": s.Provider.ID, "date": "2030-01-07", "start_time": "09:00", "end_time": "12:00"}
+	rec := serve(t, "/availabilities", ctl.CreateAvailability, nil, http.MethodPost, "/availabilities", input)
+	expectStatus(t, rec, http.StatusCreated)
+	var body struct {
+		Data struct {
+			Slots []struct{} `json:"slots"`
+		} `json:"data"`
+	}
+	decode(t, rec, &body)
+	if len(body.Data.Slots) != 6 {
+		t.Fatalf("expected 6 slots of 30 minutes from 09:00 to 12:00, got %d", len(body.Data.Slots))
+	}
+
+	overlapping := gin.H{"provider_id": s.Provider.ID, "date": "2030-01-07", "start_time": "11:00", "end_time": "13:00"}
+	rec = serve(t, "/availabilities", ctl.CreateAvailability, nil, http.MethodPost, "/availabilities", overlapping)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	unknown := gin.H{"provider_id": 999, "date": "2030-01-07", "start_time": "09:00", "end_time": "12:00"}
+	rec = serve(t, "/availabilities", ctl.CreateAvailability, nil, http.MethodPost, "/availabilities", unknown)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
@@ -1,14 +1,35 @@
 package controllers
 
 import (
-	"github.com/adriel-meb/appointly-backend/internal/db"
+	"context"
+	"errors"
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/metrics"
 	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/adriel-meb/appointly-backend/scripts"
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// BookingController serves /bookings
+type BookingController struct {
+	bookings       repository.BookingRepository
+	availabilities repository.AvailabilityRepository
+	providers      repository.ProviderRepository
+	effects        BookingEffects
+}
+
+// NewBookingController returns the bookings handlers
+func NewBookingController(bookings repository.BookingRepository, availabilities repository.AvailabilityRepository,
+	providers repository.ProviderRepository, effects BookingEffects) *BookingController {
+	return &BookingController{bookings: bookings, availabilities: availabilities, providers: providers, effects: effects}
+}
+
 // CreateBookingInput represents request body
 type CreateBookingInput struct {
 	PatientID  uint   `json:"patient_id" binding:"required"`
@@ -16,10 +37,13 @@ type CreateBookingInput struct {
 	ServiceID  uint   `json:"service_id" binding:"required"`
 	StartTime  string `json:"start_time" binding:"required"` // ISO8601 e.g. "2025-09-02T15:00:00Z"
 	Notes      string `json:"notes" `
+
+	// Optional: bill part of the price to one of the patient's insurers
+	InsuranceMembershipID *uint `json:"insurance_membership_id"`
 }
 
 // CreateBooking handles POST /bookings
-func CreateBooking(c *gin.Context) {
+func (ctl *BookingController) CreateBooking(c *gin.Context) {
 	var input CreateBookingInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Invalid input", "error": err.Error()})
@@ -34,8 +58,8 @@ func CreateBooking(c *gin.Context) {
 	}
 
 	// 2. Fetch service to get duration
-	var service models.Service
-	if err := db.DB.First(&service, input.ServiceID).Error; err != nil {
+	service, err := ctl.providers.FindService(c.Request.Context(), input.ServiceID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Service not found"})
 		return
 	}
@@ -44,48 +68,82 @@ func CreateBooking(c *gin.Context) {
 	endTime := startTime.Add(time.Duration(service.DurationMinutes) * time.Minute)
 
 	// 4. Check provider availability
-	var availability models.Availability
-	err = db.DB.Where("provider_id = ?", input.ProviderID).
-		Where("? BETWEEN start_time AND end_time", startTime.Format("15:04")).
-		Where("? BETWEEN start_time AND end_time", endTime.Format("15:04")).
-		First(&availability).Error
-
+	_, err = ctl.availabilities.FindCovering(c.Request.Context(), input.ProviderID, startTime.Format("15:04"), endTime.Format("15:04"))
 	if err != nil {
 		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Provider not available at this time"})
 		return
 	}
 
 	// 5. Check for overlapping bookings
-	var overlap int64
-	db.DB.Model(&models.Booking{}).
-		Where("provider_id = ?", input.ProviderID).
-		Where("status IN ?", []string{"pending", "confirmed"}).
-		Where("start_time < ? AND end_time > ?", endTime, startTime).
-		Count(&overlap)
-
+	overlap, err := ctl.bookings.CountOverlapping(c.Request.Context(), input.ProviderID, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to check availability", "error": err.Error()})
+		return
+	}
 	if overlap > 0 {
 		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "This slot is already booked"})
 		return
 	}
 
-	// 6. Create booking
+	// 5b. Check the provider's imported calendars
+	busy, err := ctl.effects.IsBusy(c.Request.Context(), input.ProviderID, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to check availability", "error": err.Error()})
+		return
+	}
+	if busy {
+		c.JSON(http.StatusConflict, gin.H{"status": "error", "message": "Provider is busy at this time"})
+		return
+	}
+
+	// 6. Split the price with the patient's insurer, if any
+	insured, payable := 0.0, service.Price
+	if input.InsuranceMembershipID != nil {
+		provider, err := ctl.providers.FindByID(c.Request.Context(), input.ProviderID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"status": "error", "message": "Provider not found"})
+			return
+		}
+
+		membership, err := ctl.effects.CheckCoverage(c.Request.Context(), *input.InsuranceMembershipID, input.PatientID, provider, startTime)
+		var refused *CoverageError
+		if errors.As(err, &refused) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"status": "error", "message": "Insurance not accepted", "error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to check insurance coverage", "error": err.Error()})
+			return
+		}
+		insured, payable = scripts.InsuranceSplit(service.Price, membership.CoveragePercent)
+	}
+
+	// 7. Create booking
 	booking := models.Booking{
-		PatientID:  input.PatientID,
-		ProviderID: input.ProviderID,
-		ServiceID:  input.ServiceID,
-		StartTime:  startTime,
-		EndTime:    endTime,
-		Status:     models.Pending, // default
-		Notes:      input.Notes,
-		Amount:     service.Price,
+		PatientID:             input.PatientID,
+		ProviderID:            input.ProviderID,
+		ServiceID:             input.ServiceID,
+		StartTime:             startTime,
+		EndTime:               endTime,
+		Status:                models.Pending, // default
+		Notes:                 input.Notes,
+		Amount:                service.Price,
+		InsuranceMembershipID: input.InsuranceMembershipID,
+		InsuredAmount:         insured,
+		PatientAmount:         payable,
 	}
 
-	if err := db.DB.Create(&booking).Error; err != nil {
+	if err := ctl.bookings.Create(c.Request.Context(), &booking); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "Failed to create booking", "error": err.Error()})
 		return
 	}
 
-	// 7. Success response
+	ctl.effects.Publish(c.Request.Context(), events.BookingCreated, booking)
+	ctl.countBooking(c.Request.Context(), metrics.BookingCreated, booking.ProviderID)
+	ctl.effects.Notify(c.Request.Context(), booking, models.NotifyBookingCreated,
+		"Your appointment on "+booking.StartTime.Format("02/01/2006 15:04")+" has been requested")
+
+	// 8. Success response
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
 		"message": "Booking created successfully",
@@ -93,11 +151,55 @@ func CreateBooking(c *gin.Context) {
 	})
 }
 
-func GetAllBooking(c *gin.Context) {
+// GetAllBooking handles GET /bookings (admin only)
+// Filters: ?status=&payment_status=&provider_id=&patient_id=&from=&to= (on start_time),
+// plus ?limit=&sort=&cursor=&fields=
+func (ctl *BookingController) GetAllBooking(c *gin.Context) {
+	params, ok := parseListParams(c, bookingsListSpec)
+	if !ok {
+		return
+	}
 
-	var bookings []models.Booking
+	var filter repository.BookingFilter
+	if v := c.Query("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+	if v := c.Query("payment_status"); v != "" {
+		filter.PaymentStatuses = strings.Split(v, ",")
+	}
+	for column, target := range map[string]*uint{"provider_id": &filter.ProviderID, "patient_id": &filter.PatientID} {
+		if v := c.Query(column); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, APIResponse{
+					Status:  "error",
+					Message: "Invalid " + column,
+				})
+				return
+			}
+			*target = uint(id)
+		}
+	}
+	for param, bound := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := c.Query(param); v != "" {
+			t, err := parseSearchTime(v, param == "to")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, APIResponse{
+					Status:  "error",
+					Message: "Invalid " + param,
+					Error:   err.Error(),
+				})
+				return
+			}
+			*bound = &t
+		}
+	}
 
-	if err := db.DB.Find(&bookings).Error; err != nil {
+	bookings, page, err := ctl.bookings.List(c.Request.Context(), filter, params)
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to fetch bookings",
@@ -107,14 +209,10 @@ func GetAllBooking(c *gin.Context) {
 	}
 
 	// Success response
-	c.JSON(http.StatusOK, APIResponse{
-		Status:  "success",
-		Message: "bookings fetched successfully",
-		Data:    bookings,
-	})
+	respondList(c, "bookings fetched successfully", bookings, len(bookings), params, page)
 }
 
-func ConfirmBooking(c *gin.Context) {
+func (ctl *BookingController) ConfirmBooking(c *gin.Context) {
 	// booking input form
 	type ConfirmInput struct {
 		ID uint `json:"id" binding:"required"`
@@ -131,8 +229,8 @@ func ConfirmBooking(c *gin.Context) {
 	}
 
 	// check if booking exists
-	var booking models.Booking
-	if err := db.DB.First(&booking, inputID.ID).Error; err != nil {
+	booking, err := ctl.bookings.FindByID(c.Request.Context(), inputID.ID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Booking not found",
@@ -149,9 +247,19 @@ func ConfirmBooking(c *gin.Context) {
 		return
 	}
 
+	// prepaid services are confirmed by the payment webhook
+	if service, err := ctl.providers.FindService(c.Request.Context(), booking.ServiceID); err == nil &&
+		service.RequiresPrepayment && booking.PaymentStatus != models.PaymentPaid {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "This service requires prepayment: the booking is confirmed once paid",
+		})
+		return
+	}
+
 	// update the status
 	booking.Status = models.Confirmed
-	if err := db.DB.Save(&booking).Error; err != nil {
+	if err := ctl.bookings.Save(c.Request.Context(), &booking, events.BookingConfirmed); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to update booking",
@@ -160,6 +268,10 @@ func ConfirmBooking(c *gin.Context) {
 		return
 	}
 
+	ctl.effects.Publish(c.Request.Context(), events.BookingConfirmed, booking)
+	ctl.effects.Notify(c.Request.Context(), booking, models.NotifyBookingConfirmed,
+		"Your appointment on "+booking.StartTime.Format("02/01/2006 15:04")+" is confirmed")
+
 	// return success
 	c.JSON(http.StatusOK, APIResponse{
 		Status:  "success",
@@ -168,9 +280,15 @@ func ConfirmBooking(c *gin.Context) {
 	})
 }
 
-func CancelBooking(c *gin.Context) {
+// CancelBooking handles POST /bookings/cancel
+// Applies the provider's cancellation policy: the fee depends on how late the
+// patient cancels, and paid bookings are refunded the rest through the gateway.
+// Patients can no longer cancel once the appointment has started (the provider
+// reports a no-show instead). Cancellations by the provider or an admin are always free.
+func (ctl *BookingController) CancelBooking(c *gin.Context) {
 	type CancelInput struct {
-		ID uint `json:"id" binding:"required"`
+		ID     uint   `json:"id" binding:"required"`
+		Reason string `json:"reason"`
 	}
 
 	var input CancelInput
@@ -183,8 +301,14 @@ func CancelBooking(c *gin.Context) {
 		return
 	}
 
-	var booking models.Booking
-	if err := db.DB.First(&booking, input.ID).Error; err != nil {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	booking, err := ctl.bookings.FindByID(c.Request.Context(), input.ID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Booking not found",
@@ -192,7 +316,25 @@ func CancelBooking(c *gin.Context) {
 		return
 	}
 
-	if booking.Status == models.Completed {
+	provider, err := ctl.providers.FindByID(c.Request.Context(), booking.ProviderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider not found",
+		})
+		return
+	}
+
+	byProvider := user.Role == models.RoleAdmin || user.ID == provider.UserID
+	if !byProvider && user.ID != booking.PatientID {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only cancel your own bookings",
+		})
+		return
+	}
+
+	if booking.Status == models.Completed || booking.Status == models.NoShow {
 		c.JSON(http.StatusBadRequest, APIResponse{
 			Status:  "error",
 			Message: "Completed bookings cannot be cancelled",
@@ -207,8 +349,24 @@ func CancelBooking(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	if !byProvider && !now.Before(booking.StartTime) {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "The appointment has already started and can no longer be cancelled",
+		})
+		return
+	}
+
+	fee := 0.0
+	if !byProvider {
+		fee = scripts.CancellationFee(ctl.effects.CancellationPolicy(c.Request.Context(), booking.ProviderID), patientAmount(booking), booking.StartTime, now)
+	}
+
 	booking.Status = models.Cancelled
-	if err := db.DB.Save(&booking).Error; err != nil {
+	booking.CancelledAt = &now
+	booking.CancellationFee = fee
+	if err := ctl.bookings.Save(c.Request.Context(), &booking, events.BookingCancelled); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to cancel booking",
@@ -217,14 +375,119 @@ func CancelBooking(c *gin.Context) {
 		return
 	}
 
+	reason := input.Reason
+	if reason == "" {
+		reason = "booking cancelled"
+	}
+	refund, err := ctl.effects.Refund(c.Request.Context(), &booking, patientAmount(booking)-fee, reason)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "refund failed", "booking_id", booking.ID, "error", err)
+	}
+
+	ctl.effects.Publish(c.Request.Context(), events.BookingCancelled, booking)
+	ctl.countBooking(c.Request.Context(), metrics.BookingCancelled, booking.ProviderID)
+	ctl.effects.Notify(c.Request.Context(), booking, models.NotifyBookingCancelled,
+		"Your appointment on "+booking.StartTime.Format("02/01/2006 15:04")+" has been cancelled")
+
 	c.JSON(http.StatusOK, APIResponse{
 		Status:  "success",
 		Message: "Booking cancelled successfully",
-		Data:    booking,
+		Data: gin.H{
+			"booking":          booking,
+			"cancellation_fee": fee,
+			"refund":           refund,
+		},
 	})
 }
 
-func CompleteBooking(c *gin.Context) {
+// MarkNoShow handles POST /bookings/no-show
+// Lets the provider record that the patient did not come; the no-show fee applies.
+func (ctl *BookingController) MarkNoShow(c *gin.Context) {
+	var input struct {
+		ID uint `json:"id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	booking, err := ctl.bookings.FindByID(c.Request.Context(), input.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Booking not found",
+		})
+		return
+	}
+
+	provider, err := ctl.providers.FindByID(c.Request.Context(), booking.ProviderID)
+	if err != nil ||
+		(user.ID != provider.UserID && user.Role != models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "Only the provider can report a no-show",
+		})
+		return
+	}
+
+	if booking.Status != models.Confirmed {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Only confirmed bookings can be marked as no-show",
+		})
+		return
+	}
+	if time.Now().Before(booking.StartTime) {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "The appointment has not started yet",
+		})
+		return
+	}
+
+	fee := scripts.NoShowFee(ctl.effects.CancellationPolicy(c.Request.Context(), booking.ProviderID), patientAmount(booking))
+	booking.Status = models.NoShow
+	booking.CancellationFee = fee
+	if err := ctl.bookings.Save(c.Request.Context(), &booking, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to update booking",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	refund, err := ctl.effects.Refund(c.Request.Context(), &booking, patientAmount(booking)-fee, "no-show")
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "refund failed", "booking_id", booking.ID, "error", err)
+	}
+	ctl.countBooking(c.Request.Context(), metrics.BookingNoShow, booking.ProviderID)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Booking marked as no-show",
+		Data: gin.H{
+			"booking":          booking,
+			"cancellation_fee": fee,
+			"refund":           refund,
+		},
+	})
+}
+
+// CompleteBooking handles POST /bookings/complete
+// Lets the provider (or an admin) record that a confirmed appointment took place;
+// the invoice is issued and the patient may then review it.
+func (ctl *BookingController) CompleteBooking(c *gin.Context) {
 	type CompleteInput struct {
 		ID uint `json:"id" binding:"required"`
 	}
@@ -239,8 +502,14 @@ func CompleteBooking(c *gin.Context) {
 		return
 	}
 
-	var booking models.Booking
-	if err := db.DB.First(&booking, input.ID).Error; err != nil {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	booking, err := ctl.bookings.FindByID(c.Request.Context(), input.ID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Booking not found",
@@ -248,6 +517,16 @@ func CompleteBooking(c *gin.Context) {
 		return
 	}
 
+	provider, err := ctl.providers.FindByID(c.Request.Context(), booking.ProviderID)
+	if err != nil ||
+		(user.ID != provider.UserID && user.Role != models.RoleAdmin) {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "Only the provider can complete a booking",
+		})
+		return
+	}
+
 	if booking.Status != models.Confirmed {
 		c.JSON(http.StatusBadRequest, APIResponse{
 			Status:  "error",
@@ -255,9 +534,16 @@ func CompleteBooking(c *gin.Context) {
 		})
 		return
 	}
+	if time.Now().Before(booking.StartTime) {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "The appointment has not started yet",
+		})
+		return
+	}
 
 	booking.Status = models.Completed
-	if err := db.DB.Save(&booking).Error; err != nil {
+	if err := ctl.bookings.Save(c.Request.Context(), &booking, ""); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to complete booking",
@@ -266,6 +552,8 @@ func CompleteBooking(c *gin.Context) {
 		return
 	}
 
+	ctl.effects.IssueInvoice(c.Request.Context(), booking.ID)
+
 	c.JSON(http.StatusOK, APIResponse{
 		Status:  "success",
 		Message: "Booking marked as completed successfully",
@@ -273,7 +561,7 @@ func CompleteBooking(c *gin.Context) {
 	})
 }
 
-func GetBookingByID(c *gin.Context) {
+func (ctl *BookingController) GetBookingByID(c *gin.Context) {
 	// Extract booking ID from URL params
 	idParam := c.Param("id")
 	if idParam == "" {
@@ -296,8 +584,8 @@ func GetBookingByID(c *gin.Context) {
 	}
 
 	// Find booking
-	var booking models.Booking
-	if err := db.DB.First(&booking, id).Error; err != nil {
+	booking, err := ctl.bookings.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Booking not found",
@@ -312,3 +600,14 @@ func GetBookingByID(c *gin.Context) {
 		Data:    booking,
 	})
 }
+
+// countBooking adds a booking event to the metrics, under the specialization of the provider
+func (ctl *BookingController) countBooking(ctx context.Context, event string, providerID uint) {
+	specialization := "unknown"
+	if provider, err := ctl.providers.FindByID(ctx, providerID); err == nil {
+		if found, err := ctl.providers.FindSpecialization(ctx, provider.SpecializationID); err == nil {
+			specialization = found.Name
+		}
+	}
+	metrics.Bookings.WithLabelValues(event, specialization).Inc()
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// bookingStart is Monday 2030-01-07 at 09:00 UTC, within the fixture availability
+const bookingStart = "2030-01-07T09:00:00Z"
+
+// newBookingController serves the bookings of the store, with the provider available
+// on 2030-01-07 from 09:00 to 12:00
+func newBookingController(s *testStore, effects *fakeBookingEffects) *BookingController {
+	s.t.Helper()
+	date := time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)
+	availability := models.Availability{ProviderID: s.Provider.ID, Date: &date, StartTime: "09:00", EndTime: "12:00"}
+	if err := s.repos.Availabilities.Create(context.Background(), &availability, nil); err != nil {
+		s.t.Fatalf("create availability: %v", err)
+	}
+	return NewBookingController(s.repos.Bookings, s.repos.Availabilities, s.repos.Providers, effects)
+}
+
+// bookingInput is the body of POST /bookings for the store's patient and service
+func (s *testStore) bookingInput(start string) gin.H {
+	return gin.H{
+		"patient_id":  s.Patient.ID,
+		"provider_id": s.Provider.ID,
+		"service_id":  s.Service.ID,
+		"start_time":  start,
+	}
+}
+
+func TestCreateAndCancelBooking(t *testing.T) {
+	s := newTestStore(t)
+	effects := &fakeBookingEffects{}
+	ctl := newBookingController(s, effects)
+
+	rec := serve(t, "/bookings", ctl.CreateBooking, &s.Patient, http.MethodPost, "/bookings", s.bookingInput(bookingStart))
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Data models.Booking `json:"data"`
+	}
+	decode(t, rec, &created)
+	if created.Data.Status != models.Pending || created.Data.Amount != s.Service.Price {
+		t.Fatalf("expected a pending booking of %v, got %s of %v", s.Service.Price, created.Data.Status, created.Data.Amount)
+	}
+
+	// the slot is taken now
+	rec = serve(t, "/bookings", ctl.CreateBooking, &s.Patient, http.MethodPost, "/bookings", s.bookingInput("2030-01-07T09:15:00Z"))
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = serve(t, "/bookings/cancel", ctl.CancelBooking, &s.Patient, http.MethodPost, "/bookings/cancel", gin.H{"id": created.Data.ID})
+	expectStatus(t, rec, http.StatusOK)
+
+	if want := []events.Type{events.BookingCreated, events.BookingCancelled}; !slices.Equal(effects.published, want) {
+		t.Errorf("expected events %v, got %v", want, effects.published)
+	}
+	if want := []models.NotificationEvent{models.NotifyBookingCreated, models.NotifyBookingCancelled}; !slices.Equal(effects.notified, want) {
+		t.Errorf("expected notifications %v, got %v", want, effects.notified)
+	}
+	// cancelled well ahead: everything is refunded
+	if len(effects.refunded) != 1 || effects.refunded[0] != s.Service.Price {
+		t.Errorf("expected a full refund of %v, got %v", s.Service.Price, effects.refunded)
+	}
+}
+
+func TestCancelBookingIsLimitedToItsParties(t *testing.T) {
+	s := newTestStore(t)
+	ctl := newBookingController(s, &fakeBookingEffects{})
+
+	rec := serve(t, "/bookings", ctl.CreateBooking, &s.Patient, http.MethodPost, "/bookings", s.bookingInput(bookingStart))
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Data models.Booking `json:"data"`
+	}
+	decode(t, rec, &created)
+	cancel := gin.H{"id": created.Data.ID}
+
+	rec = serve(t, "/bookings/cancel", ctl.CancelBooking, nil, http.MethodPost, "/bookings/cancel", cancel)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	other := s.createUser("Other Patient", "other@example.com", models.RolePatient, nil)
+	rec = serve(t, "/bookings/cancel", ctl.CancelBooking, &other, http.MethodPost, "/bookings/cancel", cancel)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = serve(t, "/bookings/cancel", ctl.CancelBooking, &s.ProviderUser, http.MethodPost, "/bookings/cancel", cancel)
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestCreateBookingWhenProviderIsBusy(t *testing.T) {
+	s := newTestStore(t)
+	ctl := newBookingController(s, &fakeBookingEffects{busy: true})
+
+	rec := serve(t, "/bookings", ctl.CreateBooking, &s.Patient, http.MethodPost, "/bookings", s.bookingInput(bookingStart))
+	expectStatus(t, rec, http.StatusConflict)
+}
+
+func TestCreateBookingWithInsurance(t *testing.T) {
+	cases := map[string]struct {
+		coverage error
+		status   int
+	}{
+		"refused":          {nil, http.StatusUnprocessableEntity},
+		"check has failed": {errors.New("connection reset"), http.StatusInternalServerError},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := newTestStore(t)
+			ctl := newBookingController(s, &fakeBookingEffects{coverage: tc.coverage})
+
+			input := s.bookingInput(bookingStart)
+			input["insurance_membership_id"] = 1
+			rec := serve(t, "/bookings", ctl.CreateBooking, &s.Patient, http.MethodPost, "/bookings", input)
+			expectStatus(t, rec, tc.status)
+		})
+	}
+}
+
+func TestCompleteBookingIssuesTheInvoice(t *testing.T) {
+	s := newTestStore(t)
+	effects := &fakeBookingEffects{}
+	ctl := newBookingController(s, effects)
+
+	start := time.Now().Add(-time.Hour)
+	booking := models.Booking{
+		PatientID:  s.Patient.ID,
+		ProviderID: s.Provider.ID,
+		ServiceID:  s.Service.ID,
+		StartTime:  start,
+		EndTime:    start.Add(30 * time.Minute),
+		Status:     models.Confirmed,
+		Amount:     s.Service.Price,
+	}
+	if err := s.repos.Bookings.Create(context.Background(), &booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	rec := serve(t, "/bookings/complete", ctl.CompleteBooking, &s.Patient, http.MethodPost, "/bookings/complete", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = serve(t, "/bookings/complete", ctl.CompleteBooking, &s.ProviderUser, http.MethodPost, "/bookings/complete", gin.H{"id": booking.ID})
+	expectStatus(t, rec, http.StatusOK)
+	if !slices.Equal(effects.invoiced, []uint{booking.ID}) {
+		t.Fatalf("expected the invoice of booking %d, got %v", booking.ID, effects.invoiced)
+	}
+}
+
+func TestMyBookingsTabsWithNames(t *testing.T) {
+	s := newTestStore(t)
+	ctl := newBookingController(s, &fakeBookingEffects{})
+
+	rec := serve(t, "/bookings", ctl.CreateBooking, &s.Patient, http.MethodPost, "/bookings", s.bookingInput(bookingStart))
+	expectStatus(t, rec, http.StatusCreated)
+
+	tab := func(name string) []BookingView {
+		t.Helper()
+		rec := serve(t, "/me/bookings", ctl.GetMyBookings, &s.Patient, http.MethodGet, "/me/bookings?tab="+name, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var body struct {
+			Data []BookingView `json:"data"`
+		}
+		decode(t, rec, &body)
+		return body.Data
+	}
+
+	upcoming := tab("upcoming")
+	if len(upcoming) != 1 || upcoming[0].ProviderName != s.ProviderUser.Name || upcoming[0].ServiceTitle != s.Service.Title ||
+		upcoming[0].PatientName != s.Patient.Name {
+		t.Fatalf("expected the booking with its names in upcoming, got %+v", upcoming)
+	}
+	if past := tab("past"); len(past) != 0 {
+		t.Fatalf("expected no past booking, got %+v", past)
+	}
+
+	rec = serve(t, "/bookings/cancel", ctl.CancelBooking, &s.Patient, http.MethodPost, "/bookings/cancel", gin.H{"id": upcoming[0].ID})
+	expectStatus(t, rec, http.StatusOK)
+	if past := tab("past"); len(past) != 1 || past[0].Status != models.Cancelled {
+		t.Fatalf("expected the cancelled booking in past, got %+v", past)
+	}
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/calendar"
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+// BookingEffects is what the bookings handlers rely on besides their repositories:
+// imported calendars, insurance coverage, cancellation policies, refunds, invoices,
+// and the events and notifications sent on every change. Handler tests replace it
+// to run without a database.
+type BookingEffects interface {
+	// IsBusy reports whether an imported calendar of the provider overlaps [start, end)
+	IsBusy(ctx context.Context, providerID uint, start, end time.Time) (bool, error)
+	// CheckCoverage returns the patient's membership once the provider accepts it at that time;
+	// refusals are *CoverageError, other errors are internal
+	CheckCoverage(ctx context.Context, membershipID, patientID uint, provider models.Provider, at time.Time) (models.InsuranceMembership, error)
+	// CancellationPolicy returns the provider's policy, or the default one
+	CancellationPolicy(ctx context.Context, providerID uint) models.CancellationPolicy
+	// Refund refunds up to amount of the booking's paid payment; nil when there is nothing to refund
+	Refund(ctx context.Context, booking *models.Booking, amount float64, reason string) (*models.Refund, error)
+	// IssueInvoice issues the invoice of the booking, logging failures
+	IssueInvoice(ctx context.Context, bookingID uint)
+	// Publish broadcasts a booking change to the event streams; the repository queues its webhooks
+	Publish(ctx context.Context, eventType events.Type, booking models.Booking)
+	// Notify notifies the booking's patient
+	Notify(ctx context.Context, booking models.Booking, event models.NotificationEvent, message string)
+}
+
+// NewBookingEffects returns the BookingEffects backed by the database, the payment
+// gateways, the event hub and the notification dispatcher
+func NewBookingEffects() BookingEffects {
+	return bookingEffects{}
+}
+
+type bookingEffects struct{}
+
+func (bookingEffects) IsBusy(ctx context.Context, providerID uint, start, end time.Time) (bool, error) {
+	return calendar.IsBusy(ctx, providerID, start, end)
+}
+
+func (bookingEffects) CheckCoverage(ctx context.Context, membershipID, patientID uint, provider models.Provider, at time.Time) (models.InsuranceMembership, error) {
+	return checkInsuranceCoverage(ctx, membershipID, patientID, provider, at)
+}
+
+func (bookingEffects) CancellationPolicy(ctx context.Context, providerID uint) models.CancellationPolicy {
+	return loadCancellationPolicy(providerID)
+}
+
+func (bookingEffects) Refund(ctx context.Context, booking *models.Booking, amount float64, reason string) (*models.Refund, error) {
+	return refundBooking(ctx, booking, amount, reason)
+}
+
+func (bookingEffects) IssueInvoice(ctx context.Context, bookingID uint) {
+	issueInvoiceLogged(ctx, bookingID)
+}
+
+func (bookingEffects) Publish(ctx context.Context, eventType events.Type, booking models.Booking) {
+	publishBookingEvent(eventType, booking)
+}
+
+func (bookingEffects) Notify(ctx context.Context, booking models.Booking, event models.NotificationEvent, message string) {
+	CreateBookingNotification(ctx, booking, event, message)
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+// BookingView is a booking with the names a list needs to display it
+type BookingView struct {
+	models.Booking
+	PatientName  string  `json:"patient_name"`
+	PatientPhone *string `json:"patient_phone,omitempty"`
+	ProviderName string  `json:"provider_name"`
+	ServiceTitle string  `json:"service_title"`
+}
+
+var myBookingsFields = append(append([]string{}, bookingsListSpec.Fields...), "provider_name", "service_title")
+
+// buildBookingViews adds the patient, provider and service names to the bookings
+func (ctl *BookingController) buildBookingViews(ctx context.Context, bookings []models.Booking) ([]BookingView, error) {
+	views := make([]BookingView, len(bookings))
+	if len(bookings) == 0 {
+		return views, nil
+	}
+
+	names, err := ctl.bookings.LoadNames(ctx, bookings)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, b := range bookings {
+		patient := names.Patients[b.PatientID]
+		views[i] = BookingView{
+			Booking:      b,
+			PatientName:  patient.Name,
+			PatientPhone: patient.PhoneNumber,
+			ProviderName: names.Providers[b.ProviderID],
+			ServiceTitle: names.Services[b.ServiceID],
+		}
+	}
+	return views, nil
+}
+
+// GetMyBookings handles GET /me/bookings?tab=upcoming|past
+// Upcoming: pending or confirmed bookings not started yet, soonest first.
+// Past: the others, latest first. Paginated like the other lists.
+func (ctl *BookingController) GetMyBookings(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	tab := c.DefaultQuery("tab", "upcoming")
+	spec := bookingsListSpec
+	spec.Fields = myBookingsFields
+	now := time.Now()
+
+	filter := repository.BookingFilter{PatientID: user.ID}
+	switch tab {
+	case "upcoming":
+		spec.DefaultSort = "start_time"
+		filter.UpcomingAt = &now
+	case "past":
+		spec.DefaultSort = "-start_time"
+		filter.PastAt = &now
+	default:
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "tab must be 'upcoming' or 'past'",
+		})
+		return
+	}
+
+	params, ok := parseListParams(c, spec)
+	if !ok {
+		return
+	}
+
+	bookings, page, err := ctl.bookings.List(c.Request.Context(), filter, params)
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch bookings",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	views, err := ctl.buildBookingViews(c.Request.Context(), bookings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch bookings",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	respondList(c, "Bookings fetched successfully", views, len(views), params, page)
+}
+
+// ProviderAgendaResponse is a provider's bookings over a day or a week
+type ProviderAgendaResponse struct {
+	View     string        `json:"view"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Bookings []BookingView `json:"bookings"`
+}
+
+// GetProviderBookings handles GET /providers/:id/bookings?view=day|week&date=2026-10-19
+// The week view starts on the Monday of the date. Cancelled bookings are left out
+// unless ?include_cancelled=true. Only the provider itself or an admin can see it.
+func (ctl *BookingController) GetProviderBookings(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid provider ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	provider, err := ctl.providers.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider not found",
+		})
+		return
+	}
+	if provider.UserID != user.ID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only see your own agenda",
+		})
+		return
+	}
+
+	loc := scripts.BusinessLocation()
+	day := time.Now().In(loc)
+	if v := c.Query("date"); v != "" {
+		if day, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Invalid date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+	}
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	view := c.DefaultQuery("view", "day")
+	var to time.Time
+	switch view {
+	case "day":
+		to = from.AddDate(0, 0, 1)
+	case "week":
+		from = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7)) // back to Monday
+		to = from.AddDate(0, 0, 7)
+	default:
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "view must be 'day' or 'week'",
+		})
+		return
+	}
+
+	includeCancelled := c.Query("include_cancelled") == "true"
+	bookings, err := ctl.bookings.Agenda(c.Request.Context(), provider.ID, from, to, includeCancelled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch agenda",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	views, err := ctl.buildBookingViews(c.Request.Context(), bookings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch agenda",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Agenda fetched successfully",
+		Data:    ProviderAgendaResponse{View: view, From: from, To: to, Bookings: views},
+		Length:  len(views),
+	})
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adriel-meb/appointly-backend/internal/calendar"
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarFeedResponse is the subscription URL of a user's calendar feed
+type CalendarFeedResponse struct {
+	URL string `json:"url"`
+}
+
+// newCalendarToken returns a random 32-byte hex token
+func newCalendarToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// calendarFeedURL is the absolute feed URL of a token, on the host the request came through
+func calendarFeedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/calendar/" + token + ".ics"
+}
+
+// saveCalendarToken gives the user a new feed token, invalidating the previous URL
+func saveCalendarToken(user *models.User) error {
+	token, err := newCalendarToken()
+	if err != nil {
+		return err
+	}
+	if err := db.DB.Model(user).Update("calendar_token", token).Error; err != nil {
+		return err
+	}
+	user.CalendarToken = &token
+	return nil
+}
+
+// GetMyCalendarFeed handles GET /me/calendar
+// Returns the user's secret feed URL, creating it on first call.
+func GetMyCalendarFeed(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	if user.CalendarToken == nil {
+		if err := saveCalendarToken(&user); err != nil {
+			c.JSON(http.StatusInternalServerError, APIResponse{
+				Status:  "error",
+				Message: "Failed to create calendar feed",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Calendar feed fetched successfully",
+		Data:    CalendarFeedResponse{URL: calendarFeedURL(c, *user.CalendarToken)},
+	})
+}
+
+// RotateMyCalendarFeed handles POST /me/calendar/rotate
+// Replaces the feed token: calendars subscribed to the old URL stop updating.
+func RotateMyCalendarFeed(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	if err := saveCalendarToken(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to rotate calendar feed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Calendar feed rotated successfully",
+		Data:    CalendarFeedResponse{URL: calendarFeedURL(c, *user.CalendarToken)},
+	})
+}
+
+// GetCalendarFeed handles GET /calendar/:token.ics (public, the token is the credential)
+func GetCalendarFeed(c *gin.Context) {
+	token, found := strings.CutSuffix(c.Param("token"), ".ics")
+	if !found || token == "" {
+		c.JSON(http.StatusNotFound, APIResponse{Status: "error", Message: "Calendar not found"})
+		return
+	}
+
+	var user models.User
+	if err := db.DB.Where("calendar_token = ?", token).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{Status: "error", Message: "Calendar not found"})
+		return
+	}
+
+	feed, err := calendar.UserFeed(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to build calendar",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, calendar.ContentType, feed)
+}
+
+// GetBookingCalendarFile handles GET /bookings/:id/ics
+// Downloads the booking as an .ics file; patient, provider or admin only.
+func GetBookingCalendarFile(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid booking ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var booking models.Booking
+	if err := db.DB.First(&booking, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Booking not found",
+		})
+		return
+	}
+
+	var provider models.Provider
+	db.DB.Select("id", "user_id").First(&provider, booking.ProviderID)
+	if user.ID != booking.PatientID && user.ID != provider.UserID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only download your own bookings",
+		})
+		return
+	}
+
+	file, err := calendar.BookingFile(booking, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to build calendar",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+calendar.Filename(booking.ID)+`"`)
+	c.Data(http.StatusOK, calendar.ContentType, file)
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+// loadCancellationPolicy returns the provider's policy, or the default one
+func loadCancellationPolicy(providerID uint) models.CancellationPolicy {
+	var policy models.CancellationPolicy
+	if err := db.DB.Where("provider_id = ?", providerID).First(&policy).Error; err != nil {
+		return scripts.DefaultCancellationPolicy(providerID)
+	}
+	return policy
+}
+
+// GetCancellationPolicy handles GET /providers/:id/cancellation-policy
+func GetCancellationPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid provider ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var provider models.Provider
+	if err := db.DB.First(&provider, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Cancellation policy fetched successfully",
+		Data:    loadCancellationPolicy(provider.ID),
+	})
+}
+
+// UpdateCancellationPolicy handles PUT /providers/:id/cancellation-policy
+// Only the provider itself or an admin can change it.
+func UpdateCancellationPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid provider ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	var provider models.Provider
+	if err := db.DB.First(&provider, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider not found",
+		})
+		return
+	}
+	if provider.UserID != user.ID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only change your own cancellation policy",
+		})
+		return
+	}
+
+	var input struct {
+		FullRefundHours            *int     `json:"full_refund_hours" binding:"required,gte=0"`
+		LateCancellationFeePercent *float64 `json:"late_cancellation_fee_percent" binding:"required,gte=0,lte=100"`
+		NoShowFeePercent           *float64 `json:"no_show_fee_percent" binding:"required,gte=0,lte=100"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	policy := loadCancellationPolicy(provider.ID)
+	policy.FullRefundHours = *input.FullRefundHours
+	policy.LateCancellationFeePercent = *input.LateCancellationFeePercent
+	policy.NoShowFeePercent = *input.NoShowFeePercent
+
+	if err := db.DB.Save(&policy).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to update cancellation policy",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Cancellation policy updated successfully",
+		Data:    policy,
+	})
+}
@@ -4,19 +4,30 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/adriel-meb/appointly-backend/internal/db"
 	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
 	"github.com/gin-gonic/gin"
 )
 
+// CityController serves /cities
+type CityController struct {
+	cities repository.CityRepository
+}
+
+// NewCityController returns the cities handlers
+func NewCityController(cities repository.CityRepository) *CityController {
+	return &CityController{cities: cities}
+}
+
 // ------------------ CREATE CITY ------------------
 
 type CitiesResponse struct {
+	ID         uint                 `json:"id"`
 	Name       string               `json:"name"`
 	Insurances []InsuranceResponse2 `json:"insurances"`
 }
 
-func CreateCity(c *gin.Context) {
+func (ctl *CityController) CreateCity(c *gin.Context) {
 	var input models.City
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -28,7 +39,7 @@ func CreateCity(c *gin.Context) {
 		return
 	}
 
-	if err := db.DB.Create(&input).Error; err != nil {
+	if err := ctl.cities.Create(c.Request.Context(), &input); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to create city",
@@ -46,11 +57,18 @@ func CreateCity(c *gin.Context) {
 
 // ------------------ GET ALL CITIES ------------------
 
-func GetAllCities(c *gin.Context) {
-	var cities []models.City
+func (ctl *CityController) GetAllCities(c *gin.Context) {
+	params, ok := parseListParams(c, citiesListSpec)
+	if !ok {
+		return
+	}
 
-	// preload insurances for each city
-	if err := db.DB.Preload("Insurances").Find(&cities).Error; err != nil {
+	// cities come with their insurances
+	cities, page, err := ctl.cities.List(c.Request.Context(), params)
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to fetch cities",
@@ -70,21 +88,18 @@ func GetAllCities(c *gin.Context) {
 		}
 
 		response = append(response, CitiesResponse{
+			ID:         city.ID,
 			Name:       city.Name,
 			Insurances: insurances,
 		})
 	}
 
-	c.JSON(http.StatusOK, APIResponse{
-		Status:  "success",
-		Message: "Cities fetched successfully",
-		Data:    response,
-	})
+	respondList(c, "Cities fetched successfully", response, len(response), params, page)
 }
 
 // ------------------ GET CITY BY ID ------------------
 
-func GetCityByID(c *gin.Context) {
+func (ctl *CityController) GetCityByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -96,8 +111,8 @@ func GetCityByID(c *gin.Context) {
 		return
 	}
 
-	var city models.City
-	if err := db.DB.Preload("Insurances").First(&city, id).Error; err != nil {
+	city, err := ctl.cities.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "City not found",
@@ -126,7 +141,7 @@ func GetCityByID(c *gin.Context) {
 
 // ------------------ UPDATE CITY ------------------
 
-func UpdateCity(c *gin.Context) {
+func (ctl *CityController) UpdateCity(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -138,8 +153,8 @@ func UpdateCity(c *gin.Context) {
 		return
 	}
 
-	var city models.City
-	if err := db.DB.First(&city, id).Error; err != nil {
+	city, err := ctl.cities.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "City not found",
@@ -159,7 +174,7 @@ func UpdateCity(c *gin.Context) {
 
 	city.Name = input.Name
 
-	if err := db.DB.Save(&city).Error; err != nil {
+	if err := ctl.cities.Update(c.Request.Context(), &city); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to update city",
@@ -177,7 +192,7 @@ func UpdateCity(c *gin.Context) {
 
 // ------------------ DELETE CITY ------------------
 
-func DeleteCity(c *gin.Context) {
+func (ctl *CityController) DeleteCity(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -189,8 +204,8 @@ func DeleteCity(c *gin.Context) {
 		return
 	}
 
-	var city models.City
-	if err := db.DB.First(&city, id).Error; err != nil {
+	city, err := ctl.cities.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "City not found",
@@ -198,7 +213,7 @@ func DeleteCity(c *gin.Context) {
 		return
 	}
 
-	if err := db.DB.Delete(&city).Error; err != nil {
+	if err := ctl.cities.Delete(c.Request.Context(), city.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to delete city",
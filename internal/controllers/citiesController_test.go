@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateAndGetCity(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewCityController(s.repos.Cities)
+
+	rec := serve(t, "/cities", ctl.CreateCity, nil, http.MethodPost, "/cities", gin.H{"name": "Port-Gentil"})
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Data struct {
+			ID uint `json:"ID"`
+		} `json:"data"`
+	}
+	decode(t, rec, &created)
+
+	rec = serve(t, "/cities/:id", ctl.GetCityByID, nil, http.MethodGet, fmt.Sprintf("/cities/%d", created.Data.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var body struct {
+		Data CitiesResponse `json:"data"`
+	}
+	decode(t, rec, &body)
+	if body.Data.Name != "Port-Gentil" {
+		t.Fatalf("expected Port-Gentil, got %q", body.Data.Name)
+	}
+
+	expectStatus(t, serve(t, "/cities/:id", ctl.GetCityByID, nil, http.MethodGet, "/cities/999", nil), http.StatusNotFound)
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+// The handler tests serve one controller from the in-memory repositories: they run
+// without a database, and the side effects of bookings are recorded by fakeBookingEffects.
+// The full router is exercised against SQLite by the tests of cmd/server.
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testStore is a memory store holding a patient, a provider and an admin, plus the
+// provider's specialization, city and 30-minute service
+type testStore struct {
+	t     *testing.T
+	repos repository.Repositories
+
+	Patient        models.User
+	ProviderUser   models.User
+	Admin          models.User
+	Specialization models.Specialization
+	City           models.City
+	Provider       models.Provider
+	Service        models.Service
+}
+
+// newTestStore returns a seeded memory store
+func newTestStore(t *testing.T) *testStore {
+	t.Helper()
+	memory := repository.NewMemory()
+	s := &testStore{t: t, repos: memory.Repositories()}
+
+	s.Specialization = memory.AddSpecialization(models.Specialization{Name: "Dentist"})
+	s.City = models.City{Name: "Libreville"}
+	if err := s.repos.Cities.Create(context.Background(), &s.City); err != nil {
+		t.Fatalf("create city: %v", err)
+	}
+
+	s.Patient = s.createUser("Patient", "patient@example.com", models.RolePatient, nil)
+	s.Admin = s.createUser("Admin", "admin@example.com", models.RoleAdmin, nil)
+	s.Provider = models.Provider{SpecializationID: s.Specialization.ID, CityID: s.City.ID, Bio: "Dentist in Libreville"}
+	s.ProviderUser = s.createUser("Provider", "provider@example.com", models.RoleProvider, &s.Provider)
+
+	s.Service = memory.AddService(models.Service{
+		ProviderID:      s.Provider.ID,
+		Title:           "Consultation",
+		DurationMinutes: 30,
+		Price:           15000,
+	})
+	return s
+}
+
+// createUser stores a user, with its provider profile when provider is not nil
+func (s *testStore) createUser(name, email string, role models.UserRole, provider *models.Provider) models.User {
+	s.t.Helper()
+	user := models.User{Name: name, Email: email, Role: role}
+	if err := s.repos.Users.Create(context.Background(), &user, provider); err != nil {
+		s.t.Fatalf("create user %s: %v", email, err)
+	}
+	return user
+}
+
+// serve runs one request through handler mounted on route, authenticated as user
+// when it is not nil
+func serve(t *testing.T, route string, handler gin.HandlerFunc, user *models.User,
+	method, target string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	router := gin.New()
+	router.Handle(method, route, func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", *user)
+		}
+		c.Next()
+	}, handler)
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, rec.Code, rec.Body)
+	}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode response: %v: %s", err, rec.Body)
+	}
+}
+
+// fakeBookingEffects records what the bookings handlers do besides their repositories
+type fakeBookingEffects struct {
+	busy      bool
+	coverage  error // returned by CheckCoverage instead of its refusal
+	published []events.Type
+	notified  []models.NotificationEvent
+	refunded  []float64
+	invoiced  []uint
+}
+
+func (f *fakeBookingEffects) IsBusy(context.Context, uint, time.Time, time.Time) (bool, error) {
+	return f.busy, nil
+}
+
+func (f *fakeBookingEffects) CheckCoverage(context.Context, uint, uint, models.Provider, time.Time) (models.InsuranceMembership, error) {
+	if f.coverage != nil {
+		return models.InsuranceMembership{}, f.coverage
+	}
+	return models.InsuranceMembership{}, &CoverageError{"insurance membership not found for this patient"}
+}
+
+func (f *fakeBookingEffects) CancellationPolicy(_ context.Context, providerID uint) models.CancellationPolicy {
+	return scripts.DefaultCancellationPolicy(providerID)
+}
+
+func (f *fakeBookingEffects) Refund(_ context.Context, _ *models.Booking, amount float64, _ string) (*models.Refund, error) {
+	f.refunded = append(f.refunded, amount)
+	return nil, nil
+}
+
+func (f *fakeBookingEffects) IssueInvoice(_ context.Context, bookingID uint) {
+	f.invoiced = append(f.invoiced, bookingID)
+}
+
+func (f *fakeBookingEffects) Publish(_ context.Context, eventType events.Type, _ models.Booking) {
+	f.published = append(f.published, eventType)
+}
+
+func (f *fakeBookingEffects) Notify(_ context.Context, _ models.Booking, event models.NotificationEvent, _ string) {
+	f.notified = append(f.notified, event)
+}
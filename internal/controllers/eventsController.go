@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+// SlotEventData is the public payload of slot events: it never exposes the patient
+type SlotEventData struct {
+	ProviderID uint      `json:"provider_id"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Available  bool      `json:"available"`
+}
+
+var (
+	streamsClosing   = make(chan struct{})
+	closeStreamsOnce sync.Once
+)
+
+// CloseEventStreams ends every open event stream, and those opened later.
+// Streams never finish by themselves: the server calls it when shutting down
+// so they do not hold up the drain of in-flight requests.
+func CloseEventStreams() {
+	closeStreamsOnce.Do(func() { close(streamsClosing) })
+}
+
+// StreamEvents handles GET /events
+// Streams booking and slot updates as Server-Sent Events.
+// Optional filter: ?provider_id=3 to only follow one provider.
+func StreamEvents(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	var providerID uint64
+	if p := c.Query("provider_id"); p != "" {
+		id, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Invalid provider_id"})
+			return
+		}
+		providerID = id
+	}
+
+	sub := events.Subscribe(func(e events.Event) bool {
+		if providerID != 0 && e.ProviderID != uint(providerID) {
+			return false
+		}
+		return e.VisibleTo(user)
+	}, 32)
+	defer events.Unsubscribe(sub)
+
+	// The stream outlives the server write timeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+
+	// Keep idle connections open through proxies
+	heartbeat := time.NewTicker(25 * time.Second)
+	defer heartbeat.Stop()
+
+	c.SSEvent("ready", gin.H{"user_id": user.ID})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-streamsClosing:
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(e.Type), e)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// publishBookingEvent broadcasts a booking change to its patient and provider,
+// plus the matching public slot event for everyone watching the provider.
+func publishBookingEvent(eventType events.Type, booking models.Booking) {
+	var provider models.Provider
+	db.DB.Select("id", "user_id").First(&provider, booking.ProviderID)
+
+	event := events.BookingEvent(eventType, booking)
+	event.ProviderUserID = provider.UserID
+	events.Publish(event)
+
+	if eventType == events.BookingConfirmed {
+		return // the slot was already taken when the booking was created
+	}
+	slotEvent := events.SlotBooked
+	if eventType == events.BookingCancelled {
+		slotEvent = events.SlotReleased
+	}
+	events.Publish(events.Event{
+		Type: slotEvent,
+		Data: SlotEventData{
+			ProviderID: booking.ProviderID,
+			StartTime:  booking.StartTime,
+			EndTime:    booking.EndTime,
+			Available:  slotEvent == events.SlotReleased,
+		},
+		ProviderID: booking.ProviderID,
+		Public:     true,
+	})
+}
+
+// publishAvailabilityEvent tells slot viewers that a provider's availability changed
+func publishAvailabilityEvent(availability models.Availability, action string) {
+	events.Publish(events.AvailabilityEvent(availability, action))
+}
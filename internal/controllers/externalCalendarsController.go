@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adriel-meb/appointly-backend/internal/calendar"
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadCalendarOwner resolves the :id provider and checks the caller is the provider
+// itself or an admin. It writes the error response and returns false on failure.
+func loadCalendarOwner(c *gin.Context) (models.Provider, bool) {
+	var provider models.Provider
+
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return provider, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid provider ID format",
+			Error:   err.Error(),
+		})
+		return provider, false
+	}
+
+	if err := db.DB.First(&provider, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider not found",
+		})
+		return provider, false
+	}
+	if provider.UserID != user.ID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only manage your own calendars",
+		})
+		return provider, false
+	}
+	return provider, true
+}
+
+// findExternalCalendar loads the :calendarId calendar of the provider, answering 400/404 itself
+func findExternalCalendar(c *gin.Context, provider models.Provider) (models.ExternalCalendar, bool) {
+	var cal models.ExternalCalendar
+	id, err := strconv.ParseUint(c.Param("calendarId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid calendar ID format",
+			Error:   err.Error(),
+		})
+		return cal, false
+	}
+	if err := db.DB.Where("provider_id = ?", provider.ID).First(&cal, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Calendar not found",
+		})
+		return cal, false
+	}
+	return cal, true
+}
+
+// GetExternalCalendars handles GET /providers/:id/calendars
+func GetExternalCalendars(c *gin.Context) {
+	provider, ok := loadCalendarOwner(c)
+	if !ok {
+		return
+	}
+
+	var calendars []models.ExternalCalendar
+	if err := db.DB.Where("provider_id = ?", provider.ID).Order("id").Find(&calendars).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch calendars",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Calendars fetched successfully",
+		Data:    calendars,
+		Length:  len(calendars),
+	})
+}
+
+// CreateExternalCalendar handles POST /providers/:id/calendars
+// Either JSON {"name", "url"} to subscribe to an ICS URL, or a multipart form with
+// "name" and an .ics "file". The calendar is synced at once and rejected if unreadable.
+func CreateExternalCalendar(c *gin.Context) {
+	provider, ok := loadCalendarOwner(c)
+	if !ok {
+		return
+	}
+
+	cal := models.ExternalCalendar{ProviderID: provider.ID}
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Missing .ics file",
+				Error:   err.Error(),
+			})
+			return
+		}
+		if file.Size > calendar.MaxCalendarSize {
+			c.JSON(http.StatusRequestEntityTooLarge, APIResponse{
+				Status:  "error",
+				Message: "Calendar files are limited to 5 MB",
+			})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Unreadable .ics file",
+				Error:   err.Error(),
+			})
+			return
+		}
+		content, err := io.ReadAll(io.LimitReader(f, calendar.MaxCalendarSize))
+		f.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Unreadable .ics file",
+				Error:   err.Error(),
+			})
+			return
+		}
+		cal.Name = c.PostForm("name")
+		if cal.Name == "" {
+			cal.Name = file.Filename
+		}
+		cal.Source = string(content)
+	} else {
+		var input struct {
+			Name string `json:"name" binding:"required,max=100"`
+			URL  string `json:"url" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Invalid input",
+				Error:   err.Error(),
+			})
+			return
+		}
+		url, err := calendar.NormalizeURL(input.URL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Invalid calendar URL",
+				Error:   err.Error(),
+			})
+			return
+		}
+		cal.Name, cal.URL = input.Name, url
+	}
+	if len(cal.Name) > 100 {
+		cal.Name = cal.Name[:100]
+	}
+
+	if err := db.DB.Create(&cal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to create calendar",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := calendar.Sync(c.Request.Context(), &cal); err != nil {
+		deleteExternalCalendar(cal)
+		c.JSON(http.StatusUnprocessableEntity, APIResponse{
+			Status:  "error",
+			Message: "Calendar could not be imported",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Status:  "success",
+		Message: "Calendar imported successfully",
+		Data:    cal,
+	})
+}
+
+// SyncExternalCalendar handles POST /providers/:id/calendars/:calendarId/sync
+func SyncExternalCalendar(c *gin.Context) {
+	provider, ok := loadCalendarOwner(c)
+	if !ok {
+		return
+	}
+	cal, ok := findExternalCalendar(c, provider)
+	if !ok {
+		return
+	}
+
+	if err := calendar.Sync(c.Request.Context(), &cal); err != nil {
+		c.JSON(http.StatusBadGateway, APIResponse{
+			Status:  "error",
+			Message: "Calendar sync failed, previous busy times are kept",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Calendar synced successfully",
+		Data:    cal,
+	})
+}
+
+// DeleteExternalCalendar handles DELETE /providers/:id/calendars/:calendarId
+// The calendar's busy times are removed with it.
+func DeleteExternalCalendar(c *gin.Context) {
+	provider, ok := loadCalendarOwner(c)
+	if !ok {
+		return
+	}
+	cal, ok := findExternalCalendar(c, provider)
+	if !ok {
+		return
+	}
+
+	if err := deleteExternalCalendar(cal); err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to delete calendar",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Calendar deleted successfully",
+	})
+}
+
+// deleteExternalCalendar removes a calendar and its busy times for good
+func deleteExternalCalendar(cal models.ExternalCalendar) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("external_calendar_id = ?", cal.ID).Delete(&models.ExternalBusyTime{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&cal).Error
+	})
+}
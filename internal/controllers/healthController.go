@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds the checks of one readiness probe
+const readinessTimeout = 3 * time.Second
+
+// Healthz handles GET /healthz (liveness): the process is up and serving
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{Status: "success", Message: "ok"})
+}
+
+// HealthController serves the readiness probe
+type HealthController struct {
+	checks []health.Check
+}
+
+// NewHealthController returns the readiness probe running checks
+func NewHealthController(checks []health.Check) *HealthController {
+	return &HealthController{checks: checks}
+}
+
+// Readyz handles GET /readyz (readiness): 200 when every check passes, 503 otherwise,
+// with the result of each check
+func (ctl *HealthController) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	results, ready := health.Run(ctx, ctl.checks)
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, APIResponse{Status: "error", Message: "not ready", Data: results})
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{Status: "success", Message: "ready", Data: results})
+}
@@ -4,8 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/adriel-meb/appointly-backend/internal/db"
 	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
 	"github.com/gin-gonic/gin"
 )
 
@@ -26,9 +26,19 @@ type InsuranceResponse struct {
 	Cities      []CityNameResponse `json:"cities"`
 }
 
+// InsuranceController serves /insurances
+type InsuranceController struct {
+	insurances repository.InsuranceRepository
+}
+
+// NewInsuranceController returns the insurances handlers
+func NewInsuranceController(insurances repository.InsuranceRepository) *InsuranceController {
+	return &InsuranceController{insurances: insurances}
+}
+
 // ------------------ CREATE INSURANCE ------------------
 
-func CreateInsurance(c *gin.Context) {
+func (ctl *InsuranceController) CreateInsurance(c *gin.Context) {
 	var input struct {
 		Name        string `json:"name"`
 		Description string `json:"description"`
@@ -60,20 +70,7 @@ func CreateInsurance(c *gin.Context) {
 	}
 
 	// Lier les villes si fournies
-	if len(input.CityIDs) > 0 {
-		var cities []models.City
-		if err := db.DB.Find(&cities, input.CityIDs).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, APIResponse{
-				Status:  "error",
-				Message: "Failed to fetch cities",
-				Error:   err.Error(),
-			})
-			return
-		}
-		insurance.Cities = cities
-	}
-
-	if err := db.DB.Create(&insurance).Error; err != nil {
+	if err := ctl.insurances.Create(c.Request.Context(), &insurance, input.CityIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to create insurance",
@@ -91,25 +88,23 @@ func CreateInsurance(c *gin.Context) {
 
 // ------------------ GET ALL INSURANCES ------------------
 
-func GetAllInsurances(c *gin.Context) {
-	var insurances []models.Insurance
-
-	name := c.Query("name")
-	coverage := c.Query("coverage")
-	email := c.Query("email")
-
-	query := db.DB.Preload("Cities").Model(&models.Insurance{})
-	if name != "" {
-		query = query.Where("LOWER(name) LIKE ?", "%"+name+"%")
-	}
-	if coverage != "" {
-		query = query.Where("LOWER(coverage) LIKE ?", "%"+coverage+"%")
+func (ctl *InsuranceController) GetAllInsurances(c *gin.Context) {
+	params, ok := parseListParams(c, insurancesListSpec)
+	if !ok {
+		return
 	}
-	if email != "" {
-		query = query.Where("LOWER(email) LIKE ?", "%"+email+"%")
+
+	filter := repository.InsuranceFilter{
+		Name:     c.Query("name"),
+		Coverage: c.Query("coverage"),
+		Email:    c.Query("email"),
 	}
 
-	if err := query.Find(&insurances).Error; err != nil {
+	insurances, page, err := ctl.insurances.List(c.Request.Context(), filter, params)
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to fetch insurances",
@@ -139,16 +134,12 @@ func GetAllInsurances(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, APIResponse{
-		Status:  "success",
-		Message: "Insurances fetched successfully",
-		Data:    response,
-	})
+	respondList(c, "Insurances fetched successfully", response, len(response), params, page)
 }
 
 // ------------------ GET INSURANCE BY ID ------------------
 
-func GetInsuranceByID(c *gin.Context) {
+func (ctl *InsuranceController) GetInsuranceByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -160,8 +151,8 @@ func GetInsuranceByID(c *gin.Context) {
 		return
 	}
 
-	var insurance models.Insurance
-	if err := db.DB.Preload("Cities").First(&insurance, id).Error; err != nil {
+	insurance, err := ctl.insurances.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Insurance not found",
@@ -195,7 +186,7 @@ func GetInsuranceByID(c *gin.Context) {
 
 // ------------------ UPDATE INSURANCE ------------------
 
-func UpdateInsurance(c *gin.Context) {
+func (ctl *InsuranceController) UpdateInsurance(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -207,8 +198,8 @@ func UpdateInsurance(c *gin.Context) {
 		return
 	}
 
-	var insurance models.Insurance
-	if err := db.DB.Preload("Cities").First(&insurance, id).Error; err != nil {
+	insurance, err := ctl.insurances.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Insurance not found",
@@ -244,21 +235,8 @@ func UpdateInsurance(c *gin.Context) {
 	insurance.Website = input.Website
 	insurance.LogoURL = input.LogoURL
 
-	// Mettre à jour les villes
-	if input.CityIDs != nil {
-		var cities []models.City
-		if err := db.DB.Find(&cities, input.CityIDs).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, APIResponse{
-				Status:  "error",
-				Message: "Failed to fetch cities",
-				Error:   err.Error(),
-			})
-			return
-		}
-		db.DB.Model(&insurance).Association("Cities").Replace(cities)
-	}
-
-	if err := db.DB.Save(&insurance).Error; err != nil {
+	// Mettre à jour les villes si fournies
+	if err := ctl.insurances.Update(c.Request.Context(), &insurance, input.CityIDs); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to update insurance",
@@ -276,7 +254,7 @@ func UpdateInsurance(c *gin.Context) {
 
 // ------------------ DELETE INSURANCE ------------------
 
-func DeleteInsurance(c *gin.Context) {
+func (ctl *InsuranceController) DeleteInsurance(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
@@ -288,8 +266,8 @@ func DeleteInsurance(c *gin.Context) {
 		return
 	}
 
-	var insurance models.Insurance
-	if err := db.DB.First(&insurance, id).Error; err != nil {
+	insurance, err := ctl.insurances.FindByID(c.Request.Context(), uint(id))
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Insurance not found",
@@ -297,17 +275,8 @@ func DeleteInsurance(c *gin.Context) {
 		return
 	}
 
-	// Supprimer les associations villes avant suppression
-	if err := db.DB.Model(&insurance).Association("Cities").Clear(); err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Status:  "error",
-			Message: "Failed to clear city associations",
-			Error:   err.Error(),
-		})
-		return
-	}
-
-	if err := db.DB.Delete(&insurance).Error; err != nil {
+	// Les associations villes sont supprimées avec l'assurance
+	if err := ctl.insurances.Delete(c.Request.Context(), insurance.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to delete insurance",
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateInsuranceInItsCities(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewInsuranceController(s.repos.Insurances)
+
+	rec := serve(t, "/insurances", ctl.CreateInsurance, nil, http.MethodPost, "/insurances",
+		gin.H{"name": "CNAMGS", "coverage": "80%", "city_ids": []uint{s.City.ID, 999}})
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Data struct {
+			ID uint `json:"ID"`
+		} `json:"data"`
+	}
+	decode(t, rec, &created)
+
+	rec = serve(t, "/insurances/:id", ctl.GetInsuranceByID, nil, http.MethodGet, fmt.Sprintf("/insurances/%d", created.Data.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var body struct {
+		Data InsuranceResponse `json:"data"`
+	}
+	decode(t, rec, &body)
+	// unknown city ids are ignored
+	if len(body.Data.Cities) != 1 || body.Data.Cities[0].Name != s.City.Name {
+		t.Fatalf("expected the insurance to operate in %s only, got %+v", s.City.Name, body.Data.Cities)
+	}
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// InsuranceMembershipInput represents request body
+type InsuranceMembershipInput struct {
+	InsuranceID     uint     `json:"insurance_id" binding:"required"`
+	MemberNumber    string   `json:"member_number" binding:"required,max=50"`
+	CoveragePercent *float64 `json:"coverage_percent" binding:"required,gte=0,lte=100"`
+	ValidUntil      string   `json:"valid_until"` // optional, "2006-01-02"
+}
+
+// CoverageError is why an insurance membership does not cover a booking.
+// Its message is meant for the client; other coverage errors are internal.
+type CoverageError struct {
+	Message string
+}
+
+func (e *CoverageError) Error() string {
+	return e.Message
+}
+
+// checkInsuranceCoverage returns the patient's membership once the provider is known
+// to accept its insurer in the provider's city. Refusals are *CoverageError.
+func checkInsuranceCoverage(ctx context.Context, membershipID, patientID uint, provider models.Provider, at time.Time) (models.InsuranceMembership, error) {
+	tx := db.DB.WithContext(ctx)
+
+	var membership models.InsuranceMembership
+	if err := tx.Preload("Insurance").Where("user_id = ?", patientID).First(&membership, membershipID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return membership, &CoverageError{"insurance membership not found for this patient"}
+		}
+		return membership, err
+	}
+
+	if membership.ValidUntil != nil && membership.ValidUntil.Before(at) {
+		return membership, &CoverageError{"insurance membership has expired"}
+	}
+
+	var accepted int64
+	if err := tx.Table("provider_insurances").
+		Where("provider_id = ? AND insurance_id = ?", provider.ID, membership.InsuranceID).
+		Count(&accepted).Error; err != nil {
+		return membership, err
+	}
+	if accepted == 0 {
+		return membership, &CoverageError{fmt.Sprintf("provider does not accept %s", membership.Insurance.Name)}
+	}
+
+	var covered int64
+	if err := tx.Table("insurance_cities").
+		Where("insurance_id = ? AND city_id = ?", membership.InsuranceID, provider.CityID).
+		Count(&covered).Error; err != nil {
+		return membership, err
+	}
+	if covered == 0 {
+		return membership, &CoverageError{fmt.Sprintf("%s does not cover the provider's city", membership.Insurance.Name)}
+	}
+
+	return membership, nil
+}
+
+// GetMyInsurances handles GET /me/insurances
+func GetMyInsurances(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	var memberships []models.InsuranceMembership
+	if err := db.DB.Preload("Insurance").Where("user_id = ?", user.ID).Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch insurance memberships",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Insurance memberships fetched successfully",
+		Data:    memberships,
+		Length:  len(memberships),
+	})
+}
+
+// AddMyInsurance handles POST /me/insurances
+// Adding an insurer the patient already has replaces the existing membership.
+func AddMyInsurance(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	var input InsuranceMembershipInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var validUntil *time.Time
+	if input.ValidUntil != "" {
+		t, err := time.Parse("2006-01-02", input.ValidUntil)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Invalid valid_until format. Use YYYY-MM-DD",
+			})
+			return
+		}
+		// Valid through the whole day
+		t = t.Add(24*time.Hour - time.Nanosecond)
+		validUntil = &t
+	}
+
+	var insurance models.Insurance
+	if err := db.DB.First(&insurance, input.InsuranceID).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Insurance not found",
+		})
+		return
+	}
+
+	var membership models.InsuranceMembership
+	err := db.DB.Where("user_id = ? AND insurance_id = ?", user.ID, insurance.ID).First(&membership).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to save insurance membership",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if membership.ID == 0 {
+		status = http.StatusCreated
+	}
+	membership.UserID = user.ID
+	membership.InsuranceID = insurance.ID
+	membership.MemberNumber = input.MemberNumber
+	membership.CoveragePercent = *input.CoveragePercent
+	membership.ValidUntil = validUntil
+
+	if err := db.DB.Save(&membership).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to save insurance membership",
+			Error:   err.Error(),
+		})
+		return
+	}
+	membership.Insurance = &insurance
+
+	c.JSON(status, APIResponse{
+		Status:  "success",
+		Message: "Insurance membership saved successfully",
+		Data:    membership,
+	})
+}
+
+// DeleteMyInsurance handles DELETE /me/insurances/:id
+// Existing bookings keep the amounts they were created with.
+func DeleteMyInsurance(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid membership ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Hard delete so the insurer can be added again (unique per user)
+	result := db.DB.Unscoped().Where("user_id = ?", user.ID).Delete(&models.InsuranceMembership{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to delete insurance membership",
+			Error:   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Insurance membership not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Insurance membership deleted successfully",
+	})
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/payments"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// issueInvoice returns the invoice of a booking, creating it on first call.
+// Numbers are sequential per provider: the provider row is locked while the
+// next sequence is allocated so concurrent issues cannot share a number.
+// The payment status is the booking's current one, as it changes with
+// payments and refunds after the invoice is issued.
+func issueInvoice(bookingID uint) (models.Invoice, error) {
+	var invoice models.Invoice
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+
+		var provider models.Provider
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&provider, booking.ProviderID).Error; err != nil {
+			return err
+		}
+
+		// Checked under the lock: a concurrent issue for the same booking waits here
+		// and then finds the invoice instead of failing on the unique booking_id
+		if err := tx.Where("booking_id = ?", bookingID).First(&invoice).Error; err == nil {
+			if invoice.PaymentStatus == booking.PaymentStatus {
+				return nil
+			}
+			invoice.PaymentStatus = booking.PaymentStatus
+			return tx.Model(&invoice).Update("payment_status", invoice.PaymentStatus).Error
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Preload("User").Preload("Specialization").Preload("City").First(&provider, provider.ID).Error; err != nil {
+			return err
+		}
+
+		var patient models.User
+		if err := tx.First(&patient, booking.PatientID).Error; err != nil {
+			return err
+		}
+
+		var service models.Service
+		if err := tx.First(&service, booking.ServiceID).Error; err != nil {
+			return err
+		}
+
+		var last uint
+		if err := tx.Model(&models.Invoice{}).Where("provider_id = ?", provider.ID).
+			Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+
+		invoice = models.Invoice{
+			Number:          fmt.Sprintf("INV-%d-%06d", provider.ID, last+1),
+			Sequence:        last + 1,
+			BookingID:       booking.ID,
+			ProviderID:      provider.ID,
+			PatientID:       patient.ID,
+			PatientName:     patient.Name,
+			PatientEmail:    patient.Email,
+			PatientPhone:    patient.PhoneNumber,
+			ProviderAddress: provider.Address,
+			ServiceTitle:    service.Title,
+			ServiceDate:     booking.StartTime,
+			DurationMinutes: service.DurationMinutes,
+			Amount:          booking.Amount,
+			InsuredAmount:   booking.InsuredAmount,
+			PatientAmount:   patientAmount(booking),
+			Currency:        payments.DefaultCurrency,
+			PaymentStatus:   booking.PaymentStatus,
+			IssuedAt:        time.Now(),
+		}
+		if provider.User != nil {
+			invoice.ProviderName = provider.User.Name
+		}
+		if provider.Specialization != nil {
+			invoice.ProviderSpecialization = provider.Specialization.Name
+		}
+		if provider.City != nil {
+			invoice.ProviderCity = provider.City.Name
+		}
+		if booking.InsuranceMembershipID != nil {
+			var membership models.InsuranceMembership
+			if err := tx.Unscoped().Preload("Insurance").First(&membership, *booking.InsuranceMembershipID).Error; err == nil {
+				invoice.MemberNumber = membership.MemberNumber
+				if membership.Insurance != nil {
+					invoice.InsurerName = membership.Insurance.Name
+				}
+			}
+		}
+
+		return tx.Create(&invoice).Error
+	})
+
+	return invoice, err
+}
+
+// issueInvoiceLogged issues an invoice after a payment or completion without failing the caller
+func issueInvoiceLogged(ctx context.Context, bookingID uint) {
+	if _, err := issueInvoice(bookingID); err != nil {
+		slog.ErrorContext(ctx, "failed to issue invoice", "booking_id", bookingID, "error", err)
+	}
+}
+
+// GetBookingInvoice handles GET /bookings/:id/invoice
+// Returns JSON by default, or the PDF with ?format=pdf (or "Accept: application/pdf").
+// Invoices exist once the booking is paid or completed.
+func GetBookingInvoice(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid booking ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var booking models.Booking
+	if err := db.DB.First(&booking, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Booking not found",
+		})
+		return
+	}
+
+	var provider models.Provider
+	db.DB.Select("id", "user_id").First(&provider, booking.ProviderID)
+	if user.ID != booking.PatientID && user.ID != provider.UserID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only access invoices of your own bookings",
+		})
+		return
+	}
+
+	invoiceable := booking.Status == models.Completed ||
+		booking.PaymentStatus == models.PaymentPaid ||
+		booking.PaymentStatus == models.PaymentPartiallyRefunded ||
+		booking.PaymentStatus == models.PaymentRefunded
+	if !invoiceable {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "No invoice yet: bookings are invoiced once paid or completed",
+		})
+		return
+	}
+
+	invoice, err := issueInvoice(booking.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to issue invoice",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if c.Query("format") == "pdf" || strings.Contains(c.GetHeader("Accept"), "application/pdf") {
+		pdf, err := scripts.RenderInvoicePDF(invoice)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, APIResponse{
+				Status:  "error",
+				Message: "Failed to render invoice",
+				Error:   err.Error(),
+			})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+invoice.Number+`.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Invoice fetched successfully",
+		Data:    invoice,
+	})
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/gin-gonic/gin"
+)
+
+// List endpoint specs: allowed ?sort= keys (JSON name -> column) and ?fields=
+var (
+	usersListSpec = listing.Spec{
+		Sorts:       map[string]string{"name": "name", "email": "email", "created_at": "created_at"},
+		DefaultSort: "id",
+		Fields:      []string{"ID", "CreatedAt", "name", "email", "role", "phone", "specialization_id", "bio"},
+	}
+	providersListSpec = listing.Spec{
+		Sorts:       map[string]string{"rating": "rating", "review_count": "review_count", "created_at": "created_at"},
+		DefaultSort: "id",
+		Fields: []string{"id", "bio", "rating", "price", "address", "lat", "lng", "image_url", "user_name",
+			"user_email", "user_phone", "specialization", "city", "insurances", "availabilities",
+			"distance", "relevance", "snippet", "earliest_slot"},
+	}
+	bookingsListSpec = listing.Spec{
+		Sorts:       map[string]string{"start_time": "start_time", "created_at": "created_at", "amount": "amount", "status": "status"},
+		DefaultSort: "id",
+		Fields: []string{"ID", "CreatedAt", "patient_id", "provider_id", "service_id", "start_time", "end_time",
+			"status", "notes", "payment_status", "amount", "insured_amount", "patient_amount", "cancelled_at"},
+	}
+	servicesListSpec = listing.Spec{
+		Sorts:       map[string]string{"title": "title", "price": "price", "duration_minutes": "duration_minutes", "created_at": "created_at"},
+		DefaultSort: "id",
+		Fields:      []string{"ID", "CreatedAt", "Title", "ProviderID", "Provider", "Description", "DurationMinutes", "Price", "RequiresPrepayment"},
+	}
+	citiesListSpec = listing.Spec{
+		Sorts:       map[string]string{"name": "name"},
+		DefaultSort: "id",
+		Fields:      []string{"id", "name", "insurances"},
+	}
+	insurancesListSpec = listing.Spec{
+		Sorts:       map[string]string{"name": "name", "created_at": "created_at"},
+		DefaultSort: "id",
+		Fields:      []string{"id", "name", "description", "coverage", "phone", "email", "website", "logo_url", "cities"},
+	}
+)
+
+// parseListParams parses ?limit=&sort=&cursor=&fields=, answering 400 when invalid
+func parseListParams(c *gin.Context, spec listing.Spec) (listing.Params, bool) {
+	params, err := listing.Parse(c, spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid list parameters",
+			Error:   err.Error(),
+		})
+		return params, false
+	}
+	return params, true
+}
+
+// invalidCursor answers 400 when the list query refused the cursor, as one of another
+// kind of listing, and reports whether it did
+func invalidCursor(c *gin.Context, err error) bool {
+	if !errors.Is(err, listing.ErrInvalidCursor) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, APIResponse{
+		Status:  "error",
+		Message: "Invalid list parameters",
+		Error:   err.Error(),
+	})
+	return true
+}
+
+// respondList writes one page of a list endpoint, keeping only the ?fields= asked for
+func respondList(c *gin.Context, message string, data interface{}, length int, params listing.Params, page listing.Page) {
+	projected, err := listing.Project(data, params.Fields)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to build response",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:     "success",
+		Message:    message,
+		Data:       projected,
+		Length:     length,
+		Pagination: &page,
+	})
+}
@@ -1,34 +1,25 @@
 package controllers
 
 import (
-	"github.com/adriel-meb/appointly-backend/internal/db"
+	"context"
+	"log/slog"
+
 	"github.com/adriel-meb/appointly-backend/internal/models"
-	"github.com/adriel-meb/appointly-backend/scripts"
-	"github.com/gin-gonic/gin"
-	"net/http"
+	"github.com/adriel-meb/appointly-backend/internal/notifications"
 )
 
-func CreateNotification(c *gin.Context, userID uint, message string, notificationType models.NotificationType) error {
-	notification := models.Notification{
-		UserID:           userID,
-		Message:          message,
-		NotificationType: notificationType,
-	}
-
-	// Save notification in DB
-	if err := db.DB.Create(&notification).Error; err != nil {
-		return err
+// CreateNotification notifies a user through the dispatcher, honoring their preferences.
+// Delivery failures are logged only: they must never fail the request that triggered them.
+func CreateNotification(ctx context.Context, userID uint, event models.NotificationEvent, message string) {
+	if _, err := notifications.Dispatch(ctx, userID, event, message); err != nil {
+		slog.ErrorContext(ctx, "failed to notify user", "user_id", userID, "event", event, "error", err)
 	}
+}
 
-	// Try sending notification
-	if err := scripts.SendNotifications(notificationType, message); err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Status:  "error",
-			Message: "Failed to send notification",
-			Error:   err.Error(),
-		})
-		return err
+// CreateBookingNotification is CreateNotification for an event of a booking, notifying its patient.
+// Confirmation and cancellation emails carry the booking's .ics file.
+func CreateBookingNotification(ctx context.Context, booking models.Booking, event models.NotificationEvent, message string) {
+	if _, err := notifications.DispatchForBooking(ctx, booking.PatientID, booking.ID, event, message); err != nil {
+		slog.ErrorContext(ctx, "failed to notify user", "user_id", booking.PatientID, "booking_id", booking.ID, "event", event, "error", err)
 	}
-
-	return nil
 }
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/notifications"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationPreferenceResponse struct {
+	Timezone        string                                                 `json:"timezone"`
+	QuietHoursStart *string                                                `json:"quiet_hours_start"`
+	QuietHoursEnd   *string                                                `json:"quiet_hours_end"`
+	MarketingOptOut bool                                                   `json:"marketing_opt_out"`
+	Channels        map[models.NotificationEvent][]models.NotificationType `json:"channels"` // effective channels, in fallback order
+}
+
+// buildPreferenceResponse resolves the effective channels of every event, defaults included
+func buildPreferenceResponse(pref models.NotificationPreference) NotificationPreferenceResponse {
+	channels := map[models.NotificationEvent][]models.NotificationType{}
+	for _, event := range notifications.Events {
+		list := notifications.ChannelsFor(pref, event)
+		if list == nil {
+			list = []models.NotificationType{}
+		}
+		channels[event] = list
+	}
+
+	return NotificationPreferenceResponse{
+		Timezone:        pref.Timezone,
+		QuietHoursStart: pref.QuietHoursStart,
+		QuietHoursEnd:   pref.QuietHoursEnd,
+		MarketingOptOut: pref.MarketingOptOut,
+		Channels:        channels,
+	}
+}
+
+// GetNotificationPreferences handles GET /me/notification-preferences
+func GetNotificationPreferences(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	pref, err := notifications.LoadPreference(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch notification preferences",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Notification preferences fetched successfully",
+		Data:    buildPreferenceResponse(pref),
+	})
+}
+
+// UpdateNotificationPreferences handles PUT /me/notification-preferences
+// Omitted fields are left unchanged; "channels" replaces the per-event configuration
+// for the events it lists (an empty list disables that event).
+func UpdateNotificationPreferences(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	var input struct {
+		Timezone        *string                                                `json:"timezone"`
+		QuietHoursStart *string                                                `json:"quiet_hours_start"`
+		QuietHoursEnd   *string                                                `json:"quiet_hours_end"`
+		MarketingOptOut *bool                                                  `json:"marketing_opt_out"`
+		Channels        map[models.NotificationEvent][]models.NotificationType `json:"channels"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	pref, err := notifications.LoadPreference(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch notification preferences",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Apply updates if values are not nil
+	if input.Timezone != nil {
+		if _, err := time.LoadLocation(*input.Timezone); err != nil || *input.Timezone == "" {
+			c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Invalid timezone"})
+			return
+		}
+		pref.Timezone = *input.Timezone
+	}
+	if input.QuietHoursStart != nil {
+		pref.QuietHoursStart = input.QuietHoursStart
+	}
+	if input.QuietHoursEnd != nil {
+		pref.QuietHoursEnd = input.QuietHoursEnd
+	}
+	if input.MarketingOptOut != nil {
+		pref.MarketingOptOut = *input.MarketingOptOut
+	}
+
+	// "" clears quiet hours; both bounds must be set together
+	if pref.QuietHoursStart != nil && *pref.QuietHoursStart == "" {
+		pref.QuietHoursStart = nil
+	}
+	if pref.QuietHoursEnd != nil && *pref.QuietHoursEnd == "" {
+		pref.QuietHoursEnd = nil
+	}
+	if (pref.QuietHoursStart == nil) != (pref.QuietHoursEnd == nil) {
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "quiet_hours_start and quiet_hours_end must be set together"})
+		return
+	}
+	if pref.QuietHoursStart != nil {
+		if _, _, err := scripts.QuietHoursEnd(*pref.QuietHoursStart, *pref.QuietHoursEnd, time.UTC, time.Now()); err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Invalid quiet hours (use HH:MM)", Error: err.Error()})
+			return
+		}
+	}
+
+	// Validate channels before touching the database
+	events := map[models.NotificationEvent]string{}
+	for event, channels := range input.Channels {
+		if !notifications.IsKnownEvent(event) {
+			c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Unknown notification event: " + string(event)})
+			return
+		}
+		list, err := notifications.JoinChannels(channels)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: err.Error()})
+			return
+		}
+		events[event] = list
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Events").Save(&pref).Error; err != nil {
+			return err
+		}
+
+		for event, list := range events {
+			if err := tx.Where("preference_id = ? AND event_type = ?", pref.ID, event).
+				Delete(&models.NotificationEventPreference{}).Error; err != nil {
+				return err
+			}
+			row := models.NotificationEventPreference{PreferenceID: pref.ID, EventType: event, Channels: list}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to update notification preferences",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	pref, err = notifications.LoadPreference(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch notification preferences",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Notification preferences updated successfully",
+		Data:    buildPreferenceResponse(pref),
+	})
+}
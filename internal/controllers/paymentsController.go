@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/payments"
+	"github.com/adriel-meb/appointly-backend/internal/webhooks"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PayBooking handles POST /bookings/:id/pay
+// Creates a payment intent at the configured gateway for the booking amount.
+// Calling it again while a payment is pending returns that payment.
+func PayBooking(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid booking ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var booking models.Booking
+	if err := db.DB.First(&booking, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Booking not found",
+		})
+		return
+	}
+
+	// Only the patient (or an admin) pays for a booking
+	if booking.PatientID != user.ID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{Status: "error", Message: "You can only pay your own bookings"})
+		return
+	}
+
+	switch {
+	case booking.Status != models.Pending && booking.Status != models.Confirmed:
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Only pending or confirmed bookings can be paid"})
+		return
+	case booking.PaymentStatus == models.PaymentPaid || booking.PaymentStatus == models.PaymentPartiallyRefunded:
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Booking is already paid"})
+		return
+	case patientAmount(booking) <= 0:
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Booking has nothing to pay"})
+		return
+	}
+
+	// Reuse the pending intent instead of charging twice
+	var pending models.Payment
+	if err := db.DB.Where("booking_id = ? AND status = ?", booking.ID, models.PaymentPending).
+		First(&pending).Error; err == nil {
+		c.JSON(http.StatusOK, APIResponse{
+			Status:  "success",
+			Message: "Payment already in progress",
+			Data:    pending,
+		})
+		return
+	}
+
+	gateway, err := payments.Default()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Payment gateway unavailable",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	intent, err := gateway.CreateIntent(c.Request.Context(), payments.IntentRequest{
+		BookingID:   booking.ID,
+		Amount:      patientAmount(booking),
+		Currency:    payments.DefaultCurrency,
+		Description: "Appointly booking #" + strconv.FormatUint(uint64(booking.ID), 10),
+		CustomerRef: user.Email,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, APIResponse{
+			Status:  "error",
+			Message: "Failed to create payment",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	payment := models.Payment{
+		BookingID:   booking.ID,
+		Gateway:     gateway.Name(),
+		ExternalID:  intent.ExternalID,
+		CheckoutURL: intent.CheckoutURL,
+		Amount:      patientAmount(booking),
+		Currency:    payments.DefaultCurrency,
+		Status:      models.PaymentPending,
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&booking).Update("payment_status", models.PaymentPending).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to save payment",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Status:  "success",
+		Message: "Payment created successfully",
+		Data:    payment,
+	})
+}
+
+// HandlePaymentWebhook handles POST /payments/webhook/:gateway
+// Called by the gateway (not by users): the request is authenticated by the gateway signature.
+func HandlePaymentWebhook(c *gin.Context) {
+	gateway, err := payments.Get(c.Param("gateway"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{Status: "error", Message: "Unknown payment gateway"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Failed to read body"})
+		return
+	}
+
+	result, err := gateway.ParseWebhook(c.Request.Header, body)
+	if errors.Is(err, payments.ErrInvalidSignature) {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Message: "Invalid signature"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: "Invalid webhook payload", Error: err.Error()})
+		return
+	}
+
+	payment, confirmed, err := applyPaymentResult(c.Request.Context(), gateway.Name(), result)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, APIResponse{Status: "error", Message: "Payment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to update payment",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Paid after the booking was cancelled: give the money back, minus the fee
+	if payment.Status == models.PaymentPaid {
+		var booking models.Booking
+		if err := db.DB.First(&booking, payment.BookingID).Error; err == nil && booking.Status == models.Cancelled {
+			if _, err := refundBooking(c.Request.Context(), &booking, patientAmount(booking)-booking.CancellationFee, "paid after cancellation"); err != nil {
+				slog.ErrorContext(c.Request.Context(), "refund failed", "booking_id", booking.ID, "error", err)
+			}
+		} else if err == nil {
+			issueInvoiceLogged(c.Request.Context(), booking.ID)
+		}
+	}
+
+	if confirmed != nil {
+		publishBookingEvent(events.BookingConfirmed, *confirmed)
+		CreateBookingNotification(c.Request.Context(), *confirmed, models.NotifyBookingConfirmed,
+			"Payment received: your appointment on "+confirmed.StartTime.Format("02/01/2006 15:04")+" is confirmed")
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Payment updated",
+		Data:    payment,
+	})
+}
+
+// applyPaymentResult moves a payment and its booking to their final payment state.
+// Bookings of prepaid services are confirmed once paid; the confirmed booking is
+// returned so the caller can notify about it. Repeated webhooks are no-ops.
+func applyPaymentResult(ctx context.Context, gateway string, result payments.WebhookResult) (models.Payment, *models.Booking, error) {
+	var payment models.Payment
+	var confirmed *models.Booking
+
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("gateway = ? AND external_id = ?", gateway, result.ExternalID).
+			First(&payment).Error; err != nil {
+			return err
+		}
+		if payment.Status != models.PaymentPending {
+			return nil // already processed
+		}
+
+		var booking models.Booking
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, payment.BookingID).Error; err != nil {
+			return err
+		}
+
+		if !result.Succeeded {
+			payment.Status = models.PaymentFailed
+			payment.FailureReason = result.Reason
+			if err := tx.Save(&payment).Error; err != nil {
+				return err
+			}
+			if booking.PaymentStatus != models.PaymentPaid {
+				return tx.Model(&booking).Update("payment_status", models.PaymentFailed).Error
+			}
+			return nil
+		}
+
+		now := time.Now()
+		payment.Status = models.PaymentPaid
+		payment.PaidAt = &now
+		if err := tx.Save(&payment).Error; err != nil {
+			return err
+		}
+
+		booking.PaymentStatus = models.PaymentPaid
+
+		var service models.Service
+		if err := tx.First(&service, booking.ServiceID).Error; err != nil {
+			return err
+		}
+		if service.RequiresPrepayment && booking.Status == models.Pending {
+			booking.Status = models.Confirmed
+			confirmed = &booking
+		}
+		if err := tx.Save(&booking).Error; err != nil {
+			return err
+		}
+		if confirmed == nil {
+			return nil
+		}
+		return webhooks.Enqueue(tx, events.BookingEvent(events.BookingConfirmed, booking))
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "payment webhook", "gateway", gateway, "external_id", result.ExternalID, "error", err)
+	} else if confirmed != nil {
+		webhooks.Wake()
+	}
+	return payment, confirmed, err
+}
+
+// patientAmount is the part of a booking the patient pays; bookings created
+// before insurance support only carry Amount.
+func patientAmount(booking models.Booking) float64 {
+	if booking.InsuranceMembershipID == nil && booking.PatientAmount == 0 {
+		return booking.Amount
+	}
+	return booking.PatientAmount
+}
+
+// refundBooking refunds up to amount of the booking's paid payment through its gateway
+// and updates the payment statuses. It returns nil when there is nothing to refund.
+// The amount is reserved on the locked payment row before the gateway is called, so
+// concurrent refunds never give back more than was paid; a failed refund releases it.
+func refundBooking(ctx context.Context, booking *models.Booking, amount float64, reason string) (*models.Refund, error) {
+	if amount <= 0 || (booking.PaymentStatus != models.PaymentPaid && booking.PaymentStatus != models.PaymentPartiallyRefunded) {
+		return nil, nil
+	}
+
+	var payment models.Payment
+	var refund *models.Refund
+	err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ? AND status IN ?", booking.ID,
+				[]models.PaymentStatus{models.PaymentPaid, models.PaymentPartiallyRefunded}).
+			Order("id DESC").First(&payment).Error; err != nil {
+			return err
+		}
+
+		if refundable := payment.Amount - payment.RefundedAmount; amount > refundable {
+			amount = refundable
+		}
+		if amount <= 0 {
+			return nil
+		}
+
+		refund = &models.Refund{
+			PaymentID: payment.ID,
+			Amount:    amount,
+			Reason:    reason,
+			Status:    models.RefundPending,
+		}
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+		return tx.Model(&payment).Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount)).Error
+	})
+	if err != nil || refund == nil {
+		return nil, err
+	}
+
+	gateway, err := payments.Get(payment.Gateway)
+	var result payments.RefundResult
+	if err == nil {
+		result, err = gateway.Refund(ctx, payment.ExternalID, amount)
+	}
+	if err != nil || !result.Succeeded {
+		refund.Status = models.RefundFailed
+		refund.FailureReason = result.Reason
+		if err != nil {
+			refund.FailureReason = err.Error()
+		}
+		if err := db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(refund).Error; err != nil {
+				return err
+			}
+			return tx.Model(&payment).Update("refunded_amount", gorm.Expr("refunded_amount - ?", amount)).Error
+		}); err != nil {
+			slog.ErrorContext(ctx, "failed to record failed refund", "refund_id", refund.ID, "error", err)
+		}
+		return refund, fmt.Errorf("refund %d failed: %s", refund.ID, refund.FailureReason)
+	}
+
+	err = db.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		refund.Status = models.RefundSucceeded
+		refund.ExternalID = result.ExternalID
+		if err := tx.Save(refund).Error; err != nil {
+			return err
+		}
+
+		// Refunds still in progress are reserved in refunded_amount: the status only
+		// counts the succeeded ones
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, payment.ID).Error; err != nil {
+			return err
+		}
+		var refunded float64
+		if err := tx.Model(&models.Refund{}).Where("payment_id = ? AND status = ?", payment.ID, models.RefundSucceeded).
+			Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error; err != nil {
+			return err
+		}
+		payment.Status = models.PaymentPartiallyRefunded
+		if refunded >= payment.Amount {
+			payment.Status = models.PaymentRefunded
+		}
+		if err := tx.Model(&payment).Update("status", payment.Status).Error; err != nil {
+			return err
+		}
+
+		booking.PaymentStatus = payment.Status
+		return tx.Model(booking).Update("payment_status", booking.PaymentStatus).Error
+	})
+	return refund, err
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/calendar"
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+// Availability search limits
+const (
+	defaultSlotMinutes     = 30
+	defaultSearchWindow    = 7 * 24 * time.Hour
+	maxAvailabilityWindow  = 31 * 24 * time.Hour
+	availabilityTimeLayout = "2006-01-02T15:04"
+
+	// maxAvailabilityCandidates bounds the providers whose free slots are computed
+	// for one search; narrower filters reach the others
+	maxAvailabilityCandidates = 200
+)
+
+// availabilityQuery is the ?available_from=&available_to=&service_id=&specialization_id=
+// part of the providers search. Window is nil when no availability is asked for.
+type availabilityQuery struct {
+	ServiceID        uint
+	SpecializationID uint
+	Window           *scripts.Interval
+	Duration         time.Duration
+}
+
+// FreeSlotResponse is the earliest bookable slot of a provider in the searched window
+type FreeSlotResponse struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// parseSearchTime accepts RFC3339, "2006-01-02T15:04" (business time) or a plain date;
+// a plain date means the start of the day, or its end when endOfDay is set.
+func parseSearchTime(value string, endOfDay bool) (time.Time, error) {
+	loc := scripts.BusinessLocation()
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(availabilityTimeLayout, value, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339, YYYY-MM-DDTHH:MM or YYYY-MM-DD", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseAvailabilityQuery reads the availability filters of GET /providers
+func (ctl *ProviderController) parseAvailabilityQuery(c *gin.Context) (availabilityQuery, error) {
+	q := availabilityQuery{Duration: defaultSlotMinutes * time.Minute}
+
+	for name, target := range map[string]*uint{"service_id": &q.ServiceID, "specialization_id": &q.SpecializationID} {
+		if value := c.Query(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return q, fmt.Errorf("%s must be a number", name)
+			}
+			*target = uint(id)
+		}
+	}
+
+	if q.ServiceID != 0 {
+		service, err := ctl.providers.FindService(c.Request.Context(), q.ServiceID)
+		if err != nil {
+			return q, errors.New("service not found")
+		}
+		q.Duration = time.Duration(service.DurationMinutes) * time.Minute
+	}
+
+	fromStr, toStr := c.Query("available_from"), c.Query("available_to")
+	if fromStr == "" && toStr == "" {
+		return q, nil
+	}
+
+	now := time.Now()
+	from, to := now, time.Time{}
+	var err error
+	if fromStr != "" {
+		if from, err = parseSearchTime(fromStr, false); err != nil {
+			return q, err
+		}
+	}
+	if toStr != "" {
+		if to, err = parseSearchTime(toStr, true); err != nil {
+			return q, err
+		}
+	} else {
+		to = from.Add(defaultSearchWindow)
+	}
+
+	// Past slots cannot be booked
+	if from.Before(now) {
+		from = now
+	}
+	if !to.After(from) {
+		return q, errors.New("available_to must be after available_from and in the future")
+	}
+	if to.Sub(from) > maxAvailabilityWindow {
+		return q, errors.New("the availability window cannot exceed 31 days")
+	}
+
+	q.Window = &scripts.Interval{Start: from, End: to}
+	return q, nil
+}
+
+// days returns the business-time days of the window, which dated availabilities must
+// fall in; nil when no availability is asked for
+func (q availabilityQuery) days() *repository.DayRange {
+	if q.Window == nil {
+		return nil
+	}
+	loc := scripts.BusinessLocation()
+	day := func(t time.Time) time.Time {
+		y, m, d := t.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return &repository.DayRange{From: day(q.Window.Start), To: day(q.Window.End)}
+}
+
+// earliestFreeSlots returns the earliest free slot of each provider within the window.
+// Providers without one are absent from the map.
+func earliestFreeSlots(providers []models.Provider, q availabilityQuery) (map[uint]FreeSlotResponse, error) {
+	slots := map[uint]FreeSlotResponse{}
+	if q.Window == nil || len(providers) == 0 {
+		return slots, nil
+	}
+
+	ids := make([]uint, len(providers))
+	for i, p := range providers {
+		ids[i] = p.ID
+	}
+
+	busy, err := busyIntervals(ids, *q.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := scripts.BusinessLocation()
+	for _, p := range providers {
+		start, ok := scripts.EarliestFreeSlot(p.Availabilities, busy[p.ID], q.Window.Start, q.Window.End, q.Duration, loc)
+		if ok {
+			slots[p.ID] = FreeSlotResponse{StartTime: start, EndTime: start.Add(q.Duration)}
+		}
+	}
+	return slots, nil
+}
+
+// busyIntervals returns the pending and confirmed bookings and imported busy times of the
+// providers overlapping the window
+func busyIntervals(providerIDs []uint, window scripts.Interval) (map[uint][]scripts.Interval, error) {
+	var bookings []models.Booking
+	err := db.DB.Select("provider_id", "start_time", "end_time").
+		Where("provider_id IN ?", providerIDs).
+		Where("status IN ?", []models.StatusBooking{models.Pending, models.Confirmed}).
+		Where("start_time < ? AND end_time > ?", window.End, window.Start).
+		Find(&bookings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Events imported from the providers' own calendars block slots too
+	busy, err := calendar.BusyIntervals(providerIDs, window)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bookings {
+		busy[b.ProviderID] = append(busy[b.ProviderID], scripts.Interval{Start: b.StartTime, End: b.EndTime})
+	}
+	return busy, nil
+}
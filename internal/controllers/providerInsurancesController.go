@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+// loadOwnedProviderAndInsurance resolves the :id and :insuranceId params and checks
+// the caller is the provider itself or an admin. It writes the error response and
+// returns false on failure.
+func (ctl *ProviderController) loadOwnedProviderAndInsurance(c *gin.Context) (models.Provider, models.Insurance, bool) {
+	var provider models.Provider
+	var insurance models.Insurance
+
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return provider, insurance, false
+	}
+
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid provider ID format",
+			Error:   err.Error(),
+		})
+		return provider, insurance, false
+	}
+	insuranceID, err := strconv.ParseUint(c.Param("insuranceId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid insurance ID format",
+			Error:   err.Error(),
+		})
+		return provider, insurance, false
+	}
+
+	provider, err = ctl.providers.FindByID(c.Request.Context(), uint(providerID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider not found",
+		})
+		return provider, insurance, false
+	}
+	if provider.UserID != user.ID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only manage your own insurances",
+		})
+		return provider, insurance, false
+	}
+
+	insurance, err = ctl.insurances.FindByID(c.Request.Context(), uint(insuranceID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Insurance not found",
+		})
+		return provider, insurance, false
+	}
+
+	return provider, insurance, true
+}
+
+// AddProviderInsurance handles PUT /providers/:id/insurances/:insuranceId
+// Idempotent: accepting an insurance twice keeps a single link.
+func (ctl *ProviderController) AddProviderInsurance(c *gin.Context) {
+	provider, insurance, ok := ctl.loadOwnedProviderAndInsurance(c)
+	if !ok {
+		return
+	}
+
+	if err := ctl.providers.AddInsurance(c.Request.Context(), provider.ID, insurance.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to add insurance",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Insurance added to provider successfully",
+		Data:    insurance,
+	})
+}
+
+// RemoveProviderInsurance handles DELETE /providers/:id/insurances/:insuranceId
+func (ctl *ProviderController) RemoveProviderInsurance(c *gin.Context) {
+	provider, insurance, ok := ctl.loadOwnedProviderAndInsurance(c)
+	if !ok {
+		return
+	}
+
+	removed, err := ctl.providers.RemoveInsurance(c.Request.Context(), provider.ID, insurance.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to remove insurance",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider does not accept this insurance",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Insurance removed from provider successfully",
+	})
+}
@@ -1,12 +1,17 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
 	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
 	"github.com/adriel-meb/appointly-backend/scripts"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"strings"
+	"sort"
+	"strconv"
 )
 
 type InsuranceResponse2 struct {
@@ -36,14 +41,88 @@ type ProviderResponse struct {
 	City           string                   `json:"city"`
 	Insurances     []InsuranceResponse2     `json:"insurances"`
 	Availabilities []AvailabilitiesResponse `json:"availabilities"`
+	Distance       string                   `json:"distance,omitempty"`      // only with ?lat=&lng=
+	Relevance      float64                  `json:"relevance,omitempty"`     // only with ?search=
+	Snippet        string                   `json:"snippet,omitempty"`       // matches wrapped in <mark>
+	EarliestSlot   *FreeSlotResponse        `json:"earliest_slot,omitempty"` // only with ?available_from/to
 }
 
-// CreateProvider handles POST /providers (admin only)
-func CreateProvider(c *gin.Context) {
+// ProviderController serves provider profiles and their search under /providers
+type ProviderController struct {
+	providers  repository.ProviderRepository
+	users      repository.UserRepository
+	insurances repository.InsuranceRepository
+}
+
+// NewProviderController returns the provider profile handlers
+func NewProviderController(providers repository.ProviderRepository, users repository.UserRepository,
+	insurances repository.InsuranceRepository) *ProviderController {
+	return &ProviderController{providers: providers, users: users, insurances: insurances}
+}
+
+// parseProviderID reads the :id provider, answering 404 when it is not a valid id
+func parseProviderID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Provider not found",
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondProviderSaveError answers a failed provider save: 400 for unknown links, 500 otherwise
+func respondProviderSaveError(c *gin.Context, message string, err error) {
+	var invalid *repository.LinkError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid provider links",
+			Error:   invalid.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, APIResponse{
+		Status:  "error",
+		Message: message,
+		Error:   err.Error(),
+	})
+}
+
+// authorizeProviderUser checks the caller is the provider's user or an admin. It writes
+// the error response and returns false otherwise.
+func authorizeProviderUser(c *gin.Context, providerUserID uint, message string) bool {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return false
+	}
+	if user.ID != providerUserID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: message,
+		})
+		return false
+	}
+	return true
+}
+
+// CreateProvider handles POST /providers (admin, or the user creating their own profile)
+func (ctl *ProviderController) CreateProvider(c *gin.Context) {
 	type CreateProviderInput struct {
-		SpecializationID uint   `json:"specialization_id" binding:"required"` // FK to specializations
-		Bio              string `json:"bio"`
-		UserID           uint   `json:"user_id" binding:"required"`
+		SpecializationID uint     `json:"specialization_id" binding:"required"` // FK to specializations
+		Bio              string   `json:"bio"`
+		UserID           uint     `json:"user_id" binding:"required"`
+		CityID           uint     `json:"city_id" binding:"required"`
+		Address          string   `json:"address" binding:"max=255"`
+		Lat              *float64 `json:"lat" binding:"omitempty,gte=-90,lte=90"`
+		Lng              *float64 `json:"lng" binding:"omitempty,gte=-180,lte=180"`
+		Price            string   `json:"price" binding:"max=50"`
+		ImageURL         string   `json:"image_url" binding:"omitempty,url"`
+		InsuranceIDs     []uint   `json:"insurance_ids"`
 	}
 
 	var input CreateProviderInput
@@ -56,9 +135,21 @@ func CreateProvider(c *gin.Context) {
 		return
 	}
 
+	if (input.Lat == nil) != (input.Lng == nil) {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "lat and lng must be given together",
+		})
+		return
+	}
+
+	if !authorizeProviderUser(c, input.UserID, "You can only create your own provider profile") {
+		return
+	}
+
 	// Check if user exists
-	var user models.User
-	if result := db.DB.First(&user, input.UserID); result.RowsAffected == 0 {
+	user, err := ctl.users.FindByID(c.Request.Context(), input.UserID)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, APIResponse{
 			Status:  "error",
 			Message: "User not found",
@@ -67,8 +158,7 @@ func CreateProvider(c *gin.Context) {
 	}
 
 	// Check if already a provider
-	var existingProvider models.Provider
-	if db.DB.Where("user_id = ?", input.UserID).First(&existingProvider); db.DB.RowsAffected > 0 {
+	if _, err := ctl.providers.FindByUserID(c.Request.Context(), input.UserID); err == nil {
 		c.JSON(http.StatusBadRequest, APIResponse{
 			Status:  "error",
 			Message: "User is already a provider",
@@ -86,8 +176,7 @@ func CreateProvider(c *gin.Context) {
 	}
 
 	// Optional: validate specialization exists
-	var specialization models.Specialization
-	if err := db.DB.First(&specialization, input.SpecializationID).Error; err != nil {
+	if found, err := ctl.providers.SpecializationExists(c.Request.Context(), input.SpecializationID); err != nil || !found {
 		c.JSON(http.StatusBadRequest, APIResponse{
 			Status:  "error",
 			Message: "Specialization not found",
@@ -95,19 +184,22 @@ func CreateProvider(c *gin.Context) {
 		return
 	}
 
-	// Create provider
+	// Create provider with its city and insurances, all or nothing
 	provider := models.Provider{
 		UserID:           input.UserID,
 		SpecializationID: input.SpecializationID,
 		Bio:              input.Bio,
+		CityID:           input.CityID,
+		Address:          input.Address,
+		Price:            input.Price,
+		ImageURL:         input.ImageURL,
+	}
+	if input.Lat != nil {
+		provider.Lat, provider.Lng = *input.Lat, *input.Lng
 	}
 
-	if err := db.DB.Create(&provider).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Status:  "error",
-			Message: "Failed to create provider",
-			Error:   err.Error(),
-		})
+	if err := ctl.providers.Create(c.Request.Context(), &provider, input.InsuranceIDs); err != nil {
+		respondProviderSaveError(c, "Failed to create provider", err)
 		return
 	}
 
@@ -118,49 +210,127 @@ func CreateProvider(c *gin.Context) {
 	})
 }
 
-// GetAllProviders handles GET /providers
-// Supports optional filters: ?search=dr+name&location=Libreville&insurance=CNAMGS
-func GetAllProviders(c *gin.Context) {
-	var providers []models.Provider
+// Geo search defaults, in km
+const (
+	defaultSearchRadiusKm = 25
+	maxSearchRadiusKm     = 500
+)
 
-	query := c.Query("search")
-	city := c.Query("location")
-	insurance := c.Query("insurance")
+// parseGeoQuery reads lat, lng and radius_km; it returns nil when no position is given
+func parseGeoQuery(c *gin.Context) (*repository.GeoFilter, error) {
+	latStr, lngStr := c.Query("lat"), c.Query("lng")
+	if latStr == "" && lngStr == "" {
+		return nil, nil
+	}
+	if latStr == "" || lngStr == "" {
+		return nil, fmt.Errorf("lat and lng must be given together")
+	}
+
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("lat must be a number between -90 and 90")
+	}
+	lng, err := strconv.ParseFloat(lngStr, 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return nil, fmt.Errorf("lng must be a number between -180 and 180")
+	}
 
-	tx := db.DB.Preload("User").
-		Preload("Specialization").
-		Preload("City").
-		Preload("Insurances").
-		Preload("Availabilities")
+	radius := float64(defaultSearchRadiusKm)
+	if r := c.Query("radius_km"); r != "" {
+		radius, err = strconv.ParseFloat(r, 64)
+		if err != nil || radius <= 0 || radius > maxSearchRadiusKm {
+			return nil, fmt.Errorf("radius_km must be a number between 0 and %d", maxSearchRadiusKm)
+		}
+	}
 
-	// ------------------ Filters ------------------
+	return &repository.GeoFilter{Lat: lat, Lng: lng, RadiusKm: radius}, nil
+}
 
-	// Search in bio, user name, specialization
-	if query != "" {
-		query = strings.ToLower(query)
-		tx = tx.Joins("JOIN users u ON u.id = providers.user_id").
-			Joins("JOIN specializations s ON s.id = providers.specialization_id").
-			Where("LOWER(providers.bio) LIKE ? OR LOWER(u.name) LIKE ? OR LOWER(s.name) LIKE ?",
-				"%"+query+"%", "%"+query+"%", "%"+query+"%")
+// GetAllProviders handles GET /providers
+// Supports optional filters: ?search=dr+name&location=Libreville&insurance=CNAMGS
+// and ?lat=0.39&lng=9.45&radius_km=10 to keep providers nearby, nearest first.
+// ?available_from=&available_to= (with optional service_id/specialization_id) keeps
+// providers with a free slot in the window and inlines the earliest one.
+func (ctl *ProviderController) GetAllProviders(c *gin.Context) {
+	geo, err := parseGeoQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid location search",
+			Error:   err.Error(),
+		})
+		return
 	}
 
-	// Filter by city name
-	if city != "" {
-		tx = tx.Joins("JOIN cities c ON c.id = providers.city_id").
-			Where("LOWER(c.name) LIKE ?", "%"+city+"%")
+	params, ok := parseListParams(c, providersListSpec)
+	if !ok {
+		return
+	}
+
+	availability, err := ctl.parseAvailabilityQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid availability search",
+			Error:   err.Error(),
+		})
+		return
 	}
 
-	// Filter by insurance name
-	if insurance != "" {
-		tx = tx.Joins("JOIN provider_insurances pi ON pi.provider_id = providers.id").
-			Joins("JOIN insurances i ON i.id = pi.insurance_id").
-			Where("LOWER(i.name) LIKE ?", "%"+insurance+"%").
-			Distinct("providers.id")
+	filter := repository.ProviderFilter{
+		Search:           c.Query("search"),
+		City:             c.Query("location"),
+		Insurance:        c.Query("insurance"),
+		ServiceID:        availability.ServiceID,
+		SpecializationID: availability.SpecializationID,
+		Near:             geo,
+		AvailableDays:    availability.days(),
 	}
 
-	// ------------------ Execute Query ------------------
+	// Searches are ranked (distance, relevance, earliest slot) and paged by offset;
+	// plain listings are paged by keyset on the ?sort= column.
+	if (filter.Ranked() || availability.Window != nil) && listing.Sorted(c) {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid list parameters",
+			Error:   "sort cannot be combined with search, lat/lng or available_from/to",
+		})
+		return
+	}
 
-	if err := tx.Find(&providers).Error; err != nil {
+	ctx := c.Request.Context()
+	var providers []models.Provider
+	var page listing.Page
+	freeSlots := map[uint]FreeSlotResponse{}
+	if availability.Window != nil {
+		// Free slots are computed in Go: load the candidates, then page in memory
+		if providers, err = ctl.providers.ListMatching(ctx, filter, maxAvailabilityCandidates); err == nil {
+			freeSlots, err = earliestFreeSlots(providers, availability)
+		}
+		if err == nil {
+			available := providers[:0]
+			for _, p := range providers {
+				if _, ok := freeSlots[p.ID]; ok {
+					available = append(available, p)
+				}
+			}
+			providers = available
+
+			// Soonest first, unless ranked by distance or relevance
+			if !filter.Ranked() {
+				sort.SliceStable(providers, func(i, j int) bool {
+					return freeSlots[providers[i].ID].StartTime.Before(freeSlots[providers[j].ID].StartTime)
+				})
+			}
+			providers, page, err = listing.Slice(providers, params)
+		}
+	} else {
+		providers, page, err = ctl.providers.List(ctx, filter, params)
+	}
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to fetch providers",
@@ -169,6 +339,23 @@ func GetAllProviders(c *gin.Context) {
 		return
 	}
 
+	// Relevance and highlighted excerpts of the matches
+	hits := map[uint]db.SearchHit{}
+	if filter.Search != "" {
+		ids := make([]uint, len(providers))
+		for i, p := range providers {
+			ids[i] = p.ID
+		}
+		if hits, err = ctl.providers.SearchHits(ctx, filter.Search, ids); err != nil {
+			c.JSON(http.StatusInternalServerError, APIResponse{
+				Status:  "error",
+				Message: "Failed to fetch providers",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
 	// ------------------ Map to Custom Response ------------------
 
 	var response []ProviderResponse
@@ -194,6 +381,16 @@ func GetAllProviders(c *gin.Context) {
 			})
 		}
 
+		var earliest *FreeSlotResponse
+		if slot, ok := freeSlots[p.ID]; ok {
+			earliest = &slot
+		}
+
+		distance := ""
+		if geo != nil {
+			distance = scripts.FormatDistance(scripts.DistanceKm(geo.Lat, geo.Lng, p.Lat, p.Lng))
+		}
+
 		// Append provider
 		response = append(response, ProviderResponse{
 			ID:             p.ID,
@@ -211,31 +408,27 @@ func GetAllProviders(c *gin.Context) {
 			UserEmail:      p.User.Email,
 			UserPhone:      p.User.PhoneNumber,
 			Availabilities: availabilities,
+			Distance:       distance,
+			Relevance:      hits[p.ID].Rank,
+			Snippet:        hits[p.ID].Snippet,
+			EarliestSlot:   earliest,
 		})
 	}
 
 	// ------------------ Response ------------------
 
-	c.JSON(http.StatusOK, APIResponse{
-		Status:  "success",
-		Length:  len(response),
-		Message: "Providers fetched successfully",
-		Data:    response,
-	})
+	respondList(c, "Providers fetched successfully", response, len(response), params, page)
 }
 
-// GetProviderByID placeholder
 // GetProviderByID handles GET /providers/:id
-func GetProviderByID(c *gin.Context) {
-	id := c.Param("id")
-	var provider models.Provider
-
-	if err := db.DB.Preload("User").
-		Preload("Specialization").
-		Preload("City").
-		Preload("Insurances").
-		Preload("Availabilities").
-		First(&provider, id).Error; err != nil {
+func (ctl *ProviderController) GetProviderByID(c *gin.Context) {
+	id, ok := parseProviderID(c)
+	if !ok {
+		return
+	}
+
+	provider, err := ctl.providers.FindProfile(c.Request.Context(), id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Provider not found",
@@ -290,12 +483,15 @@ func GetProviderByID(c *gin.Context) {
 	})
 }
 
-// UpdateProvider handles PUT /providers/:id
-func UpdateProvider(c *gin.Context) {
-	id := c.Param("id")
-	var provider models.Provider
+// UpdateProvider handles PUT /providers/:id (the provider itself or an admin)
+func (ctl *ProviderController) UpdateProvider(c *gin.Context) {
+	id, ok := parseProviderID(c)
+	if !ok {
+		return
+	}
 
-	if err := db.DB.First(&provider, id).Error; err != nil {
+	provider, err := ctl.providers.FindByID(c.Request.Context(), id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Provider not found",
@@ -303,16 +499,20 @@ func UpdateProvider(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeProviderUser(c, provider.UserID, "You can only update your own provider profile") {
+		return
+	}
 
 	type UpdateProviderInput struct {
 		SpecializationID *uint    `json:"specialization_id"`
 		Bio              *string  `json:"bio"`
 		CityID           *uint    `json:"city_id"`
-		ImageURL         *string  `json:"image_url"`
-		Price            *string  `json:"price"`
-		Address          *string  `json:"address"`
-		Lat              *float64 `json:"lat"`
-		Lng              *float64 `json:"lng"`
+		ImageURL         *string  `json:"image_url" binding:"omitempty,url"`
+		Price            *string  `json:"price" binding:"omitempty,max=50"`
+		Address          *string  `json:"address" binding:"omitempty,max=255"`
+		Lat              *float64 `json:"lat" binding:"omitempty,gte=-90,lte=90"`
+		Lng              *float64 `json:"lng" binding:"omitempty,gte=-180,lte=180"`
+		InsuranceIDs     *[]uint  `json:"insurance_ids"` // replaces the accepted insurances
 	}
 
 	var input UpdateProviderInput
@@ -351,12 +551,23 @@ func UpdateProvider(c *gin.Context) {
 		provider.Lng = *input.Lng
 	}
 
-	if err := db.DB.Save(&provider).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, APIResponse{
-			Status:  "error",
-			Message: "Failed to update provider",
-			Error:   err.Error(),
-		})
+	if input.SpecializationID != nil {
+		if found, err := ctl.providers.SpecializationExists(c.Request.Context(), *input.SpecializationID); err != nil || !found {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Specialization not found",
+			})
+			return
+		}
+	}
+
+	// insurance_ids replaces the accepted insurances; without it they are kept
+	var insuranceIDs []uint
+	if input.InsuranceIDs != nil {
+		insuranceIDs = *input.InsuranceIDs
+	}
+	if err := ctl.providers.Update(c.Request.Context(), &provider, insuranceIDs); err != nil {
+		respondProviderSaveError(c, "Failed to update provider", err)
 		return
 	}
 
@@ -367,12 +578,15 @@ func UpdateProvider(c *gin.Context) {
 	})
 }
 
-// DeleteProvider handles DELETE /providers/:id
-func DeleteProvider(c *gin.Context) {
-	id := c.Param("id")
-	var provider models.Provider
+// DeleteProvider handles DELETE /providers/:id (the provider itself or an admin)
+func (ctl *ProviderController) DeleteProvider(c *gin.Context) {
+	id, ok := parseProviderID(c)
+	if !ok {
+		return
+	}
 
-	if err := db.DB.First(&provider, id).Error; err != nil {
+	provider, err := ctl.providers.FindByID(c.Request.Context(), id)
+	if err != nil {
 		c.JSON(http.StatusNotFound, APIResponse{
 			Status:  "error",
 			Message: "Provider not found",
@@ -380,8 +594,11 @@ func DeleteProvider(c *gin.Context) {
 		})
 		return
 	}
+	if !authorizeProviderUser(c, provider.UserID, "You can only delete your own provider profile") {
+		return
+	}
 
-	if err := db.DB.Delete(&provider).Error; err != nil {
+	if err := ctl.providers.Delete(c.Request.Context(), provider.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to delete provider",
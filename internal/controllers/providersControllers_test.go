@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateProviderThenGetProfile(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewProviderController(s.repos.Providers, s.repos.Users, s.repos.Insurances)
+
+	user := s.createUser("New Provider", "new-provider@example.com", models.RoleProvider, nil)
+	input := gin.H{"user_id": user.ID, "specialization_id": s.Specialization.ID, "city_id": s.City.ID, "bio": "Pediatrician"}
+	// another user cannot create the profile for them
+	expectStatus(t, serve(t, "/providers", ctl.CreateProvider, &s.Patient, http.MethodPost, "/providers", input), http.StatusForbidden)
+
+	rec := serve(t, "/providers", ctl.CreateProvider, &user, http.MethodPost, "/providers", input)
+	expectStatus(t, rec, http.StatusCreated)
+	var created struct {
+		Data models.Provider `json:"data"`
+	}
+	decode(t, rec, &created)
+
+	// a user has one provider profile at most
+	expectStatus(t, serve(t, "/providers", ctl.CreateProvider, &s.Admin, http.MethodPost, "/providers", input), http.StatusBadRequest)
+
+	rec = serve(t, "/providers/:id", ctl.GetProviderByID, nil, http.MethodGet, fmt.Sprintf("/providers/%d", created.Data.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var body struct {
+		Data ProviderResponse `json:"data"`
+	}
+	decode(t, rec, &body)
+	if body.Data.UserName != user.Name || body.Data.Specialization != s.Specialization.Name || body.Data.City != s.City.Name {
+		t.Fatalf("expected the profile with its user, specialization and city, got %+v", body.Data)
+	}
+}
+
+func TestUpdateProviderOnlyByItselfOrAdmin(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewProviderController(s.repos.Providers, s.repos.Users, s.repos.Insurances)
+	target := fmt.Sprintf("/providers/%d", s.Provider.ID)
+
+	other := models.Provider{SpecializationID: s.Specialization.ID, CityID: s.City.ID}
+	otherUser := s.createUser("Other", "other@example.com", models.RoleProvider, &other)
+
+	// insurance_ids must not bypass the owner check of the insurance routes
+	input := gin.H{"bio": "Taken over", "insurance_ids": []uint{}}
+	expectStatus(t, serve(t, "/providers/:id", ctl.UpdateProvider, nil, http.MethodPut, target, input), http.StatusUnauthorized)
+	expectStatus(t, serve(t, "/providers/:id", ctl.UpdateProvider, &otherUser, http.MethodPut, target, input), http.StatusForbidden)
+	expectStatus(t, serve(t, "/providers/:id", ctl.DeleteProvider, &s.Patient, http.MethodDelete, target, nil), http.StatusForbidden)
+
+	expectStatus(t, serve(t, "/providers/:id", ctl.UpdateProvider, &s.ProviderUser, http.MethodPut, target, gin.H{"bio": "Dentist"}), http.StatusOK)
+	expectStatus(t, serve(t, "/providers/:id", ctl.UpdateProvider, &s.Admin, http.MethodPut, target, gin.H{"bio": "Checked"}), http.StatusOK)
+}
+
+func TestListProvidersByInsuranceAndDistance(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewProviderController(s.repos.Providers, s.repos.Users, s.repos.Insurances)
+
+	insurance := models.Insurance{Name: "CNAMGS"}
+	if err := s.repos.Insurances.Create(context.Background(), &insurance, nil); err != nil {
+		t.Fatalf("create insurance: %v", err)
+	}
+	s.Provider.Lat, s.Provider.Lng = 0.3901, 9.4544 // Libreville
+	if err := s.repos.Providers.Update(context.Background(), &s.Provider, nil); err != nil {
+		t.Fatalf("locate provider: %v", err)
+	}
+	far := models.Provider{SpecializationID: s.Specialization.ID, CityID: s.City.ID, Lat: 4.0511, Lng: 9.7679} // Douala
+	s.createUser("Far", "far@example.com", models.RoleProvider, &far)
+
+	target := fmt.Sprintf("/providers/%d/insurances/%d", s.Provider.ID, insurance.ID)
+	route := "/providers/:id/insurances/:insuranceId"
+	expectStatus(t, serve(t, route, ctl.AddProviderInsurance, &s.Patient, http.MethodPut, target, nil), http.StatusForbidden)
+	expectStatus(t, serve(t, route, ctl.AddProviderInsurance, &s.ProviderUser, http.MethodPut, target, nil), http.StatusOK)
+
+	list := func(query string) []ProviderResponse {
+		t.Helper()
+		rec := serve(t, "/providers", ctl.GetAllProviders, nil, http.MethodGet, "/providers?"+query, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var body struct {
+			Data []ProviderResponse `json:"data"`
+		}
+		decode(t, rec, &body)
+		return body.Data
+	}
+
+	if got := list("insurance=cnam"); len(got) != 1 || got[0].ID != s.Provider.ID {
+		t.Fatalf("expected the provider accepting CNAMGS only, got %+v", got)
+	}
+	if got := list("lat=0.4&lng=9.45&radius_km=10"); len(got) != 1 || got[0].ID != s.Provider.ID || got[0].Distance == "" {
+		t.Fatalf("expected the provider nearby with its distance, got %+v", got)
+	}
+	if got := list("lat=0.4&lng=9.45&radius_km=500"); len(got) != 2 || got[0].ID != s.Provider.ID || got[1].ID != far.ID {
+		t.Fatalf("expected both providers, nearest first, got %+v", got)
+	}
+
+	expectStatus(t, serve(t, route, ctl.RemoveProviderInsurance, &s.ProviderUser, http.MethodDelete, target, nil), http.StatusOK)
+	expectStatus(t, serve(t, route, ctl.RemoveProviderInsurance, &s.ProviderUser, http.MethodDelete, target, nil), http.StatusNotFound)
+	if got := list("insurance=cnam"); len(got) != 0 {
+		t.Fatalf("expected no provider accepting CNAMGS any more, got %+v", got)
+	}
+}
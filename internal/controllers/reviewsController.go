@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var reviewsListSpec = listing.Spec{
+	Sorts:       map[string]string{"created_at": "created_at", "rating": "rating"},
+	DefaultSort: "-created_at",
+	Fields: []string{"ID", "CreatedAt", "booking_id", "provider_id", "rating", "comment", "reply",
+		"replied_at", "hidden", "hidden_reason", "patient_name"},
+}
+
+// ReviewView is a review with its author's name
+type ReviewView struct {
+	models.Review
+	PatientName string `json:"patient_name"`
+}
+
+// refreshProviderRating recomputes the provider's Rating and ReviewCount from its
+// visible reviews. The provider row is locked so concurrent reviews serialize.
+func refreshProviderRating(tx *gorm.DB, providerID uint) error {
+	var provider models.Provider
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&provider, providerID).Error; err != nil {
+		return err
+	}
+
+	var aggregate struct {
+		Average float64
+		Count   int
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("provider_id = ? AND hidden = ?", providerID, false).
+		Scan(&aggregate).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&provider).Updates(map[string]interface{}{
+		"rating":       math.Round(aggregate.Average*10) / 10,
+		"review_count": aggregate.Count,
+	}).Error
+}
+
+// findReview loads the review of the :id param, answering 400/404 itself
+func findReview(c *gin.Context) (models.Review, bool) {
+	var review models.Review
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid review ID format",
+			Error:   err.Error(),
+		})
+		return review, false
+	}
+	if err := db.DB.First(&review, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Review not found",
+		})
+		return review, false
+	}
+	return review, true
+}
+
+// CreateReview handles POST /bookings/:id/review
+// Only the patient of a completed booking can review it, once.
+func CreateReview(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid booking ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var input struct {
+		Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+		Comment string `json:"comment" binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var booking models.Booking
+	if err := db.DB.First(&booking, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Booking not found",
+		})
+		return
+	}
+	if booking.PatientID != user.ID {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only review your own bookings",
+		})
+		return
+	}
+	if booking.Status != models.Completed {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Only completed bookings can be reviewed",
+		})
+		return
+	}
+
+	review := models.Review{
+		BookingID:  booking.ID,
+		ProviderID: booking.ProviderID,
+		PatientID:  user.ID,
+		Rating:     input.Rating,
+		Comment:    input.Comment,
+	}
+
+	errAlreadyReviewed := errors.New("booking already reviewed")
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Unscoped().Model(&models.Review{}).Where("booking_id = ?", booking.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errAlreadyReviewed
+		}
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return refreshProviderRating(tx, booking.ProviderID)
+	})
+	if errors.Is(err, errAlreadyReviewed) {
+		c.JSON(http.StatusConflict, APIResponse{
+			Status:  "error",
+			Message: "This booking has already been reviewed",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to create review",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Status:  "success",
+		Message: "Review created successfully",
+		Data:    review,
+	})
+}
+
+// GetProviderReviews handles GET /providers/:id/reviews (visible reviews only)
+func GetProviderReviews(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid provider ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	listReviews(c, db.DB.Where("provider_id = ? AND hidden = ?", id, false))
+}
+
+// GetAllReviews handles GET /reviews (admin only), for moderation
+// Filters: ?provider_id=&hidden=true|false
+func GetAllReviews(c *gin.Context) {
+	tx := db.DB.Model(&models.Review{})
+	if v := c.Query("provider_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, APIResponse{
+				Status:  "error",
+				Message: "Invalid provider_id",
+			})
+			return
+		}
+		tx = tx.Where("provider_id = ?", id)
+	}
+	if v := c.Query("hidden"); v != "" {
+		tx = tx.Where("hidden = ?", v == "true")
+	}
+
+	listReviews(c, tx)
+}
+
+// listReviews writes a page of the reviews matched by tx, with their authors' names
+func listReviews(c *gin.Context, tx *gorm.DB) {
+	params, ok := parseListParams(c, reviewsListSpec)
+	if !ok {
+		return
+	}
+
+	reviews, page, err := listing.Find[models.Review](tx, params)
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch reviews",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	patientIDs := map[uint]bool{}
+	for _, r := range reviews {
+		patientIDs[r.PatientID] = true
+	}
+	var patients []models.User
+	if len(patientIDs) > 0 {
+		if err := db.DB.Select("id", "name").Where("id IN ?", keys(patientIDs)).Find(&patients).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, APIResponse{
+				Status:  "error",
+				Message: "Failed to fetch reviews",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+	names := map[uint]string{}
+	for _, p := range patients {
+		names[p.ID] = p.Name
+	}
+
+	views := make([]ReviewView, len(reviews))
+	for i, r := range reviews {
+		views[i] = ReviewView{Review: r, PatientName: names[r.PatientID]}
+	}
+
+	respondList(c, "Reviews fetched successfully", views, len(views), params, page)
+}
+
+// ReplyToReview handles PUT /reviews/:id/reply
+// The reviewed provider (or an admin) answers publicly; replying again replaces the answer.
+func ReplyToReview(c *gin.Context) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return
+	}
+
+	review, ok := findReview(c)
+	if !ok {
+		return
+	}
+
+	var provider models.Provider
+	db.DB.Select("id", "user_id").First(&provider, review.ProviderID)
+	if provider.UserID != user.ID && user.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "Only the reviewed provider can reply",
+		})
+		return
+	}
+
+	var input struct {
+		Reply string `json:"reply" binding:"required,max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	review.Reply = input.Reply
+	review.RepliedAt = &now
+	if err := db.DB.Model(&review).Updates(map[string]interface{}{"reply": review.Reply, "replied_at": now}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to save reply",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Reply saved successfully",
+		Data:    review,
+	})
+}
+
+// ModerateReview handles PUT /reviews/:id/moderation (admin only)
+// Hiding or showing a review updates the provider's rating in the same transaction.
+func ModerateReview(c *gin.Context) {
+	review, ok := findReview(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Hidden *bool  `json:"hidden" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	review.Hidden = *input.Hidden
+	review.HiddenReason = ""
+	if review.Hidden {
+		review.HiddenReason = input.Reason
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&review).Updates(map[string]interface{}{
+			"hidden":        review.Hidden,
+			"hidden_reason": review.HiddenReason,
+		}).Error; err != nil {
+			return err
+		}
+		return refreshProviderRating(tx, review.ProviderID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to moderate review",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Review moderated successfully",
+		Data:    review,
+	})
+}
+
+func keys(set map[uint]bool) []uint {
+	ids := make([]uint, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	return ids
+}
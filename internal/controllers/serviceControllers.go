@@ -1,8 +1,8 @@
 package controllers
 
 import (
-	"fmt"
 	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
 	"github.com/adriel-meb/appointly-backend/internal/models"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -17,6 +17,8 @@ func CreateService(c *gin.Context) {
 		DurationMinutes int     `json:"duration_minutes" binding:"required,gt=0"`
 		Price           float64 `json:"price" binding:"required,gt=0"`
 		ProviderID      uint    `json:"provider_id" binding:"required"`
+
+		RequiresPrepayment bool `json:"requires_prepayment"`
 	}
 
 	var input CreateServiceInput
@@ -56,6 +58,8 @@ func CreateService(c *gin.Context) {
 		DurationMinutes: uint(input.DurationMinutes),
 		Price:           float64(input.Price),
 		ProviderID:      input.ProviderID,
+
+		RequiresPrepayment: input.RequiresPrepayment,
 	}
 
 	if err := db.DB.Create(&service).Error; err != nil {
@@ -66,6 +70,7 @@ func CreateService(c *gin.Context) {
 		})
 		return
 	}
+	db.RefreshProviderSearchLogged(c.Request.Context(), service.ProviderID)
 
 	// 5. Return success
 	c.JSON(http.StatusCreated, APIResponse{
@@ -76,9 +81,16 @@ func CreateService(c *gin.Context) {
 }
 
 func GetAllServices(c *gin.Context) {
-	var services []models.Service
+	params, ok := parseListParams(c, servicesListSpec)
+	if !ok {
+		return
+	}
 
-	if err := db.DB.Preload("Provider").Find(&services).Error; err != nil {
+	services, page, err := listing.Find[models.Service](db.DB.Preload("Provider"), params)
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status:  "error",
 			Message: "Failed to fetch services",
@@ -87,11 +99,7 @@ func GetAllServices(c *gin.Context) {
 		return
 	}
 	// Success response
-	c.JSON(http.StatusOK, APIResponse{
-		Status:  "success",
-		Message: "Services fetched successfully",
-		Data:    services,
-	})
+	respondList(c, "Services fetched successfully", services, len(services), params, page)
 }
 
 // DeleteProvider placeholder
@@ -115,7 +123,6 @@ func GetServiceByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
-		fmt.Println("error in parsing number", err)
 		c.JSON(http.StatusBadRequest, APIResponse{
 			Status:  "error",
 			Message: "Invalid id",
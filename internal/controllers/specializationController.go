@@ -124,6 +124,7 @@ func UpdateSpecialization(c *gin.Context) {
 		})
 		return
 	}
+	db.RefreshProviderSearchLogged(c.Request.Context())
 
 	c.JSON(http.StatusOK, APIResponse{
 		Status:  "success",
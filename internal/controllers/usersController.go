@@ -1,11 +1,12 @@
 package controllers
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 
-	"github.com/adriel-meb/appointly-backend/internal/db"
-	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/repository"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,6 +18,18 @@ type APIResponse struct {
 	Error   string      `json:"error,omitempty"`   // error details (hidden from clients in real prod)
 	Version string      `json:"version,omitempty"` // optional API version
 	Length  int         `json:"length,omitempty"`  // length of data
+
+	Pagination *listing.Page `json:"pagination,omitempty"` // list endpoints: total and next cursor
+}
+
+// UserController serves /users
+type UserController struct {
+	users repository.UserRepository
+}
+
+// NewUserController returns the users handlers
+func NewUserController(users repository.UserRepository) *UserController {
+	return &UserController{users: users}
 }
 
 // ---------------------- ROUTES ---------------------- //
@@ -39,13 +52,19 @@ func GetWelcome(c *gin.Context) {
 }
 
 // GetAllUsers -> GET /users
-// Fetch all users from the database
-func GetAllUsers(c *gin.Context) {
-	var users []models.User
+// Fetch a page of users (?limit=&sort=&cursor=&fields=)
+func (ctl *UserController) GetAllUsers(c *gin.Context) {
+	params, ok := parseListParams(c, usersListSpec)
+	if !ok {
+		return
+	}
 
-	// Try to fetch all users
-	if err := db.DB.Find(&users).Error; err != nil {
-		log.Printf("DB error fetching users: %v", err) // log only on server side
+	users, page, err := ctl.users.List(c.Request.Context(), params)
+	if invalidCursor(c, err) {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to fetch users", "error", err) // log only on server side
 		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status: "error",
 			Error:  "Failed to fetch users", // generic error to client
@@ -53,16 +72,13 @@ func GetAllUsers(c *gin.Context) {
 		return
 	}
 
-	// Return the list of users
-	c.JSON(http.StatusOK, APIResponse{
-		Status: "success",
-		Data:   users,
-	})
+	// Return the page of users
+	respondList(c, "Users fetched successfully", users, len(users), params, page)
 }
 
 // DeleteUser -> DELETE /users/:email
 // Delete a user by email
-func DeleteUser(c *gin.Context) {
+func (ctl *UserController) DeleteUser(c *gin.Context) {
 	email := c.Param("email")
 
 	// Validate param
@@ -75,22 +91,22 @@ func DeleteUser(c *gin.Context) {
 	}
 
 	// Try to delete user
-	result := db.DB.Where("email = ?", email).Delete(&models.User{})
+	err := ctl.users.DeleteByEmail(c.Request.Context(), email)
 
-	if result.Error != nil {
-		log.Printf("DB error deleting user %s: %v", email, result.Error)
-		c.JSON(http.StatusInternalServerError, APIResponse{
+	// No user found
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, APIResponse{
 			Status: "error",
-			Error:  "Failed to delete user",
+			Error:  "User not found",
 		})
 		return
 	}
 
-	// No user found
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, APIResponse{
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to delete user", "email", email, "error", err)
+		c.JSON(http.StatusInternalServerError, APIResponse{
 			Status: "error",
-			Error:  "User not found",
+			Error:  "Failed to delete user",
 		})
 		return
 	}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+func TestListAndDeleteUsers(t *testing.T) {
+	s := newTestStore(t)
+	ctl := NewUserController(s.repos.Users)
+
+	rec := serve(t, "/users", ctl.GetAllUsers, nil, http.MethodGet, "/users?sort=email", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var body struct {
+		Data []models.User `json:"data"`
+	}
+	decode(t, rec, &body)
+	if len(body.Data) != 3 || body.Data[0].Email != s.Admin.Email {
+		t.Fatalf("expected the 3 users sorted by email, got %+v", body.Data)
+	}
+
+	expectStatus(t, serve(t, "/users/:email", ctl.DeleteUser, nil, http.MethodDelete, "/users/"+s.Patient.Email, nil), http.StatusOK)
+	expectStatus(t, serve(t, "/users/:email", ctl.DeleteUser, nil, http.MethodDelete, "/users/"+s.Patient.Email, nil), http.StatusNotFound)
+}
@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/webhooks"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookInput struct {
+	URL         string   `json:"url" binding:"required"`
+	Secret      string   `json:"secret"` // generated when empty
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+	ProviderID  *uint    `json:"provider_id"`
+}
+
+// validateWebhookInput checks the URL, event types and provider scope
+func validateWebhookInput(input WebhookInput) (string, error) {
+	u, err := url.ParseRequestURI(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("url must be an absolute http(s) URL")
+	}
+
+	var types []string
+	seen := make(map[string]bool, len(input.EventTypes))
+	for _, t := range input.EventTypes {
+		if !webhooks.IsSupportedEvent(events.Type(t)) {
+			return "", fmt.Errorf("unsupported event type: %s", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+
+	if input.ProviderID != nil {
+		var provider models.Provider
+		if err := db.DB.First(&provider, *input.ProviderID).Error; err != nil {
+			return "", errors.New("Provider not found")
+		}
+	}
+	return strings.Join(types, ","), nil
+}
+
+// webhookScope returns the provider whose subscriptions the caller manages: nil for
+// an admin, who manages them all, or the caller's own provider profile. It answers
+// 401/403 itself.
+func webhookScope(c *gin.Context) (*uint, bool) {
+	user, ok := scripts.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, APIResponse{Status: "error", Error: "Unauthorized"})
+		return nil, false
+	}
+	if user.Role == models.RoleAdmin {
+		return nil, true
+	}
+
+	var provider models.Provider
+	if err := db.DB.Where("user_id = ?", user.ID).First(&provider).Error; err != nil {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "Only admins and providers with a profile can manage webhooks",
+		})
+		return nil, false
+	}
+	return &provider.ID, true
+}
+
+// scopeWebhookInput ties the subscription of a provider to its own profile
+func scopeWebhookInput(c *gin.Context, scope *uint, input *WebhookInput) bool {
+	if scope == nil {
+		return true
+	}
+	if input.ProviderID != nil && *input.ProviderID != *scope {
+		c.JSON(http.StatusForbidden, APIResponse{
+			Status:  "error",
+			Message: "You can only subscribe to your own events",
+		})
+		return false
+	}
+	input.ProviderID = scope
+	return true
+}
+
+// CreateWebhook handles POST /webhooks (admin, or a provider for its own events)
+func CreateWebhook(c *gin.Context) {
+	scope, ok := webhookScope(c)
+	if !ok {
+		return
+	}
+
+	var input WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !scopeWebhookInput(c, scope, &input) {
+		return
+	}
+
+	eventTypes, err := validateWebhookInput(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: err.Error()})
+		return
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		if secret, err = webhooks.NewSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, APIResponse{Status: "error", Message: "Failed to generate secret"})
+			return
+		}
+	}
+
+	subscription := models.WebhookSubscription{
+		URL:         input.URL,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Description: input.Description,
+		Active:      input.Active == nil || *input.Active,
+		ProviderID:  input.ProviderID,
+	}
+
+	if err := db.DB.Create(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to create webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// The secret is only ever returned here
+	c.JSON(http.StatusCreated, APIResponse{
+		Status:  "success",
+		Message: "Webhook created successfully",
+		Data: gin.H{
+			"webhook": subscription,
+			"secret":  secret,
+		},
+	})
+}
+
+// GetAllWebhooks handles GET /webhooks (admin: all, provider: its own)
+func GetAllWebhooks(c *gin.Context) {
+	scope, ok := webhookScope(c)
+	if !ok {
+		return
+	}
+
+	query := db.DB.Order("id")
+	if scope != nil {
+		query = query.Where("provider_id = ?", *scope)
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := query.Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch webhooks",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Webhooks fetched successfully",
+		Length:  len(subscriptions),
+		Data:    subscriptions,
+	})
+}
+
+// findWebhook loads the subscription from the :id param, answering 400/403/404 itself.
+// A provider only finds its own subscriptions.
+func findWebhook(c *gin.Context) (models.WebhookSubscription, *uint, bool) {
+	var subscription models.WebhookSubscription
+
+	scope, ok := webhookScope(c)
+	if !ok {
+		return subscription, nil, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid webhook ID format",
+			Error:   err.Error(),
+		})
+		return subscription, nil, false
+	}
+
+	query := db.DB
+	if scope != nil {
+		query = query.Where("provider_id = ?", *scope)
+	}
+	if err := query.First(&subscription, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Webhook not found",
+		})
+		return subscription, nil, false
+	}
+	return subscription, scope, true
+}
+
+// GetWebhookByID handles GET /webhooks/:id
+func GetWebhookByID(c *gin.Context) {
+	subscription, _, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Webhook fetched successfully",
+		Data:    subscription,
+	})
+}
+
+// UpdateWebhook handles PUT /webhooks/:id
+// An empty secret keeps the current one.
+func UpdateWebhook(c *gin.Context) {
+	subscription, scope, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var input WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid input",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if !scopeWebhookInput(c, scope, &input) {
+		return
+	}
+
+	eventTypes, err := validateWebhookInput(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{Status: "error", Message: err.Error()})
+		return
+	}
+
+	subscription.URL = input.URL
+	subscription.EventTypes = eventTypes
+	subscription.Description = input.Description
+	subscription.ProviderID = input.ProviderID
+	if input.Secret != "" {
+		subscription.Secret = input.Secret
+	}
+	if input.Active != nil {
+		subscription.Active = *input.Active
+	}
+
+	if err := db.DB.Save(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to update webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Webhook updated successfully",
+		Data:    subscription,
+	})
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id
+func DeleteWebhook(c *gin.Context) {
+	subscription, _, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := db.DB.Delete(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to delete webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Webhook deleted successfully",
+	})
+}
+
+// GetWebhookDeliveries handles GET /webhooks/:id/deliveries
+// Optional filter: ?status=failed
+func GetWebhookDeliveries(c *gin.Context) {
+	subscription, _, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	query := db.DB.Where("subscription_id = ?", subscription.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(200).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to fetch deliveries",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Deliveries fetched successfully",
+		Length:  len(deliveries),
+		Data:    deliveries,
+	})
+}
+
+// RedeliverWebhook handles POST /webhooks/:id/deliveries/:deliveryId/redeliver
+// Sends the logged payload again right away and returns the new delivery.
+func RedeliverWebhook(c *gin.Context) {
+	subscription, _, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, APIResponse{
+			Status:  "error",
+			Message: "Invalid delivery ID format",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var original models.WebhookDelivery
+	if err := db.DB.Where("subscription_id = ?", subscription.ID).
+		First(&original, deliveryID).Error; err != nil {
+		c.JSON(http.StatusNotFound, APIResponse{
+			Status:  "error",
+			Message: "Delivery not found",
+		})
+		return
+	}
+
+	delivery, err := webhooks.Redeliver(subscription, original)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, APIResponse{
+			Status:  "error",
+			Message: "Failed to redeliver webhook",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Status:  "success",
+		Message: "Webhook redelivered",
+		Data:    delivery,
+	})
+}
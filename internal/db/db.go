@@ -1,48 +1,71 @@
 package db
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
 
-	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/config"
+	"github.com/adriel-meb/appointly-backend/internal/logging"
+	"github.com/adriel-meb/appointly-backend/internal/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-// DbConnect connects to the database using environment variables
-func DbConnect() {
+// DbConnect connects to the configured database. Queries are logged through
+// the default slog logger, with the request ID of the context they run with,
+// and traced as part of the request or job of that context.
+func DbConnect(cfg config.DatabaseConfig) {
 	var err error
-	dsn := "host=" + os.Getenv("DB_HOST") + " user=" + os.Getenv("DB_USER") + " password=" + os.Getenv("DB_PASSWORD") + " dbname=" + os.Getenv("DB_NAME") + " port=" + os.Getenv("DB_PORT") + " sslmode=disable TimeZone=Africa/Libreville"
-
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: logging.Gorm(slog.Default(), cfg.SlowQuery),
+	})
 
+	if err == nil {
+		err = DB.Use(tracing.GormPlugin{})
+	}
+	if err == nil {
+		err = DB.Use(SearchIndexPlugin{})
+	}
 	if err != nil {
-		log.Fatal("failed to connect database. DSN = ", dsn, " error: ", err)
+		slog.Error("failed to connect database", "database", cfg.Name, "host", cfg.Host, "port", cfg.Port, "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Database connected")
+	slog.Info("database connected", "database", cfg.Name, "host", cfg.Host)
 }
 
+// DbMigration applies the pending versioned migrations (see migrate.go).
+// Several instances may start at once: the migration lock makes them take turns.
 func DbMigration() {
-	DbConnect()
-	err := DB.AutoMigrate(
-		&models.User{},
-		&models.Provider{},
-		&models.Specialization{},
-		&models.Service{},
-		&models.Availability{},
-		&models.Booking{},
-		&models.City{},
-		&models.Notification{},
-		&models.Insurance{},
-		&models.AvailabilitySlot{},
-	)
+	applied, err := MigrateUp(context.Background())
+	if err != nil {
+		slog.Error("failed to migrate database", "error", err)
+		os.Exit(1)
+	}
 
+	for _, migration := range applied {
+		slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	slog.Info("database migrated")
+}
+
+// Ping checks that the database answers
+func Ping(ctx context.Context) error {
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatal("failed to migrate database: ", err)
+		return err
 	}
+	return sqlDB.PingContext(ctx)
+}
 
-	log.Println("Database migrated")
+// Close closes the connection pool
+func Close() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS bookings;
+DROP TABLE IF EXISTS provider_insurances;
+DROP TABLE IF EXISTS insurance_cities;
+DROP TABLE IF EXISTS insurances;
+DROP TABLE IF EXISTS availability_slots;
+DROP TABLE IF EXISTS availabilities;
+DROP TABLE IF EXISTS services;
+DROP TABLE IF EXISTS providers;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS cities;
+DROP TABLE IF EXISTS specializations;
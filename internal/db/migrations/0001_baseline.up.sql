@@ -0,0 +1,173 @@
+-- Schema as GORM AutoMigrate created it before versioned migrations. IF NOT EXISTS
+-- lets databases built by that AutoMigrate adopt it; the following migrations then
+-- bring them, like new databases, to the current schema.
+
+CREATE TABLE IF NOT EXISTS specializations (
+	id bigserial PRIMARY KEY,
+	name text NOT NULL,
+	description text,
+	CONSTRAINT uni_specializations_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS cities (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	name varchar(100)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cities_name ON cities (name);
+CREATE INDEX IF NOT EXISTS idx_cities_deleted_at ON cities (deleted_at);
+
+CREATE TABLE IF NOT EXISTS users (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	name varchar(100) NOT NULL,
+	email varchar(150) NOT NULL,
+	password_hash text NOT NULL,
+	role varchar(20) NOT NULL DEFAULT 'patient',
+	phone_number varchar(20),
+	specialization_id bigint,
+	bio text,
+	CONSTRAINT fk_users_specialization FOREIGN KEY (specialization_id) REFERENCES specializations (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS providers (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id bigint,
+	specialization_id bigint,
+	bio text,
+	image_url text,
+	city_id bigint NOT NULL,
+	rating decimal,
+	review_count bigint,
+	price text,
+	address text,
+	lat decimal,
+	lng decimal,
+	distance text,
+	CONSTRAINT fk_providers_specialization FOREIGN KEY (specialization_id) REFERENCES specializations (id),
+	CONSTRAINT fk_providers_city FOREIGN KEY (city_id) REFERENCES cities (id) ON DELETE SET NULL ON UPDATE CASCADE,
+	CONSTRAINT fk_providers_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_providers_deleted_at ON providers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS services (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	title varchar(100) NOT NULL,
+	provider_id bigint NOT NULL,
+	description text,
+	duration_minutes bigint NOT NULL,
+	price decimal NOT NULL,
+	CONSTRAINT fk_services_provider FOREIGN KEY (provider_id) REFERENCES providers (id),
+	CONSTRAINT chk_services_duration_minutes CHECK (duration_minutes > 0),
+	CONSTRAINT chk_services_price CHECK (price > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_services_deleted_at ON services (deleted_at);
+
+CREATE TABLE IF NOT EXISTS availabilities (
+	id bigserial PRIMARY KEY,
+	provider_id bigint NOT NULL,
+	day_of_week varchar(10),
+	is_recurring boolean DEFAULT false,
+	date timestamptz,
+	start_time varchar(5) NOT NULL,
+	end_time varchar(5) NOT NULL,
+	CONSTRAINT fk_providers_availabilities FOREIGN KEY (provider_id) REFERENCES providers (id)
+);
+CREATE INDEX IF NOT EXISTS idx_availabilities_provider_id ON availabilities (provider_id);
+
+CREATE TABLE IF NOT EXISTS availability_slots (
+	id bigserial PRIMARY KEY,
+	availability_id bigint NOT NULL,
+	start_time varchar(5) NOT NULL,
+	end_time varchar(5) NOT NULL,
+	is_booked boolean DEFAULT false,
+	booked_at timestamptz DEFAULT NULL,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	CONSTRAINT fk_availabilities_slots FOREIGN KEY (availability_id) REFERENCES availabilities (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_availability_slots_availability_id ON availability_slots (availability_id);
+CREATE INDEX IF NOT EXISTS idx_availability_slots_deleted_at ON availability_slots (deleted_at);
+
+CREATE TABLE IF NOT EXISTS insurances (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	name varchar(100) NOT NULL,
+	description text,
+	coverage text,
+	phone varchar(50),
+	email varchar(100),
+	website varchar(255),
+	logo_url varchar(255)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_insurances_email ON insurances (email);
+CREATE INDEX IF NOT EXISTS idx_insurances_name ON insurances (name);
+CREATE INDEX IF NOT EXISTS idx_insurances_deleted_at ON insurances (deleted_at);
+
+CREATE TABLE IF NOT EXISTS insurance_cities (
+	insurance_id bigint,
+	city_id bigint,
+	PRIMARY KEY (insurance_id, city_id),
+	CONSTRAINT fk_insurance_cities_insurance FOREIGN KEY (insurance_id) REFERENCES insurances (id),
+	CONSTRAINT fk_insurance_cities_city FOREIGN KEY (city_id) REFERENCES cities (id)
+);
+
+CREATE TABLE IF NOT EXISTS provider_insurances (
+	provider_id bigint,
+	insurance_id bigint,
+	PRIMARY KEY (provider_id, insurance_id),
+	CONSTRAINT fk_provider_insurances_provider FOREIGN KEY (provider_id) REFERENCES providers (id),
+	CONSTRAINT fk_provider_insurances_insurance FOREIGN KEY (insurance_id) REFERENCES insurances (id)
+);
+
+CREATE TABLE IF NOT EXISTS bookings (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	patient_id bigint NOT NULL,
+	provider_id bigint NOT NULL,
+	service_id bigint NOT NULL,
+	availability_id bigint,
+	start_time timestamptz NOT NULL,
+	end_time timestamptz,
+	status varchar(20) DEFAULT 'pending',
+	notes text,
+	payment_status varchar(20) DEFAULT 'unpaid',
+	amount decimal
+);
+CREATE INDEX IF NOT EXISTS idx_bookings_patient_id ON bookings (patient_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_provider_id ON bookings (provider_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_service_id ON bookings (service_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_availability_id ON bookings (availability_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_start_time ON bookings (start_time);
+CREATE INDEX IF NOT EXISTS idx_bookings_end_time ON bookings (end_time);
+CREATE INDEX IF NOT EXISTS idx_bookings_status ON bookings (status);
+CREATE INDEX IF NOT EXISTS idx_bookings_payment_status ON bookings (payment_status);
+CREATE INDEX IF NOT EXISTS idx_bookings_deleted_at ON bookings (deleted_at);
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id bigint NOT NULL,
+	message text,
+	notification_type text
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_deleted_at ON notifications (deleted_at);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS notification_event_preferences;
+DROP TABLE IF EXISTS notification_preferences;
+DROP INDEX IF EXISTS idx_notifications_send_at;
+DROP INDEX IF EXISTS idx_notifications_status;
+DROP INDEX IF EXISTS idx_notifications_event_type;
+ALTER TABLE notifications DROP COLUMN IF EXISTS sent_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS send_at;
+ALTER TABLE notifications DROP COLUMN IF EXISTS status;
+ALTER TABLE notifications DROP COLUMN IF EXISTS event_type;
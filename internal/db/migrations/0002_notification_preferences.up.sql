@@ -0,0 +1,35 @@
+-- Notification events, deferred delivery and per-user preferences.
+-- Notifications sent before were delivered right away: they are recorded as sent.
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS event_type varchar(30);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS status varchar(20);
+UPDATE notifications SET status = 'sent' WHERE status IS NULL;
+ALTER TABLE notifications ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS send_at timestamptz;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS sent_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_notifications_event_type ON notifications (event_type);
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications (status);
+CREATE INDEX IF NOT EXISTS idx_notifications_send_at ON notifications (send_at);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id bigint NOT NULL,
+	timezone varchar(64) NOT NULL DEFAULT 'Africa/Libreville',
+	quiet_hours_start varchar(5),
+	quiet_hours_end varchar(5),
+	marketing_opt_out boolean DEFAULT false
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_preferences_user_id ON notification_preferences (user_id);
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_deleted_at ON notification_preferences (deleted_at);
+
+CREATE TABLE IF NOT EXISTS notification_event_preferences (
+	id bigserial PRIMARY KEY,
+	preference_id bigint NOT NULL,
+	event_type varchar(30) NOT NULL,
+	channels varchar(50) NOT NULL,
+	CONSTRAINT fk_notification_preferences_events FOREIGN KEY (preference_id) REFERENCES notification_preferences (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_preference_event ON notification_event_preferences (preference_id, event_type);
@@ -0,0 +1,41 @@
+-- Outbound webhook subscriptions and their delivery log.
+-- Events are identified by a UUID shared by all their deliveries.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	url varchar(255) NOT NULL,
+	secret varchar(100) NOT NULL,
+	event_types text NOT NULL,
+	description text,
+	active boolean NOT NULL,
+	provider_id bigint,
+	CONSTRAINT fk_webhook_subscriptions_provider FOREIGN KEY (provider_id) REFERENCES providers (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_provider_id ON webhook_subscriptions (provider_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_deleted_at ON webhook_subscriptions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	subscription_id bigint NOT NULL,
+	event_id varchar(36),
+	event_type varchar(50) NOT NULL,
+	payload text NOT NULL,
+	status varchar(20) DEFAULT 'pending',
+	attempts bigint DEFAULT 0,
+	next_attempt_at timestamptz,
+	last_status_code bigint,
+	last_error text,
+	delivered_at timestamptz,
+	CONSTRAINT fk_webhook_subscriptions_deliveries FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_deleted_at ON webhook_deliveries (deleted_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payments;
+ALTER TABLE services DROP COLUMN IF EXISTS requires_prepayment;
@@ -0,0 +1,24 @@
+-- Online payments of bookings; services can require them before confirmation.
+
+ALTER TABLE services ADD COLUMN IF NOT EXISTS requires_prepayment boolean DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS payments (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	booking_id bigint NOT NULL,
+	gateway varchar(30) NOT NULL,
+	external_id varchar(100) NOT NULL,
+	checkout_url varchar(255),
+	amount decimal NOT NULL,
+	currency varchar(3) NOT NULL DEFAULT 'XAF',
+	status varchar(20) NOT NULL DEFAULT 'pending',
+	failure_reason text,
+	paid_at timestamptz,
+	CONSTRAINT fk_payments_booking FOREIGN KEY (booking_id) REFERENCES bookings (id)
+);
+CREATE INDEX IF NOT EXISTS idx_payments_booking_id ON payments (booking_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_external_id ON payments (external_id);
+CREATE INDEX IF NOT EXISTS idx_payments_status ON payments (status);
+CREATE INDEX IF NOT EXISTS idx_payments_deleted_at ON payments (deleted_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS cancellation_policies;
+DROP TABLE IF EXISTS refunds;
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE bookings DROP COLUMN IF EXISTS cancellation_fee;
+ALTER TABLE bookings DROP COLUMN IF EXISTS cancelled_at;
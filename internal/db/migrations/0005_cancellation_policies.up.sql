@@ -0,0 +1,38 @@
+-- Cancellation fees and the refunds of paid bookings, per provider policy.
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancelled_at timestamptz;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancellation_fee decimal DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount decimal DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS refunds (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	payment_id bigint NOT NULL,
+	external_id varchar(100),
+	amount decimal NOT NULL,
+	reason text,
+	status varchar(20) NOT NULL DEFAULT 'pending',
+	failure_reason text,
+	CONSTRAINT fk_payments_refunds FOREIGN KEY (payment_id) REFERENCES payments (id)
+);
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds (payment_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_deleted_at ON refunds (deleted_at);
+
+CREATE TABLE IF NOT EXISTS cancellation_policies (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	provider_id bigint NOT NULL,
+	full_refund_hours bigint NOT NULL,
+	late_cancellation_fee_percent decimal NOT NULL,
+	no_show_fee_percent decimal NOT NULL,
+	CONSTRAINT fk_cancellation_policies_provider FOREIGN KEY (provider_id) REFERENCES providers (id) ON DELETE CASCADE,
+	CONSTRAINT chk_cancellation_policies_full_refund_hours CHECK (full_refund_hours >= 0),
+	CONSTRAINT chk_cancellation_policies_late_cancellation_fee_percent CHECK (late_cancellation_fee_percent BETWEEN 0 AND 100),
+	CONSTRAINT chk_cancellation_policies_no_show_fee_percent CHECK (no_show_fee_percent BETWEEN 0 AND 100)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cancellation_policies_provider_id ON cancellation_policies (provider_id);
+CREATE INDEX IF NOT EXISTS idx_cancellation_policies_deleted_at ON cancellation_policies (deleted_at);
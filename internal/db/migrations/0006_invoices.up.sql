@@ -0,0 +1,32 @@
+-- Invoices, numbered per provider.
+
+CREATE TABLE IF NOT EXISTS invoices (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	number varchar(30) NOT NULL,
+	sequence bigint NOT NULL,
+	booking_id bigint NOT NULL,
+	provider_id bigint NOT NULL,
+	patient_id bigint NOT NULL,
+	provider_name varchar(100),
+	provider_specialization varchar(100),
+	provider_address text,
+	provider_city varchar(100),
+	patient_name varchar(100),
+	patient_email varchar(150),
+	patient_phone varchar(20),
+	service_title varchar(100),
+	service_date timestamptz,
+	duration_minutes bigint,
+	amount decimal NOT NULL,
+	currency varchar(3) NOT NULL DEFAULT 'XAF',
+	payment_status varchar(20),
+	issued_at timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_number ON invoices (number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_booking_id ON invoices (booking_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoice_provider_sequence ON invoices (sequence, provider_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_patient_id ON invoices (patient_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_deleted_at ON invoices (deleted_at);
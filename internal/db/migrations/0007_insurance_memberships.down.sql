@@ -0,0 +1,9 @@
+ALTER TABLE invoices DROP COLUMN IF EXISTS patient_amount;
+ALTER TABLE invoices DROP COLUMN IF EXISTS insured_amount;
+ALTER TABLE invoices DROP COLUMN IF EXISTS member_number;
+ALTER TABLE invoices DROP COLUMN IF EXISTS insurer_name;
+DROP INDEX IF EXISTS idx_bookings_insurance_membership_id;
+ALTER TABLE bookings DROP COLUMN IF EXISTS patient_amount;
+ALTER TABLE bookings DROP COLUMN IF EXISTS insured_amount;
+ALTER TABLE bookings DROP COLUMN IF EXISTS insurance_membership_id;
+DROP TABLE IF EXISTS insurance_memberships;
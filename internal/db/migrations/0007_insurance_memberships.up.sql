@@ -0,0 +1,33 @@
+-- Patients' insurance memberships and the insured part of bookings and invoices.
+-- Bookings made before are uninsured: the patient pays the whole amount.
+
+CREATE TABLE IF NOT EXISTS insurance_memberships (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id bigint NOT NULL,
+	insurance_id bigint NOT NULL,
+	member_number varchar(50) NOT NULL,
+	coverage_percent decimal NOT NULL,
+	valid_until timestamptz,
+	CONSTRAINT fk_insurance_memberships_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+	CONSTRAINT fk_insurance_memberships_insurance FOREIGN KEY (insurance_id) REFERENCES insurances (id),
+	CONSTRAINT chk_insurance_memberships_coverage_percent CHECK (coverage_percent BETWEEN 0 AND 100)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_membership_user_insurance ON insurance_memberships (user_id, insurance_id);
+CREATE INDEX IF NOT EXISTS idx_insurance_memberships_deleted_at ON insurance_memberships (deleted_at);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS insurance_membership_id bigint;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS insured_amount decimal DEFAULT 0;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS patient_amount decimal;
+UPDATE bookings SET patient_amount = amount WHERE patient_amount IS NULL;
+ALTER TABLE bookings ALTER COLUMN patient_amount SET DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_bookings_insurance_membership_id ON bookings (insurance_membership_id);
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS insurer_name varchar(100);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS member_number varchar(50);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS insured_amount decimal DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS patient_amount decimal;
+UPDATE invoices SET patient_amount = amount WHERE patient_amount IS NULL;
+ALTER TABLE invoices ALTER COLUMN patient_amount SET DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS distance text;
+DROP INDEX IF EXISTS idx_provider_location;
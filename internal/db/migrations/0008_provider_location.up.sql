@@ -0,0 +1,5 @@
+-- Bounding-box prefilter of the distance search. The distance is now computed
+-- per query and no longer stored.
+
+CREATE INDEX IF NOT EXISTS idx_provider_location ON providers (lat, lng);
+ALTER TABLE providers DROP COLUMN IF EXISTS distance;
@@ -0,0 +1,23 @@
+-- Patients' reviews of their completed bookings.
+
+CREATE TABLE IF NOT EXISTS reviews (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	booking_id bigint NOT NULL,
+	provider_id bigint NOT NULL,
+	patient_id bigint NOT NULL,
+	rating bigint NOT NULL,
+	comment text,
+	reply text,
+	replied_at timestamptz,
+	hidden boolean NOT NULL DEFAULT false,
+	hidden_reason text,
+	CONSTRAINT chk_reviews_rating CHECK (rating BETWEEN 1 AND 5)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_booking_id ON reviews (booking_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_provider_id ON reviews (provider_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_patient_id ON reviews (patient_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_hidden ON reviews (hidden);
+CREATE INDEX IF NOT EXISTS idx_reviews_deleted_at ON reviews (deleted_at);
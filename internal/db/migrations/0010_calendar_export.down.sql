@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_booking_id;
+ALTER TABLE notifications DROP COLUMN IF EXISTS booking_id;
+DROP INDEX IF EXISTS idx_users_calendar_token;
+ALTER TABLE users DROP COLUMN IF EXISTS calendar_token;
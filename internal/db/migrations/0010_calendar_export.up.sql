@@ -0,0 +1,6 @@
+-- Secret iCalendar feed URLs, and the booking a notification is about (for its .ics).
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_token varchar(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_calendar_token ON users (calendar_token);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS booking_id bigint;
+CREATE INDEX IF NOT EXISTS idx_notifications_booking_id ON notifications (booking_id);
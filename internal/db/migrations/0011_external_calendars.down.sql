@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS external_busy_times;
+DROP TABLE IF EXISTS external_calendars;
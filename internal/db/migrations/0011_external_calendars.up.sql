@@ -0,0 +1,27 @@
+-- Providers' external calendars and the busy times imported from them.
+
+CREATE TABLE IF NOT EXISTS external_calendars (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	provider_id bigint NOT NULL,
+	name varchar(100) NOT NULL,
+	url text,
+	source text,
+	last_synced_at timestamptz,
+	last_error text,
+	busy_count bigint NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_external_calendars_provider_id ON external_calendars (provider_id);
+CREATE INDEX IF NOT EXISTS idx_external_calendars_deleted_at ON external_calendars (deleted_at);
+
+CREATE TABLE IF NOT EXISTS external_busy_times (
+	id bigserial PRIMARY KEY,
+	external_calendar_id bigint NOT NULL,
+	provider_id bigint NOT NULL,
+	start_time timestamptz NOT NULL,
+	end_time timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_external_busy_times_external_calendar_id ON external_busy_times (external_calendar_id);
+CREATE INDEX IF NOT EXISTS idx_external_busy_provider_time ON external_busy_times (provider_id, start_time);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_providers_search_vector;
+ALTER TABLE providers DROP COLUMN IF EXISTS search_vector;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS french_unaccent;
+DROP EXTENSION IF EXISTS unaccent;
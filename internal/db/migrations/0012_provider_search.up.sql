@@ -0,0 +1,25 @@
+-- Full-text search on providers: French stemming on top of unaccent,
+-- so "medecin" matches "Médecin".
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'french_unaccent') THEN
+		CREATE TEXT SEARCH CONFIGURATION french_unaccent (COPY = french);
+		ALTER TEXT SEARCH CONFIGURATION french_unaccent
+			ALTER MAPPING FOR hword, hword_part, word WITH unaccent, french_stem;
+	END IF;
+END $$;
+
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS search_vector tsvector;
+CREATE INDEX IF NOT EXISTS idx_providers_search_vector ON providers USING GIN (search_vector);
+
+-- Index existing providers; the application keeps the column up to date afterwards
+UPDATE providers p SET search_vector =
+	setweight(to_tsvector('french_unaccent', coalesce((SELECT name FROM users WHERE id = p.user_id), '')), 'A') ||
+	setweight(to_tsvector('french_unaccent', coalesce((SELECT name FROM specializations WHERE id = p.specialization_id), '')), 'A') ||
+	setweight(to_tsvector('french_unaccent', coalesce((SELECT name FROM cities WHERE id = p.city_id), '')), 'B') ||
+	setweight(to_tsvector('french_unaccent', coalesce((
+		SELECT string_agg(s.title || ' ' || coalesce(s.description, ''), ' ')
+		FROM services s WHERE s.provider_id = p.id AND s.deleted_at IS NULL), '')), 'B') ||
+	setweight(to_tsvector('french_unaccent', coalesce(p.bio, '')), 'C');
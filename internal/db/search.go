@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// SearchConfig is the text search configuration used for providers:
+// French stemming on top of unaccent, so "medecin" matches "Médecin".
+// It and the providers.search_vector column are created by migration 0012.
+const SearchConfig = "french_unaccent"
+
+// providerSearchDocumentSQL builds the weighted search document of providers p.
+// Name and specialization rank highest, then city and services, then the bio.
+const providerSearchDocumentSQL = `
+	setweight(to_tsvector('french_unaccent', coalesce((SELECT name FROM users WHERE id = p.user_id), '')), 'A') ||
+	setweight(to_tsvector('french_unaccent', coalesce((SELECT name FROM specializations WHERE id = p.specialization_id), '')), 'A') ||
+	setweight(to_tsvector('french_unaccent', coalesce((SELECT name FROM cities WHERE id = p.city_id), '')), 'B') ||
+	setweight(to_tsvector('french_unaccent', coalesce((
+		SELECT string_agg(s.title || ' ' || coalesce(s.description, ''), ' ')
+		FROM services s WHERE s.provider_id = p.id AND s.deleted_at IS NULL), '')), 'B') ||
+	setweight(to_tsvector('french_unaccent', coalesce(p.bio, '')), 'C')`
+
+// RefreshProviderSearch recomputes the search document of the given providers,
+// or of every provider when no id is given. Call it after changing a provider,
+// its services, or a name they reference; SearchIndexPlugin does it for services
+// and users.
+func RefreshProviderSearch(tx *gorm.DB, providerIDs ...uint) error {
+	if len(providerIDs) == 0 {
+		return tx.Exec(`UPDATE providers p SET search_vector = ` + providerSearchDocumentSQL).Error
+	}
+	return tx.Exec(`UPDATE providers p SET search_vector = `+providerSearchDocumentSQL+` WHERE p.id IN ?`, providerIDs).Error
+}
+
+// refreshUserProviderSearch recomputes the search document of the providers of the given users
+func refreshUserProviderSearch(tx *gorm.DB, userIDs []uint) error {
+	return tx.Exec(`UPDATE providers p SET search_vector = `+providerSearchDocumentSQL+` WHERE p.user_id IN ?`, userIDs).Error
+}
+
+// SearchIndexPlugin keeps the providers' search documents up to date when the services
+// or users they are built from are updated or deleted, whichever code path does it.
+// The refresh runs in the same transaction as the change. When the changed records
+// are not known (updates by condition), every provider is refreshed.
+type SearchIndexPlugin struct{}
+
+// Name implements gorm.Plugin
+func (SearchIndexPlugin) Name() string {
+	return "search_index"
+}
+
+// Initialize implements gorm.Plugin
+func (SearchIndexPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Update().After("gorm:update").Register("search_index:update", refreshSearchIndex); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("search_index:delete", refreshSearchIndex)
+}
+
+// refreshSearchIndex refreshes the providers affected by a write to services or users
+func refreshSearchIndex(tx *gorm.DB) {
+	stmt := tx.Statement
+	if tx.Error != nil || stmt.Schema == nil || tx.RowsAffected == 0 {
+		return
+	}
+
+	session := tx.Session(&gorm.Session{NewDB: true})
+	var err error
+	switch stmt.Schema.Table {
+	case "services":
+		err = RefreshProviderSearch(session, fieldValues(stmt, "ProviderID")...)
+	case "users":
+		if ids := fieldValues(stmt, "ID"); len(ids) > 0 {
+			err = refreshUserProviderSearch(session, ids)
+		} else {
+			err = RefreshProviderSearch(session)
+		}
+	default:
+		return
+	}
+	if err != nil {
+		tx.AddError(fmt.Errorf("refresh provider search index: %w", err))
+	}
+}
+
+// fieldValues returns the non-zero values of a uint field of the statement's records
+func fieldValues(stmt *gorm.Statement, name string) []uint {
+	field := stmt.Schema.LookUpField(name)
+	if field == nil {
+		return nil
+	}
+
+	var values []uint
+	collect := func(record reflect.Value) {
+		if value, zero := field.ValueOf(stmt.Context, record); !zero {
+			if id, ok := value.(uint); ok {
+				values = append(values, id)
+			}
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			collect(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		collect(stmt.ReflectValue)
+	}
+	return values
+}
+
+// RefreshProviderSearchLogged refreshes the search index without failing the caller:
+// the change itself is already saved, search only lags until the next refresh.
+func RefreshProviderSearchLogged(ctx context.Context, providerIDs ...uint) {
+	if err := RefreshProviderSearch(DB.WithContext(ctx), providerIDs...); err != nil {
+		slog.ErrorContext(ctx, "failed to refresh provider search index", "error", err)
+	}
+}
+
+// SearchHit is the relevance and highlighted excerpt of a provider for a query
+type SearchHit struct {
+	ID      uint
+	Rank    float64
+	Snippet string
+}
+
+// providerSearchTextSQL is the text excerpts are cut from. It is HTML-escaped before
+// ts_headline adds its <mark> tags, so names and bios written by users cannot inject
+// markup into the snippet; the parser reads the escapes as entities, not words.
+const providerSearchTextSQL = `replace(replace(replace(replace(replace(concat_ws(' · ',
+	(SELECT name FROM users WHERE id = p.user_id),
+	(SELECT name FROM specializations WHERE id = p.specialization_id),
+	(SELECT string_agg(s.title, ', ') FROM services s WHERE s.provider_id = p.id AND s.deleted_at IS NULL),
+	nullif(p.bio, '')),
+	'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&apos;')`
+
+// ProviderSearchHits ranks the given providers against a websearch-style query
+// ("dermato libreville", "pédiatre -urgence"). Snippets are HTML: the escaped text
+// with matches highlighted by <mark>.
+func ProviderSearchHits(tx *gorm.DB, query string, providerIDs []uint) (map[uint]SearchHit, error) {
+	hits := map[uint]SearchHit{}
+	if len(providerIDs) == 0 {
+		return hits, nil
+	}
+
+	var rows []SearchHit
+	err := tx.Raw(`SELECT p.id,
+			ts_rank_cd(p.search_vector, q) AS rank,
+			ts_headline('french_unaccent', `+providerSearchTextSQL+`, q,
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=8, MaxFragments=2') AS snippet
+		FROM providers p, websearch_to_tsquery('french_unaccent', ?) q
+		WHERE p.id IN ?`, query, providerIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		hits[row.ID] = row
+	}
+	return hits, nil
+}
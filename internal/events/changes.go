@@ -0,0 +1,36 @@
+package events
+
+import (
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+)
+
+// BookingEvent describes a change of a booking. Set ProviderUserID before
+// publishing it, so the provider's account can see it on the event stream.
+func BookingEvent(t Type, booking models.Booking) Event {
+	return Event{
+		Type:       t,
+		Data:       booking,
+		ProviderID: booking.ProviderID,
+		PatientID:  booking.PatientID,
+	}
+}
+
+// AvailabilityEvent describes a change of a provider's availability window;
+// action is "created", "updated" or "deleted"
+func AvailabilityEvent(availability models.Availability, action string) Event {
+	return Event{
+		Type: AvailabilityChanged,
+		Data: map[string]interface{}{
+			"action":          action,
+			"availability_id": availability.ID,
+			"provider_id":     availability.ProviderID,
+			"day_of_week":     availability.DayOfWeek,
+			"date":            scripts.FormatDate(availability.Date),
+			"start_time":      availability.StartTime,
+			"end_time":        availability.EndTime,
+		},
+		ProviderID: availability.ProviderID,
+		Public:     true,
+	}
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+// Type identifies what happened
+type Type string
+
+const (
+	BookingCreated      Type = "booking.created"
+	BookingConfirmed    Type = "booking.confirmed"
+	BookingCancelled    Type = "booking.cancelled"
+	SlotBooked          Type = "slot.booked"
+	SlotReleased        Type = "slot.released"
+	AvailabilityChanged Type = "availability.changed"
+)
+
+// Event is a change broadcast to live subscribers.
+// Public events are visible to everyone; private ones only to the patient,
+// the provider's user account and admins.
+type Event struct {
+	ID         uint64    `json:"id"`
+	Type       Type      `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+
+	ProviderID     uint `json:"provider_id"`
+	PatientID      uint `json:"-"`
+	ProviderUserID uint `json:"-"`
+	Public         bool `json:"-"`
+}
+
+// Subscription receives the events accepted by its filter
+type Subscription struct {
+	C      chan Event
+	filter func(Event) bool
+}
+
+// Hub fans events out to subscribers. Slow subscribers miss events
+// rather than blocking publishers.
+type Hub struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	nextID atomic.Uint64
+}
+
+// NewHub returns an empty hub
+func NewHub() *Hub {
+	return &Hub{subs: map[*Subscription]struct{}{}}
+}
+
+// Subscribe registers a subscriber; filter may be nil to receive everything
+func (h *Hub) Subscribe(filter func(Event) bool, buffer int) *Subscription {
+	sub := &Subscription{C: make(chan Event, buffer), filter: filter}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+	h.mu.Unlock()
+}
+
+// Publish stamps the event and delivers it to every matching subscriber
+func (h *Hub) Publish(e Event) {
+	e.ID = h.nextID.Add(1)
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.C <- e:
+		default:
+			slog.Warn("events: subscriber buffer full, dropping event", "type", e.Type, "id", e.ID)
+		}
+	}
+}
+
+// Default is the process-wide hub used by the HTTP handlers
+var Default = NewHub()
+
+// Publish sends an event through the default hub
+func Publish(e Event) { Default.Publish(e) }
+
+// Subscribe registers a subscriber on the default hub
+func Subscribe(filter func(Event) bool, buffer int) *Subscription {
+	return Default.Subscribe(filter, buffer)
+}
+
+// Unsubscribe removes a subscriber from the default hub
+func Unsubscribe(sub *Subscription) { Default.Unsubscribe(sub) }
+
+// VisibleTo reports whether a user may receive the event
+func (e Event) VisibleTo(user models.User) bool {
+	if e.Public || user.Role == models.RoleAdmin {
+		return true
+	}
+	return user.ID != 0 && (user.ID == e.PatientID || user.ID == e.ProviderUserID)
+}
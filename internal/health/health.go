@@ -0,0 +1,87 @@
+// Package health backs the liveness and readiness probes: dependency checks
+// and the heartbeats of background workers.
+package health
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Check tests one dependency the server needs to serve requests
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a Check
+type Result struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "ok" or "failing"
+	Error  string `json:"error,omitempty"`
+}
+
+// Run runs every check and reports whether they all passed
+func Run(ctx context.Context, checks []Check) ([]Result, bool) {
+	results := make([]Result, 0, len(checks))
+	ready := true
+	for _, check := range checks {
+		result := Result{Name: check.Name, Status: "ok"}
+		if err := check.Run(ctx); err != nil {
+			result.Status, result.Error = "failing", err.Error()
+			ready = false
+		}
+		results = append(results, result)
+	}
+	return results, ready
+}
+
+// missedBeats is how many beats a worker may miss before it is reported stuck
+const missedBeats = 3
+
+type heartbeat struct {
+	at    time.Time
+	every time.Duration
+}
+
+var (
+	mu    sync.Mutex
+	beats = map[string]heartbeat{}
+)
+
+// Beat records that a worker is alive and will beat again within every
+func Beat(worker string, every time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	beats[worker] = heartbeat{at: time.Now(), every: every}
+}
+
+// Stop forgets a worker that exited on purpose, so it is not reported stuck
+func Stop(worker string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(beats, worker)
+}
+
+// Workers is the check failing when a worker missed several beats
+func Workers() Check {
+	return Check{Name: "workers", Run: func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var stuck []string
+		now := time.Now()
+		for worker, beat := range beats {
+			if now.Sub(beat.at) > missedBeats*beat.every {
+				stuck = append(stuck, fmt.Sprintf("%s (last beat %s ago)", worker, now.Sub(beat.at).Round(time.Second)))
+			}
+		}
+		if len(stuck) > 0 {
+			slices.Sort(stuck)
+			return fmt.Errorf("workers not responding: %v", stuck)
+		}
+		return nil
+	}}
+}
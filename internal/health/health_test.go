@@ -0,0 +1,33 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkersReportsMissedBeats(t *testing.T) {
+	t.Cleanup(func() { Stop("fast"); Stop("slow") })
+	check := Workers()
+
+	Beat("fast", time.Millisecond)
+	Beat("slow", time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	err := check.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "fast") || strings.Contains(err.Error(), "slow") {
+		t.Fatalf("expected only the fast worker to be stuck, got %v", err)
+	}
+
+	Beat("fast", time.Millisecond)
+	if err := check.Run(context.Background()); err != nil {
+		t.Fatalf("a worker beating again is healthy, got %v", err)
+	}
+
+	Stop("fast")
+	time.Sleep(5 * time.Millisecond)
+	if err := check.Run(context.Background()); err != nil {
+		t.Fatalf("a stopped worker is not stuck, got %v", err)
+	}
+}
@@ -0,0 +1,383 @@
+// Package listing is the shared query layer of list endpoints: cursor pagination,
+// whitelisted sorting, sparse fields and total counts.
+//
+//	GET /bookings?limit=20&sort=-start_time&fields=id,start_time,status
+//	GET /bookings?limit=20&sort=-start_time&cursor=<next_cursor of the previous page>
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page size limits
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Spec describes what a list endpoint allows
+type Spec struct {
+	// Sorts maps ?sort= keys to non-null columns of the listed table; "id" is always allowed
+	Sorts map[string]string
+	// DefaultSort is used without ?sort=, e.g. "id" or "-start_time"
+	DefaultSort string
+	// Fields are the JSON keys ?fields= may select
+	Fields []string
+}
+
+// ErrInvalidCursor is returned for a cursor that is malformed or comes from another
+// kind of listing: a keyset cursor given to an offset-paged one, or the reverse.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Params is a parsed list request
+type Params struct {
+	Limit  int
+	Sort   string // sort key, without the "-" prefix
+	Desc   bool
+	Fields []string
+
+	column string
+	cursor *cursor
+}
+
+// Page is returned in APIResponse.Pagination
+type Page struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the opaque position of a page: a keyset (sort value + id) for plain
+// listings, or an offset for ranked ones that cannot be keyed by a column.
+type cursor struct {
+	Sort   string          `json:"s,omitempty"`
+	Value  json.RawMessage `json:"v,omitempty"`
+	Time   bool            `json:"t,omitempty"`
+	ID     uint            `json:"id,omitempty"`
+	Offset int             `json:"o,omitempty"`
+}
+
+// Parse reads limit, sort, cursor and fields against the endpoint spec
+func Parse(c *gin.Context, spec Spec) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return p, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		p.Limit = limit
+	}
+
+	sort := c.DefaultQuery("sort", spec.DefaultSort)
+	if sort == "" {
+		sort = "id"
+	}
+	p.Desc = strings.HasPrefix(sort, "-")
+	p.Sort = strings.TrimPrefix(sort, "-")
+	if p.Sort == "id" {
+		p.column = "id"
+	} else if column, ok := spec.Sorts[p.Sort]; ok {
+		p.column = column
+	} else {
+		return p, fmt.Errorf("cannot sort by %q (allowed: %s)", p.Sort, strings.Join(sortKeys(spec), ", "))
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return p, ErrInvalidCursor
+		}
+		var cur cursor
+		if err := json.Unmarshal(raw, &cur); err != nil {
+			return p, ErrInvalidCursor
+		}
+		if cur.Offset == 0 && cur.Sort != sort {
+			return p, errors.New("cursor belongs to another sort order")
+		}
+		p.cursor = &cur
+	}
+
+	if v := c.Query("fields"); v != "" {
+		allowed := map[string]bool{}
+		for _, f := range spec.Fields {
+			allowed[strings.ToLower(f)] = true
+		}
+		for _, f := range strings.Split(v, ",") {
+			f = strings.ToLower(strings.TrimSpace(f))
+			if f == "" {
+				continue
+			}
+			if !allowed[f] {
+				return p, fmt.Errorf("unknown field %q (allowed: %s)", f, strings.Join(spec.Fields, ", "))
+			}
+			p.Fields = append(p.Fields, f)
+		}
+	}
+
+	return p, nil
+}
+
+// Sorted tells whether the client chose the order with ?sort=
+func Sorted(c *gin.Context) bool {
+	return c.Query("sort") != ""
+}
+
+func sortKeys(spec Spec) []string {
+	keys := []string{"id"}
+	for k := range spec.Sorts {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Find runs a keyset-paginated query: the total is counted on tx as given, then the
+// page after the cursor is loaded in (sort column, id) order. Offset cursors are
+// refused with ErrInvalidCursor.
+func Find[T any](tx *gorm.DB, p Params) ([]T, Page, error) {
+	page := Page{Limit: p.Limit}
+	if p.cursor != nil && p.cursor.Offset != 0 {
+		return nil, page, ErrInvalidCursor
+	}
+
+	table, err := tableOf[T](tx)
+	if err != nil {
+		return nil, page, err
+	}
+
+	if err := tx.Session(&gorm.Session{}).Model(new(T)).Count(&page.Total).Error; err != nil {
+		return nil, page, err
+	}
+
+	column := table + "." + p.column
+	id := table + ".id"
+	query := tx.Session(&gorm.Session{})
+
+	if p.cursor != nil {
+		value, err := p.cursor.value()
+		if err != nil {
+			return nil, page, err
+		}
+		op := ">"
+		if p.Desc {
+			op = "<"
+		}
+		if p.column == "id" {
+			query = query.Where(id+" "+op+" ?", p.cursor.ID)
+		} else {
+			query = query.Where("("+column+" "+op+" ?) OR ("+column+" = ? AND "+id+" "+op+" ?)", value, value, p.cursor.ID)
+		}
+	}
+
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: column, Raw: true}, Desc: p.Desc})
+	if p.column != "id" {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: id, Raw: true}, Desc: p.Desc})
+	}
+
+	var items []T
+	if err := query.Limit(p.Limit + 1).Find(&items).Error; err != nil {
+		return nil, page, err
+	}
+
+	if len(items) > p.Limit {
+		items = items[:p.Limit]
+		page.HasMore = true
+		next, err := keysetCursor(tx, items[len(items)-1], p)
+		if err != nil {
+			return nil, page, err
+		}
+		page.NextCursor = next
+	}
+	return items, page, nil
+}
+
+// FindRanked paginates a query whose ORDER BY is already set (relevance, distance...)
+// with offset cursors. Keyset cursors are refused with ErrInvalidCursor.
+func FindRanked[T any](tx *gorm.DB, p Params) ([]T, Page, error) {
+	page := Page{Limit: p.Limit}
+	offset, err := p.offset()
+	if err != nil {
+		return nil, page, err
+	}
+	if err := tx.Session(&gorm.Session{}).Model(new(T)).Count(&page.Total).Error; err != nil {
+		return nil, page, err
+	}
+
+	var items []T
+	if err := tx.Session(&gorm.Session{}).Offset(offset).Limit(p.Limit + 1).Find(&items).Error; err != nil {
+		return nil, page, err
+	}
+
+	if len(items) > p.Limit {
+		items = items[:p.Limit]
+		page.HasMore = true
+		page.NextCursor = encode(cursor{Offset: offset + p.Limit})
+	}
+	return items, page, nil
+}
+
+// Slice paginates items already filtered and ordered in memory, with offset cursors.
+// Keyset cursors are refused with ErrInvalidCursor.
+func Slice[T any](items []T, p Params) ([]T, Page, error) {
+	page := Page{Limit: p.Limit, Total: int64(len(items))}
+
+	offset, err := p.offset()
+	if err != nil {
+		return nil, page, err
+	}
+	if offset >= len(items) {
+		return []T{}, page, nil
+	}
+	items = items[offset:]
+	if len(items) > p.Limit {
+		items = items[:p.Limit]
+		page.HasMore = true
+		page.NextCursor = encode(cursor{Offset: offset + p.Limit})
+	}
+	return items, page, nil
+}
+
+// offset returns the position of an offset cursor, 0 without a cursor
+func (p Params) offset() (int, error) {
+	if p.cursor == nil {
+		return 0, nil
+	}
+	if p.cursor.Offset <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return p.cursor.Offset, nil
+}
+
+// Project keeps only the selected fields of each item of a slice; without
+// fields the data is returned as is. Keys match case-insensitively ("id" selects "ID").
+func Project(data interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, f := range fields {
+		wanted[strings.ToLower(f)] = true
+	}
+
+	projected := make([]map[string]json.RawMessage, len(rows))
+	for i, row := range rows {
+		projected[i] = map[string]json.RawMessage{}
+		for key, value := range row {
+			if wanted[strings.ToLower(key)] {
+				projected[i][key] = value
+			}
+		}
+	}
+	return projected, nil
+}
+
+// tableOf returns the table name of T
+func tableOf[T any](tx *gorm.DB) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(new(T)); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
+
+// keysetCursor encodes the sort value and id of the last item of a page
+func keysetCursor[T any](tx *gorm.DB, last T, p Params) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(new(T)); err != nil {
+		return "", err
+	}
+
+	rv := reflect.ValueOf(last)
+	idField := stmt.Schema.LookUpField("id")
+	field := stmt.Schema.LookUpField(p.column)
+	if idField == nil || field == nil {
+		return "", fmt.Errorf("listing: %s has no column %q", stmt.Schema.Table, p.column)
+	}
+
+	idValue, _ := idField.ValueOf(tx.Statement.Context, rv)
+	id, ok := idValue.(uint)
+	if !ok {
+		return "", fmt.Errorf("listing: %s.id is not a uint", stmt.Schema.Table)
+	}
+
+	sort := p.Sort
+	if p.Desc {
+		sort = "-" + sort
+	}
+	cur := cursor{Sort: sort, ID: id}
+
+	value, _ := field.ValueOf(tx.Statement.Context, rv)
+	if t, ok := value.(time.Time); ok {
+		value = t.Format(time.RFC3339Nano)
+		cur.Time = true
+	}
+	if cur.Value, ok = marshalValue(value); !ok {
+		return "", fmt.Errorf("listing: cannot encode %s.%s in a cursor", stmt.Schema.Table, p.column)
+	}
+	return encode(cur), nil
+}
+
+func marshalValue(v interface{}) (json.RawMessage, bool) {
+	raw, err := json.Marshal(v)
+	return raw, err == nil
+}
+
+// value decodes the sort value of a keyset cursor
+func (c cursor) value() (interface{}, error) {
+	if c.Time {
+		var s string
+		if err := json.Unmarshal(c.Value, &s); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(string(c.Value)))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return f, nil
+	}
+	return v, nil
+}
+
+func encode(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
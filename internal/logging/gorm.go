@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Gorm returns the GORM logger writing to logger: failed queries at error
+// level and queries slower than slowQuery at warn level, with the request ID
+// of the context they ran with. Every query is logged when logger is at debug level.
+//
+// The values bound to a query are never logged, only its placeholders: they
+// hold password hashes, emails and phone numbers.
+func Gorm(logger *slog.Logger, slowQuery time.Duration) gormlogger.Interface {
+	level := gormlogger.Warn
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		level = gormlogger.Info
+	}
+	return gormLogger{gormlogger.NewSlogLogger(logger, gormlogger.Config{
+		LogLevel:                  level,
+		SlowThreshold:             slowQuery,
+		IgnoreRecordNotFoundError: true,
+	})}
+}
+
+type gormLogger struct {
+	gormlogger.Interface
+}
+
+func (l gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return gormLogger{l.Interface.LogMode(level)}
+}
+
+// ParamsFilter drops the values bound to the query before it is logged
+func (gormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
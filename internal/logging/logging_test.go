@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/config"
+)
+
+// record logs one line through a JSON logger and decodes it
+func record(t *testing.T, log func(*slog.Logger)) map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	log(New(config.LogConfig{Level: "info", Format: "json"}, &out))
+
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", out.String(), err)
+	}
+	return line
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+	line := record(t, func(l *slog.Logger) { l.InfoContext(ctx, "booking created", "booking_id", 7) })
+
+	if line["request_id"] != "req-1" || line["msg"] != "booking created" || line["booking_id"] != float64(7) {
+		t.Fatalf("unexpected record %v", line)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	line := record(t, func(l *slog.Logger) {
+		l.With("jwt_token", "eyJ...").WithGroup("user").Info("signup",
+			"email", "jane@example.com", "phone_number", "+241 01 02 03", "Password", "secret123", "user_id", 3)
+	})
+
+	if line["jwt_token"] != Redacted {
+		t.Errorf("expected the token to be redacted, got %v", line["jwt_token"])
+	}
+	user := line["user"].(map[string]any)
+	for _, key := range []string{"email", "phone_number", "Password"} {
+		if user[key] != Redacted {
+			t.Errorf("expected %s to be redacted, got %v", key, user[key])
+		}
+	}
+	if user["user_id"] != float64(3) {
+		t.Errorf("ids are not sensitive, got %v", user["user_id"])
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(config.LogConfig{Level: "warn", Format: "text"}, &out)
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if strings.Contains(out.String(), "hidden") || !strings.Contains(out.String(), "msg=shown") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestGormLoggerDropsQueryValues(t *testing.T) {
+	filter := Gorm(slog.Default(), 0).(interface {
+		ParamsFilter(context.Context, string, ...interface{}) (string, []interface{})
+	})
+	sql, vars := filter.ParamsFilter(context.Background(), "SELECT * FROM users WHERE email = $1", "jane@example.com")
+	if vars != nil || strings.Contains(sql, "jane") {
+		t.Fatalf("query values must not be logged, got %q %v", sql, vars)
+	}
+}
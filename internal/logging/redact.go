@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive attributes
+const Redacted = "[REDACTED]"
+
+// secretMarkers flag the keys of credentials: any key containing one is redacted
+var secretMarkers = []string{"password", "secret", "token", "authorization", "cookie", "dsn", "api_key"}
+
+// personalKeys are the keys of personal data
+var personalKeys = []string{"email", "phone", "phone_number", "address", "message", "body"}
+
+// redact is the ReplaceAttr hook of the handlers: it hides the value of
+// secrets and personal data, whatever group they are logged in. Values are
+// not inspected, so sensitive data must be logged under a descriptive key
+// rather than formatted into the message.
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindGroup {
+		return attr
+	}
+	if sensitive(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+// sensitive reports whether the value logged under key must be hidden
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	if slices.Contains(personalKeys, key) {
+		return true
+	}
+	return slices.ContainsFunc(secretMarkers, func(marker string) bool {
+		return strings.Contains(key, marker)
+	})
+}
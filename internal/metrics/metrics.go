@@ -0,0 +1,81 @@
+// Package metrics exposes the server metrics to Prometheus: HTTP traffic,
+// the database connection pool, the notification queue and business counters.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "appointly"
+
+// Registry holds every metric of the server, plus the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the served requests by method, route pattern and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes the time taken to serve requests
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// Notifications counts the delivery attempts by channel and outcome: "sent",
+	// "failed", or "unreachable" when the user has no contact for the channel
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notification delivery attempts, by channel and outcome (sent, failed, unreachable).",
+	}, []string{"channel", "outcome"})
+
+	// Bookings counts the booking events by provider specialization
+	Bookings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bookings_total",
+		Help:      "Bookings created, cancelled and marked no-show, by provider specialization.",
+	}, []string{"event", "specialization"})
+)
+
+// Booking events counted by Bookings
+const (
+	BookingCreated   = "created"
+	BookingCancelled = "cancelled"
+	BookingNoShow    = "no_show"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		Notifications,
+		Bookings,
+	)
+}
+
+// RegisterDB adds the connection pool stats of the database and the depth of
+// the notification queue read from it. Call it once, after connecting.
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(
+		collectors.NewDBStatsCollector(db, "postgres"),
+		newQueueCollector(db),
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// queueTimeout bounds the query run on each scrape
+const queueTimeout = 2 * time.Second
+
+// queuedStatuses are the notifications still waiting for delivery: pending ones
+// are being sent, scheduled ones wait for the end of quiet hours
+var queuedStatuses = []models.NotificationStatus{models.NotificationPending, models.NotificationScheduled}
+
+// queueCollector reports the notifications waiting for delivery, counted
+// when Prometheus scrapes rather than tracked by every code path changing them
+type queueCollector struct {
+	db   *sql.DB
+	desc *prometheus.Desc
+}
+
+func newQueueCollector(db *sql.DB) *queueCollector {
+	return &queueCollector{
+		db: db,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "notifications", "queued"),
+			"Notifications waiting for delivery, by status (pending, scheduled).", []string{"status"}, nil),
+	}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueTimeout)
+	defer cancel()
+
+	counts := map[models.NotificationStatus]float64{}
+	rows, err := c.db.QueryContext(ctx,
+		`SELECT status, COUNT(*) FROM notifications WHERE status IN ($1, $2) AND deleted_at IS NULL GROUP BY status`,
+		queuedStatuses[0], queuedStatuses[1])
+	if err != nil {
+		slog.Error("metrics: failed to count queued notifications", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status models.NotificationStatus
+		var count float64
+		if err := rows.Scan(&status, &count); err != nil {
+			ch <- prometheus.NewInvalidMetric(c.desc, err)
+			return
+		}
+		counts[status] = count
+	}
+
+	for _, status := range queuedStatuses {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, counts[status], string(status))
+	}
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestQueueCollector(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "queue.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.AutoMigrate(&models.Notification{}); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	later := time.Now().Add(time.Hour)
+	notifications := []models.Notification{
+		{UserID: 1, Message: "a", Status: models.NotificationScheduled, SendAt: &later},
+		{UserID: 1, Message: "b", Status: models.NotificationScheduled, SendAt: &later},
+		{UserID: 2, Message: "c", Status: models.NotificationSent},
+		{UserID: 2, Message: "d", Status: models.NotificationFailed},
+	}
+	if err := conn.Create(&notifications).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP appointly_notifications_queued Notifications waiting for delivery, by status (pending, scheduled).
+# TYPE appointly_notifications_queued gauge
+appointly_notifications_queued{status="pending"} 0
+appointly_notifications_queued{status="scheduled"} 2
+`
+	if err := testutil.CollectAndCompare(newQueueCollector(sqlDB), strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,9 +2,7 @@ package middleware
 
 import (
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -14,13 +12,13 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// RequireAuthMiddleware ensures that requests include a valid JWT
-func RequireAuthMiddleware() gin.HandlerFunc {
+// RequireAuthMiddleware ensures that requests include a valid JWT signed with secret
+func RequireAuthMiddleware(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenString string
 
 		// 1️⃣ Check token in cookie
-		if cookie, err := c.Cookie("jwt_token"); err == nil {
+		if cookie, err := c.Cookie(AuthCookie); err == nil {
 			tokenString = cookie
 		} else {
 			// 2️⃣ If no cookie, check "Authorization" header with Bearer scheme
@@ -41,7 +39,7 @@ func RequireAuthMiddleware() gin.HandlerFunc {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
+			return []byte(secret), nil
 		})
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
@@ -76,7 +74,7 @@ func RequireAuthMiddleware() gin.HandlerFunc {
 
 		// 6️⃣ Fetch the user from the database using the subject ("sub") claim
 		var user models.User
-		if err := db.DB.First(&user, "id = ?", claims["sub"]).Error; err != nil {
+		if err := db.DB.WithContext(c.Request.Context()).First(&user, "id = ?", claims["sub"]).Error; err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Unauthorized: User not found",
 			})
@@ -86,10 +84,7 @@ func RequireAuthMiddleware() gin.HandlerFunc {
 		// 7️⃣ Store the authenticated user in Gin's context for downstream handlers
 		c.Set("user", user)
 
-		// 8️⃣ Log the request for monitoring/debugging
-		log.Printf("✅ Authenticated request - UserID: %d, Path: %s", user.ID, c.Request.URL.Path)
-
-		// 9️⃣ Continue to the next handler
+		// 8️⃣ Continue to the next handler
 		c.Next()
 	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/adriel-meb/appointly-backend/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaders sets the browser security headers of every response.
+// Empty settings leave their header out.
+func SecurityHeaders(cfg config.SecurityConfig) gin.HandlerFunc {
+	headers := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": cfg.ContentSecurityPolicy,
+		"X-Frame-Options":         cfg.FrameOptions,
+		"Referrer-Policy":         cfg.ReferrerPolicy,
+	}
+	if cfg.HSTSMaxAge > 0 {
+		headers["Strict-Transport-Security"] = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds())) + "; includeSubDomains"
+	}
+
+	return func(c *gin.Context) {
+		for name, value := range headers {
+			if value != "" {
+				c.Header(name, value)
+			}
+		}
+		c.Next()
+	}
+}
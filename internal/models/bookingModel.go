@@ -15,6 +15,20 @@ const (
 	Confirmed StatusBooking = "confirmed"
 	Completed StatusBooking = "completed"
 	Cancelled StatusBooking = "cancelled"
+	NoShow    StatusBooking = "no_show" // patient did not come
+)
+
+type PaymentStatus string
+
+// Payment statuses, shared by bookings and their payments
+const (
+	PaymentUnpaid  PaymentStatus = "unpaid"
+	PaymentPending PaymentStatus = "pending" // intent created, waiting for the gateway
+	PaymentPaid    PaymentStatus = "paid"
+	PaymentFailed  PaymentStatus = "failed"
+
+	PaymentPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentRefunded          PaymentStatus = "refunded"
 )
 
 type Booking struct {
@@ -34,6 +48,15 @@ type Booking struct {
 	Notes  string        `gorm:"type:text" json:"notes"`
 
 	// Optional payment tracking
-	PaymentStatus string  `gorm:"type:varchar(20);default:'unpaid';index" json:"payment_status"`
-	Amount        float64 `json:"amount"`
+	PaymentStatus PaymentStatus `gorm:"type:varchar(20);default:'unpaid';index" json:"payment_status"`
+	Amount        float64       `json:"amount"` // full service price
+
+	// Insurance split of Amount; uninsured bookings have PatientAmount == Amount
+	InsuranceMembershipID *uint   `gorm:"index" json:"insurance_membership_id,omitempty"`
+	InsuredAmount         float64 `json:"insured_amount"`
+	PatientAmount         float64 `json:"patient_amount"`
+
+	// Cancellation
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+	CancellationFee float64    `json:"cancellation_fee"` // part of PatientAmount kept by the provider
 }
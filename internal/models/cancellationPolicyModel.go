@@ -0,0 +1,17 @@
+package models
+
+import "gorm.io/gorm"
+
+// CancellationPolicy defines what a patient gets back when cancelling with a provider.
+// Cancelling at least FullRefundHours before the appointment is free; later
+// cancellations keep LateCancellationFeePercent of the amount, and no-shows
+// keep NoShowFeePercent.
+type CancellationPolicy struct {
+	gorm.Model
+	ProviderID uint      `gorm:"not null;uniqueIndex" json:"provider_id"`
+	Provider   *Provider `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE" json:"-"`
+
+	FullRefundHours            int     `gorm:"not null;check:full_refund_hours >= 0" json:"full_refund_hours"`
+	LateCancellationFeePercent float64 `gorm:"not null;check:late_cancellation_fee_percent BETWEEN 0 AND 100" json:"late_cancellation_fee_percent"`
+	NoShowFeePercent           float64 `gorm:"not null;check:no_show_fee_percent BETWEEN 0 AND 100" json:"no_show_fee_percent"`
+}
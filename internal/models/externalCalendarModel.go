@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExternalCalendar is a provider's personal calendar (ICS URL or uploaded file) whose
+// busy events block their slots. URL calendars are fetched again on every sync;
+// uploads keep their content so recurrences are expanded again as time moves on.
+type ExternalCalendar struct {
+	gorm.Model
+	ProviderID uint   `gorm:"not null;index" json:"provider_id"`
+	Name       string `gorm:"type:varchar(100);not null" json:"name"`
+	URL        string `gorm:"type:text" json:"url,omitempty"` // empty for uploads
+	Source     string `gorm:"type:text" json:"-"`             // uploaded .ics content
+
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"` // of the last failed sync
+	BusyCount    int        `gorm:"not null;default:0" json:"busy_count"`  // busy intervals stored
+}
+
+// ExternalBusyTime is one occurrence of a busy event of an external calendar.
+// They are derived data: every sync replaces the calendar's rows.
+type ExternalBusyTime struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	ExternalCalendarID uint      `gorm:"not null;index" json:"external_calendar_id"`
+	ProviderID         uint      `gorm:"not null;index:idx_external_busy_provider_time" json:"provider_id"`
+	StartTime          time.Time `gorm:"not null;index:idx_external_busy_provider_time" json:"start_time"`
+	EndTime            time.Time `gorm:"not null" json:"end_time"`
+}
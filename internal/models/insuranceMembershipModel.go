@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InsuranceMembership is a patient's enrolment with an insurer.
+// CoveragePercent of the service price is paid by the insurer, the rest by the patient.
+type InsuranceMembership struct {
+	gorm.Model
+	UserID      uint       `gorm:"not null;uniqueIndex:idx_membership_user_insurance" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	InsuranceID uint       `gorm:"not null;uniqueIndex:idx_membership_user_insurance" json:"insurance_id"`
+	Insurance   *Insurance `gorm:"foreignKey:InsuranceID" json:"insurance,omitempty"`
+
+	MemberNumber    string     `gorm:"type:varchar(50);not null" json:"member_number"`
+	CoveragePercent float64    `gorm:"not null;check:coverage_percent BETWEEN 0 AND 100" json:"coverage_percent"`
+	ValidUntil      *time.Time `json:"valid_until,omitempty"` // nil means no expiry
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invoice is the document a patient gives their insurer for a booking.
+// Its details are copied at issue time so later edits of the provider,
+// patient or service never change an issued invoice; only the payment
+// status follows the booking's.
+type Invoice struct {
+	gorm.Model
+	Number   string `gorm:"type:varchar(30);not null;uniqueIndex" json:"number"` // e.g. "INV-3-000042"
+	Sequence uint   `gorm:"not null;uniqueIndex:idx_invoice_provider_sequence" json:"sequence"`
+
+	BookingID  uint `gorm:"not null;uniqueIndex" json:"booking_id"`
+	ProviderID uint `gorm:"not null;uniqueIndex:idx_invoice_provider_sequence" json:"provider_id"`
+	PatientID  uint `gorm:"not null;index" json:"patient_id"`
+
+	// Provider
+	ProviderName           string `gorm:"type:varchar(100)" json:"provider_name"`
+	ProviderSpecialization string `gorm:"type:varchar(100)" json:"provider_specialization"`
+	ProviderAddress        string `gorm:"type:text" json:"provider_address"`
+	ProviderCity           string `gorm:"type:varchar(100)" json:"provider_city"`
+
+	// Patient
+	PatientName  string  `gorm:"type:varchar(100)" json:"patient_name"`
+	PatientEmail string  `gorm:"type:varchar(150)" json:"patient_email"`
+	PatientPhone *string `gorm:"type:varchar(20)" json:"patient_phone,omitempty"`
+
+	// Service
+	ServiceTitle    string    `gorm:"type:varchar(100)" json:"service_title"`
+	ServiceDate     time.Time `json:"service_date"`
+	DurationMinutes uint      `json:"duration_minutes"`
+
+	// Insurance, empty for uninsured bookings
+	InsurerName   string  `gorm:"type:varchar(100)" json:"insurer_name,omitempty"`
+	MemberNumber  string  `gorm:"type:varchar(50)" json:"member_number,omitempty"`
+	InsuredAmount float64 `json:"insured_amount"`
+	PatientAmount float64 `json:"patient_amount"`
+
+	Amount        float64       `gorm:"not null" json:"amount"`
+	Currency      string        `gorm:"type:varchar(3);not null;default:'XAF'" json:"currency"`
+	PaymentStatus PaymentStatus `gorm:"type:varchar(20)" json:"payment_status"`
+	IssuedAt      time.Time     `gorm:"not null" json:"issued_at"`
+}
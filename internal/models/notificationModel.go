@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type NotificationType string
 
@@ -10,9 +14,35 @@ const (
 	Push  NotificationType = "push"
 )
 
+// NotificationEvent is the business event a notification is sent for
+type NotificationEvent string
+
+const (
+	NotifyBookingCreated   NotificationEvent = "booking_created"
+	NotifyBookingConfirmed NotificationEvent = "booking_confirmed"
+	NotifyBookingCancelled NotificationEvent = "booking_cancelled"
+	NotifyBookingReminder  NotificationEvent = "booking_reminder"
+	NotifyMarketing        NotificationEvent = "marketing"
+)
+
+type NotificationStatus string
+
+// Notification delivery statuses
+const (
+	NotificationPending   NotificationStatus = "pending"
+	NotificationScheduled NotificationStatus = "scheduled" // held back by quiet hours
+	NotificationSent      NotificationStatus = "sent"
+	NotificationFailed    NotificationStatus = "failed"
+)
+
 type Notification struct {
 	gorm.Model
-	UserID           uint             `gorm:"not null"`
-	Message          string           `gorm:"type:text" json:"message"`
-	NotificationType NotificationType `json:"notification_type"`
+	UserID           uint               `gorm:"not null"`
+	Message          string             `gorm:"type:text" json:"message"`
+	NotificationType NotificationType   `json:"notification_type"`
+	EventType        NotificationEvent  `gorm:"type:varchar(30);index" json:"event_type"`
+	Status           NotificationStatus `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	SendAt           *time.Time         `gorm:"index" json:"send_at,omitempty"` // set when delivery is deferred
+	SentAt           *time.Time         `json:"sent_at,omitempty"`
+	BookingID        *uint              `gorm:"index" json:"booking_id,omitempty"` // booking the notification is about
 }
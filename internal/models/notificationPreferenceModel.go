@@ -0,0 +1,29 @@
+package models
+
+import "gorm.io/gorm"
+
+// NotificationPreference stores how a user wants to be contacted.
+// Users without a row get the defaults from the notifications package.
+type NotificationPreference struct {
+	gorm.Model
+	UserID uint `gorm:"not null;uniqueIndex" json:"user_id"`
+
+	// Quiet hours are expressed in the user's own timezone
+	Timezone        string  `gorm:"type:varchar(64);not null;default:'Africa/Libreville'" json:"timezone"`
+	QuietHoursStart *string `gorm:"type:varchar(5)" json:"quiet_hours_start"` // "22:00"
+	QuietHoursEnd   *string `gorm:"type:varchar(5)" json:"quiet_hours_end"`   // "07:00"
+
+	MarketingOptOut bool `gorm:"default:false" json:"marketing_opt_out"`
+
+	Events []NotificationEventPreference `gorm:"foreignKey:PreferenceID;constraint:OnDelete:CASCADE" json:"events,omitempty"`
+}
+
+// NotificationEventPreference lists the channels enabled for one event type.
+// Channels is an ordered, comma-separated list ("sms,email"): the dispatcher
+// tries them in that order. An empty list disables the event entirely.
+type NotificationEventPreference struct {
+	ID           uint              `gorm:"primaryKey" json:"-"`
+	PreferenceID uint              `gorm:"not null;uniqueIndex:idx_preference_event" json:"-"`
+	EventType    NotificationEvent `gorm:"type:varchar(30);not null;uniqueIndex:idx_preference_event" json:"event_type"`
+	Channels     string            `gorm:"type:varchar(50);not null" json:"channels"`
+}
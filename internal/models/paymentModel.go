@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Payment is one attempt to pay a booking through a payment gateway
+type Payment struct {
+	gorm.Model
+	BookingID uint     `gorm:"not null;index" json:"booking_id"`
+	Booking   *Booking `gorm:"foreignKey:BookingID" json:"booking,omitempty"`
+
+	Gateway     string `gorm:"type:varchar(30);not null" json:"gateway"`
+	ExternalID  string `gorm:"type:varchar(100);not null;uniqueIndex" json:"external_id"` // id of the intent at the gateway
+	CheckoutURL string `gorm:"type:varchar(255)" json:"checkout_url,omitempty"`           // where the patient completes the payment
+
+	Amount        float64       `gorm:"not null" json:"amount"`
+	Currency      string        `gorm:"type:varchar(3);not null;default:'XAF'" json:"currency"`
+	Status        PaymentStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	FailureReason string        `gorm:"type:text" json:"failure_reason,omitempty"`
+	PaidAt        *time.Time    `json:"paid_at,omitempty"`
+
+	RefundedAmount float64  `gorm:"default:0" json:"refunded_amount"` // includes the refunds in progress
+	Refunds        []Refund `gorm:"foreignKey:PaymentID" json:"refunds,omitempty"`
+}
+
+type RefundStatus string
+
+// Refund statuses
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// Refund gives back (part of) a payment through its gateway
+type Refund struct {
+	gorm.Model
+	PaymentID     uint         `gorm:"not null;index" json:"payment_id"`
+	ExternalID    string       `gorm:"type:varchar(100)" json:"external_id,omitempty"`
+	Amount        float64      `gorm:"not null" json:"amount"`
+	Reason        string       `gorm:"type:text" json:"reason"`
+	Status        RefundStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	FailureReason string       `gorm:"type:text" json:"failure_reason,omitempty"`
+}
@@ -36,7 +36,7 @@ type Provider struct {
 	ReviewCount int     `json:"review_count,omitempty"`
 	Price       string  `json:"price,omitempty"`
 	Address     string  `json:"address,omitempty"`
-	Lat         float64 `json:"lat,omitempty"`
-	Lng         float64 `json:"lng,omitempty"`
-	Distance    string  `json:"distance"`
+	Lat         float64 `gorm:"index:idx_provider_location" json:"lat,omitempty"` // bounding-box prefilter of geo search
+	Lng         float64 `gorm:"index:idx_provider_location" json:"lng,omitempty"`
+	Distance    string  `gorm:"-" json:"distance,omitempty"` // computed by geo search
 }
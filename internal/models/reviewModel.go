@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Review is a patient's rating of a completed booking, one per booking.
+// Hidden reviews (moderated by an admin) are left out of the provider's rating.
+type Review struct {
+	gorm.Model
+	BookingID  uint `gorm:"not null;uniqueIndex" json:"booking_id"`
+	ProviderID uint `gorm:"not null;index" json:"provider_id"`
+	PatientID  uint `gorm:"not null;index" json:"patient_id"`
+
+	Rating  int    `gorm:"not null;check:rating BETWEEN 1 AND 5" json:"rating"` // stars
+	Comment string `gorm:"type:text" json:"comment"`
+
+	// Provider answer
+	Reply     string     `gorm:"type:text" json:"reply,omitempty"`
+	RepliedAt *time.Time `json:"replied_at,omitempty"`
+
+	// Moderation
+	Hidden       bool   `gorm:"not null;default:false;index" json:"hidden"`
+	HiddenReason string `gorm:"type:text" json:"hidden_reason,omitempty"`
+}
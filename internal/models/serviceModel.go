@@ -12,4 +12,7 @@ type Service struct {
 	Description     string   `gorm:"type:text"`
 	DurationMinutes uint     `gorm:"not null;check:duration_minutes > 0"`
 	Price           float64  `gorm:"not null;check:price > 0"`
+
+	// When set, bookings are only confirmed once paid online
+	RequiresPrepayment bool `gorm:"default:false"`
 }
@@ -25,4 +25,7 @@ type User struct {
 	SpecializationID *uint           `json:"specialization_id,omitempty"` // foreign key
 	Specialization   *Specialization `gorm:"foreignKey:SpecializationID" json:"specialization,omitempty"`
 	Bio              string          `json:"bio,omitempty"`
+
+	// Secret of the user's iCalendar feed URL, created on first use
+	CalendarToken *string `gorm:"type:varchar(64);uniqueIndex" json:"-"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is a partner endpoint receiving booking and availability events
+type WebhookSubscription struct {
+	gorm.Model
+	URL         string `gorm:"type:varchar(255);not null" json:"url"`
+	Secret      string `gorm:"type:varchar(100);not null" json:"-"`   // HMAC key, only shown on creation
+	EventTypes  string `gorm:"type:text;not null" json:"event_types"` // comma-separated, e.g. "booking.created,booking.cancelled"
+	Description string `gorm:"type:text" json:"description"`
+	Active      bool   `gorm:"not null" json:"active"`
+
+	// Optional: only forward events of one provider (e.g. a partner clinic)
+	ProviderID *uint     `gorm:"index" json:"provider_id"`
+	Provider   *Provider `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE" json:"-"`
+
+	Deliveries []WebhookDelivery `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type WebhookDeliveryStatus string
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending" // waiting for a (re)try
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed" // retries exhausted
+)
+
+// WebhookDelivery logs one event sent to one subscription, across all its attempts
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint   `gorm:"not null;index" json:"subscription_id"`
+	EventID        string `gorm:"type:varchar(36);index" json:"event_id"` // UUID, shared by the deliveries of one event
+	EventType      string `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        string `gorm:"type:text;not null" json:"payload"`
+
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	Attempts       int                   `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  *time.Time            `gorm:"index" json:"next_attempt_at,omitempty"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,196 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/calendar"
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/metrics"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/tracing"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// urgentEvents are delivered immediately, even during quiet hours
+var urgentEvents = map[models.NotificationEvent]bool{
+	models.NotifyBookingCancelled: true,
+}
+
+// Dispatch records a notification for a user and delivers it according to
+// their preferences. It returns nil without error when the user disabled the event.
+// During quiet hours non-urgent notifications are stored as scheduled and
+// delivered later by RunScheduler.
+func Dispatch(ctx context.Context, userID uint, event models.NotificationEvent, message string) (*models.Notification, error) {
+	return dispatch(ctx, userID, nil, event, message)
+}
+
+// DispatchForBooking is Dispatch for a notification about a booking: emails for
+// confirmations and cancellations carry the booking's .ics file.
+func DispatchForBooking(ctx context.Context, userID, bookingID uint, event models.NotificationEvent, message string) (*models.Notification, error) {
+	return dispatch(ctx, userID, &bookingID, event, message)
+}
+
+func dispatch(ctx context.Context, userID uint, bookingID *uint, event models.NotificationEvent, message string) (*models.Notification, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "notifications.dispatch", trace.WithAttributes(
+		attribute.String("notification.event", string(event)),
+		attribute.Int("user.id", int(userID)),
+	))
+	defer span.End()
+
+	pref, err := LoadPreference(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := ChannelsFor(pref, event)
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	notification := models.Notification{
+		UserID:           userID,
+		Message:          message,
+		NotificationType: channels[0],
+		EventType:        event,
+		Status:           models.NotificationPending,
+		BookingID:        bookingID,
+	}
+
+	// Hold back non-urgent notifications until quiet hours are over
+	if !urgentEvents[event] {
+		if quiet, until := inQuietHours(ctx, pref, time.Now()); quiet {
+			notification.Status = models.NotificationScheduled
+			notification.SendAt = &until
+			if err := db.DB.WithContext(ctx).Create(&notification).Error; err != nil {
+				return nil, err
+			}
+			return &notification, nil
+		}
+	}
+
+	if err := db.DB.WithContext(ctx).Create(&notification).Error; err != nil {
+		return nil, err
+	}
+
+	return &notification, deliver(ctx, &notification, channels)
+}
+
+// deliver tries each channel in order until one succeeds and persists the outcome
+func deliver(ctx context.Context, notification *models.Notification, channels []models.NotificationType) error {
+	tx := db.DB.WithContext(ctx)
+	var user models.User
+	if err := tx.First(&user, notification.UserID).Error; err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, channel := range channels {
+		if !canReach(user, channel) {
+			metrics.Notifications.WithLabelValues(string(channel), "unreachable").Inc()
+			lastErr = fmt.Errorf("user %d has no %s contact", user.ID, channel)
+			continue
+		}
+		if err := send(ctx, notification, user, channel); err != nil {
+			metrics.Notifications.WithLabelValues(string(channel), "failed").Inc()
+			lastErr = err
+			continue
+		}
+		metrics.Notifications.WithLabelValues(string(channel), "sent").Inc()
+
+		now := time.Now()
+		notification.NotificationType = channel
+		notification.Status = models.NotificationSent
+		notification.SentAt = &now
+		return tx.Save(notification).Error
+	}
+
+	notification.Status = models.NotificationFailed
+	if err := tx.Save(notification).Error; err != nil {
+		return err
+	}
+	return fmt.Errorf("notification %d could not be delivered: %v", notification.ID, lastErr)
+}
+
+// send sends a notification on one channel, in its own span
+func send(ctx context.Context, notification *models.Notification, user models.User, channel models.NotificationType) error {
+	ctx, span := tracing.Tracer().Start(ctx, "notifications.send", trace.WithAttributes(
+		attribute.String("notification.channel", string(channel)),
+	))
+	defer span.End()
+
+	err := scripts.SendNotifications(ctx, channel, notification.Message, attachmentsFor(ctx, notification, user, channel)...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// calendarEvents are the booking events whose emails carry the booking's .ics file
+var calendarEvents = map[models.NotificationEvent]bool{
+	models.NotifyBookingConfirmed: true,
+	models.NotifyBookingCancelled: true,
+}
+
+// attachmentsFor returns the files sent with a notification on a channel
+func attachmentsFor(ctx context.Context, notification *models.Notification, user models.User, channel models.NotificationType) []scripts.Attachment {
+	if channel != models.Email || notification.BookingID == nil || !calendarEvents[notification.EventType] {
+		return nil
+	}
+
+	var booking models.Booking
+	if err := db.DB.WithContext(ctx).First(&booking, *notification.BookingID).Error; err != nil {
+		slog.WarnContext(ctx, "booking not found, notification sent without .ics",
+			"notification_id", notification.ID, "booking_id", *notification.BookingID)
+		return nil
+	}
+	content, err := calendar.BookingFile(booking, user)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to build .ics, notification sent without it",
+			"notification_id", notification.ID, "booking_id", booking.ID, "error", err)
+		return nil
+	}
+	return []scripts.Attachment{{
+		Filename:    calendar.Filename(booking.ID),
+		ContentType: calendar.ContentType,
+		Content:     content,
+	}}
+}
+
+// canReach reports whether the user has the contact details a channel needs
+func canReach(user models.User, channel models.NotificationType) bool {
+	switch channel {
+	case models.SMS:
+		return user.PhoneNumber != nil && *user.PhoneNumber != ""
+	case models.Email:
+		return user.Email != ""
+	default:
+		return true
+	}
+}
+
+// inQuietHours evaluates the user's quiet window in their own timezone
+func inQuietHours(ctx context.Context, pref models.NotificationPreference, now time.Time) (bool, time.Time) {
+	if pref.QuietHoursStart == nil || pref.QuietHoursEnd == nil {
+		return false, time.Time{}
+	}
+
+	loc, err := time.LoadLocation(pref.Timezone)
+	if err != nil {
+		slog.WarnContext(ctx, "invalid timezone, using UTC", "timezone", pref.Timezone, "user_id", pref.UserID)
+		loc = time.UTC
+	}
+
+	quiet, until, err := scripts.QuietHoursEnd(*pref.QuietHoursStart, *pref.QuietHoursEnd, loc, now)
+	if err != nil {
+		slog.WarnContext(ctx, "invalid quiet hours", "user_id", pref.UserID, "error", err)
+		return false, time.Time{}
+	}
+	return quiet, until
+}
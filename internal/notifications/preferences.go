@@ -0,0 +1,101 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// DefaultTimezone is used for users who never saved preferences
+const DefaultTimezone = "Africa/Libreville"
+
+// DefaultChannels is the fallback order used when a user has not configured an event
+var DefaultChannels = []models.NotificationType{models.Email, models.SMS, models.Push}
+
+// Events lists every event type a user can configure
+var Events = []models.NotificationEvent{
+	models.NotifyBookingCreated,
+	models.NotifyBookingConfirmed,
+	models.NotifyBookingCancelled,
+	models.NotifyBookingReminder,
+	models.NotifyMarketing,
+}
+
+// IsKnownEvent reports whether event is one of Events
+func IsKnownEvent(event models.NotificationEvent) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKnownChannel reports whether channel can be delivered by SendNotifications
+func IsKnownChannel(channel models.NotificationType) bool {
+	for _, ch := range DefaultChannels {
+		if ch == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPreference returns the stored preferences of a user, or the defaults if none exist
+func LoadPreference(ctx context.Context, userID uint) (models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+	err := db.DB.WithContext(ctx).Preload("Events").Where("user_id = ?", userID).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.NotificationPreference{UserID: userID, Timezone: DefaultTimezone}, nil
+	}
+	return pref, err
+}
+
+// ChannelsFor returns the ordered channels allowed for an event.
+// Events the user never configured fall back to DefaultChannels.
+func ChannelsFor(pref models.NotificationPreference, event models.NotificationEvent) []models.NotificationType {
+	if event == models.NotifyMarketing && pref.MarketingOptOut {
+		return nil
+	}
+	for _, e := range pref.Events {
+		if e.EventType == event {
+			return ParseChannels(e.Channels)
+		}
+	}
+	return DefaultChannels
+}
+
+// ParseChannels splits a stored "sms,email" list into channel types
+func ParseChannels(list string) []models.NotificationType {
+	var channels []models.NotificationType
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			channels = append(channels, models.NotificationType(part))
+		}
+	}
+	return channels
+}
+
+// JoinChannels validates channels and serialises them for storage
+func JoinChannels(channels []models.NotificationType) (string, error) {
+	seen := map[models.NotificationType]bool{}
+	var parts []string
+	for _, ch := range channels {
+		ch = models.NotificationType(strings.ToLower(string(ch)))
+		if !IsKnownChannel(ch) {
+			return "", fmt.Errorf("unsupported notification channel: %s", ch)
+		}
+		if seen[ch] {
+			continue
+		}
+		seen[ch] = true
+		parts = append(parts, string(ch))
+	}
+	return strings.Join(parts, ","), nil
+}
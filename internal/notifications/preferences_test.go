@@ -0,0 +1,33 @@
+package notifications
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+func TestChannelsForFallsBackToDefaults(t *testing.T) {
+	pref := models.NotificationPreference{
+		Events: []models.NotificationEventPreference{
+			{EventType: models.NotifyBookingReminder, Channels: "sms, push"},
+			{EventType: models.NotifyBookingCancelled, Channels: ""},
+		},
+	}
+
+	if got := ChannelsFor(pref, models.NotifyBookingReminder); !slices.Equal(got, []models.NotificationType{models.SMS, models.Push}) {
+		t.Errorf("expected the configured sms then push, got %v", got)
+	}
+	if got := ChannelsFor(pref, models.NotifyBookingCreated); !slices.Equal(got, DefaultChannels) {
+		t.Errorf("expected the default channels for an event never configured, got %v", got)
+	}
+	// configured with no channel: the event is disabled
+	if got := ChannelsFor(pref, models.NotifyBookingCancelled); len(got) != 0 {
+		t.Errorf("expected no channel for a disabled event, got %v", got)
+	}
+
+	pref.MarketingOptOut = true
+	if got := ChannelsFor(pref, models.NotifyMarketing); len(got) != 0 {
+		t.Errorf("expected no marketing after opting out, got %v", got)
+	}
+}
@@ -0,0 +1,72 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/tracing"
+)
+
+// RunScheduler periodically delivers notifications deferred by quiet hours.
+// It blocks until ctx is cancelled.
+func RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	health.Beat("notifications", interval)
+	defer health.Stop("notifications")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			health.Beat("notifications", interval)
+			run, span := tracing.StartJob(ctx, "notifications.send_due")
+			if err := sendDue(run, time.Now()); err != nil {
+				span.RecordError(err)
+				slog.ErrorContext(run, "notification scheduler", "error", err)
+			}
+			span.End()
+		}
+	}
+}
+
+// sendDue delivers every scheduled notification whose send time has passed.
+// Channels are resolved again so preference changes made in the meantime apply.
+// A notification that cannot be handled is logged and left for the next run,
+// without holding back the others.
+func sendDue(ctx context.Context, now time.Time) error {
+	tx := db.DB.WithContext(ctx)
+	var due []models.Notification
+	if err := tx.Where("status = ? AND send_at <= ?", models.NotificationScheduled, now).
+		Find(&due).Error; err != nil {
+		return err
+	}
+
+	for i := range due {
+		pref, err := LoadPreference(ctx, due[i].UserID)
+		if err != nil {
+			slog.ErrorContext(ctx, "notification scheduler", "notification_id", due[i].ID, "error", err)
+			continue
+		}
+
+		channels := ChannelsFor(pref, due[i].EventType)
+		if len(channels) == 0 {
+			// The user disabled this event since it was scheduled
+			due[i].Status = models.NotificationFailed
+			if err := tx.Save(&due[i]).Error; err != nil {
+				slog.ErrorContext(ctx, "notification scheduler", "notification_id", due[i].ID, "error", err)
+			}
+			continue
+		}
+
+		if err := deliver(ctx, &due[i], channels); err != nil {
+			slog.ErrorContext(ctx, "notification scheduler", "notification_id", due[i].ID, "error", err)
+		}
+	}
+	return nil
+}
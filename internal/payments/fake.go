@@ -0,0 +1,93 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// FakeGatewayName is the name of the in-process gateway used locally and in tests
+const FakeGatewayName = "fake"
+
+// FakeSignatureHeader carries the hex HMAC-SHA256 of the webhook body
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeGateway accepts every intent without contacting anyone.
+// Payments are completed by POSTing to the webhook endpoint:
+//
+//	{"intent_id": "fake_pi_1", "status": "succeeded"}   // or "failed" with a "reason"
+//
+// signed with FakeSignatureHeader. Webhooks are refused when no secret is
+// configured: intent ids are guessable, so anyone could mark a booking paid.
+type FakeGateway struct {
+	Secret string
+	nextID atomic.Uint64
+}
+
+// NewFakeGateway returns a fake gateway verifying webhooks with secret
+func NewFakeGateway(secret string) *FakeGateway {
+	return &FakeGateway{Secret: secret}
+}
+
+func (g *FakeGateway) Name() string { return FakeGatewayName }
+
+func (g *FakeGateway) CreateIntent(ctx context.Context, req IntentRequest) (Intent, error) {
+	if req.Amount <= 0 {
+		return Intent{}, fmt.Errorf("amount must be positive, got %.2f", req.Amount)
+	}
+	id := fmt.Sprintf("fake_pi_%d_%d", req.BookingID, g.nextID.Add(1))
+	return Intent{
+		ExternalID:  id,
+		CheckoutURL: "https://payments.invalid/checkout/" + id,
+	}, nil
+}
+
+func (g *FakeGateway) ParseWebhook(header http.Header, body []byte) (WebhookResult, error) {
+	if g.Secret == "" || !hmac.Equal([]byte(header.Get(FakeSignatureHeader)), []byte(g.Sign(body))) {
+		return WebhookResult{}, ErrInvalidSignature
+	}
+
+	var payload struct {
+		IntentID string `json:"intent_id"`
+		Status   string `json:"status"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return WebhookResult{}, err
+	}
+	if payload.IntentID == "" {
+		return WebhookResult{}, fmt.Errorf("missing intent_id")
+	}
+	if payload.Status != "succeeded" && payload.Status != "failed" {
+		return WebhookResult{}, fmt.Errorf("unsupported status: %s", payload.Status)
+	}
+
+	return WebhookResult{
+		ExternalID: payload.IntentID,
+		Succeeded:  payload.Status == "succeeded",
+		Reason:     payload.Reason,
+	}, nil
+}
+
+// Refund always succeeds immediately
+func (g *FakeGateway) Refund(ctx context.Context, externalID string, amount float64) (RefundResult, error) {
+	if amount <= 0 {
+		return RefundResult{}, fmt.Errorf("refund amount must be positive, got %.2f", amount)
+	}
+	return RefundResult{
+		ExternalID: fmt.Sprintf("fake_re_%s_%d", externalID, g.nextID.Add(1)),
+		Succeeded:  true,
+	}, nil
+}
+
+// Sign returns the FakeSignatureHeader value for a webhook body
+func (g *FakeGateway) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(g.Secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,36 @@
+package payments
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestFakeWebhookSignature(t *testing.T) {
+	body := []byte(`{"intent_id": "fake_pi_1_1", "status": "succeeded"}`)
+	gateway := NewFakeGateway("webhook-secret")
+
+	signed := http.Header{}
+	signed.Set(FakeSignatureHeader, gateway.Sign(body))
+	result, err := gateway.ParseWebhook(signed, body)
+	if err != nil || !result.Succeeded || result.ExternalID != "fake_pi_1_1" {
+		t.Fatalf("expected the signed webhook to be accepted, got %+v, %v", result, err)
+	}
+
+	forged := http.Header{}
+	forged.Set(FakeSignatureHeader, NewFakeGateway("other").Sign(body))
+	if _, err := gateway.ParseWebhook(forged, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a wrong signature to be refused, got %v", err)
+	}
+	if _, err := gateway.ParseWebhook(http.Header{}, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected an unsigned webhook to be refused, got %v", err)
+	}
+
+	// Without a secret nothing can be verified: every webhook is refused
+	unconfigured := NewFakeGateway("")
+	unsigned := http.Header{}
+	unsigned.Set(FakeSignatureHeader, unconfigured.Sign(body))
+	if _, err := unconfigured.ParseWebhook(unsigned, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected webhooks to be refused without a secret, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// DefaultCurrency is used for every amount: services are priced in CFA francs
+const DefaultCurrency = "XAF"
+
+// IntentRequest describes a payment to collect
+type IntentRequest struct {
+	BookingID   uint
+	Amount      float64
+	Currency    string
+	Description string
+	CustomerRef string // e.g. the patient email
+}
+
+// Intent is the gateway-side payment created for an IntentRequest
+type Intent struct {
+	ExternalID  string
+	CheckoutURL string
+}
+
+// WebhookResult is the outcome of a payment reported by the gateway
+type WebhookResult struct {
+	ExternalID string
+	Succeeded  bool
+	Reason     string // failure reason, if any
+}
+
+// RefundResult is the gateway answer to a refund request
+type RefundResult struct {
+	ExternalID string
+	Succeeded  bool
+	Reason     string // failure reason, if any
+}
+
+// ErrInvalidSignature is returned when a webhook cannot be authenticated
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Gateway is implemented by every payment provider (Stripe, mobile money, fake...)
+type Gateway interface {
+	// Name identifies the gateway in URLs and in stored payments
+	Name() string
+	// CreateIntent registers a payment the patient then completes at CheckoutURL
+	CreateIntent(ctx context.Context, req IntentRequest) (Intent, error)
+	// ParseWebhook authenticates and decodes a gateway notification
+	ParseWebhook(header http.Header, body []byte) (WebhookResult, error)
+	// Refund gives back amount of a succeeded payment
+	Refund(ctx context.Context, externalID string, amount float64) (RefundResult, error)
+}
+
+var (
+	mu          sync.RWMutex
+	gateways    = map[string]Gateway{}
+	defaultName = FakeGatewayName
+)
+
+// Register makes a gateway available by name
+func Register(g Gateway) {
+	mu.Lock()
+	defer mu.Unlock()
+	gateways[g.Name()] = g
+}
+
+// Get returns a registered gateway
+func Get(name string) (Gateway, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	g, ok := gateways[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment gateway: %s", name)
+	}
+	return g, nil
+}
+
+// SetDefault selects the registered gateway used for new payments ("fake" until set)
+func SetDefault(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := gateways[name]; !ok {
+		return fmt.Errorf("unknown payment gateway: %s", name)
+	}
+	defaultName = name
+	return nil
+}
+
+// Default returns the gateway used for new payments
+func Default() (Gateway, error) {
+	mu.RLock()
+	name := defaultName
+	mu.RUnlock()
+	return Get(name)
+}
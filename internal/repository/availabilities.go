@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+// AvailabilityFilter narrows an availability list; zero fields are ignored.
+// Date, From and To apply to one-off availabilities.
+type AvailabilityFilter struct {
+	ProviderID uint
+	Date       *time.Time
+	From       *time.Time
+	To         *time.Time
+}
+
+// AvailabilityRepository stores provider availabilities and their bookable slots
+type AvailabilityRepository interface {
+	// List returns the matching availabilities with their slots
+	List(ctx context.Context, f AvailabilityFilter) ([]models.Availability, error)
+	// FindByID returns the availability with its slots
+	FindByID(ctx context.Context, id uint) (models.Availability, error)
+	// FindSameDay returns the provider's other availabilities on the weekday of a
+	// recurring availability a, or on the date of a one-off one
+	FindSameDay(ctx context.Context, a models.Availability) ([]models.Availability, error)
+	// FindCovering returns the provider's first availability whose hours include
+	// both start and end ("15:04"), or ErrNotFound
+	FindCovering(ctx context.Context, providerID uint, start, end string) (models.Availability, error)
+	// Create saves the availability and its slots, all or nothing.
+	// Like Update and Delete, it queues the availability.changed webhooks in the same transaction.
+	Create(ctx context.Context, availability *models.Availability, slots []models.AvailabilitySlot) error
+	// Update saves the availability and replaces its slots, all or nothing
+	Update(ctx context.Context, availability *models.Availability, slots []models.AvailabilitySlot) error
+	// Delete removes the availability and its slots
+	Delete(ctx context.Context, availability models.Availability) error
+}
+
+type gormAvailabilities struct {
+	db *gorm.DB
+}
+
+// NewGormAvailabilityRepository returns an AvailabilityRepository backed by the database
+func NewGormAvailabilityRepository(db *gorm.DB) AvailabilityRepository {
+	return gormAvailabilities{db}
+}
+
+func (r gormAvailabilities) List(ctx context.Context, f AvailabilityFilter) ([]models.Availability, error) {
+	query := r.db.WithContext(ctx).Preload("Slots")
+	if f.ProviderID != 0 {
+		query = query.Where("provider_id = ?", f.ProviderID)
+	}
+	if f.Date != nil {
+		query = query.Where("date = ?", f.Date)
+	}
+	if f.From != nil {
+		query = query.Where("date >= ?", f.From)
+	}
+	if f.To != nil {
+		query = query.Where("date <= ?", f.To)
+	}
+
+	var availabilities []models.Availability
+	err := query.Find(&availabilities).Error
+	return availabilities, err
+}
+
+func (r gormAvailabilities) FindByID(ctx context.Context, id uint) (models.Availability, error) {
+	var availability models.Availability
+	err := r.db.WithContext(ctx).Preload("Slots").First(&availability, id).Error
+	return availability, notFound(err)
+}
+
+func (r gormAvailabilities) FindSameDay(ctx context.Context, a models.Availability) ([]models.Availability, error) {
+	query := r.db.WithContext(ctx).Where("provider_id = ? AND id != ?", a.ProviderID, a.ID)
+	if a.IsRecurring {
+		query = query.Where("day_of_week = ? AND is_recurring = true", a.DayOfWeek)
+	} else {
+		query = query.Where("date = ? AND is_recurring = false", a.Date)
+	}
+
+	var availabilities []models.Availability
+	err := query.Find(&availabilities).Error
+	return availabilities, err
+}
+
+func (r gormAvailabilities) FindCovering(ctx context.Context, providerID uint, start, end string) (models.Availability, error) {
+	var availability models.Availability
+	err := r.db.WithContext(ctx).Where("provider_id = ?", providerID).
+		Where("? BETWEEN start_time AND end_time", start).
+		Where("? BETWEEN start_time AND end_time", end).
+		First(&availability).Error
+	return availability, notFound(err)
+}
+
+func (r gormAvailabilities) Create(ctx context.Context, availability *models.Availability, slots []models.AvailabilitySlot) error {
+	return wakeWebhooks(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(availability).Error; err != nil {
+			return err
+		}
+		if err := createSlots(tx, availability.ID, slots); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, events.AvailabilityEvent(*availability, "created"))
+	}))
+}
+
+func (r gormAvailabilities) Update(ctx context.Context, availability *models.Availability, slots []models.AvailabilitySlot) error {
+	return wakeWebhooks(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Slots").Save(availability).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("availability_id = ?", availability.ID).Delete(&models.AvailabilitySlot{}).Error; err != nil {
+			return err
+		}
+		if err := createSlots(tx, availability.ID, slots); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, events.AvailabilityEvent(*availability, "updated"))
+	}))
+}
+
+// createSlots saves the slots of an availability, setting their ids
+func createSlots(tx *gorm.DB, availabilityID uint, slots []models.AvailabilitySlot) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	for i := range slots {
+		slots[i].AvailabilityID = availabilityID
+	}
+	return tx.Create(&slots).Error
+}
+
+func (r gormAvailabilities) Delete(ctx context.Context, availability models.Availability) error {
+	return wakeWebhooks(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Availability{}, availability.ID).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, events.AvailabilityEvent(availability, "deleted"))
+	}))
+}
+
+type memoryAvailabilities struct {
+	m *Memory
+}
+
+// sameTime compares optional times, as SQL equality would (NULL matches nothing)
+func sameTime(a, b *time.Time) bool {
+	return a != nil && b != nil && a.Equal(*b)
+}
+
+func (r memoryAvailabilities) List(_ context.Context, f AvailabilityFilter) ([]models.Availability, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var availabilities []models.Availability
+	for _, a := range sortedValues(r.m.availabilities) {
+		switch {
+		case f.ProviderID != 0 && a.ProviderID != f.ProviderID,
+			f.Date != nil && !sameTime(a.Date, f.Date),
+			f.From != nil && (a.Date == nil || a.Date.Before(*f.From)),
+			f.To != nil && (a.Date == nil || a.Date.After(*f.To)):
+			continue
+		}
+		a.Slots = append([]models.AvailabilitySlot(nil), a.Slots...)
+		availabilities = append(availabilities, a)
+	}
+	return availabilities, nil
+}
+
+func (r memoryAvailabilities) FindByID(_ context.Context, id uint) (models.Availability, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	a, ok := r.m.availabilities[id]
+	if !ok {
+		return models.Availability{}, ErrNotFound
+	}
+	a.Slots = append([]models.AvailabilitySlot(nil), a.Slots...)
+	return a, nil
+}
+
+func (r memoryAvailabilities) FindSameDay(_ context.Context, a models.Availability) ([]models.Availability, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var availabilities []models.Availability
+	for _, e := range sortedValues(r.m.availabilities) {
+		if e.ProviderID != a.ProviderID || e.ID == a.ID || e.IsRecurring != a.IsRecurring {
+			continue
+		}
+		if a.IsRecurring && (e.DayOfWeek == nil || a.DayOfWeek == nil || *e.DayOfWeek != *a.DayOfWeek) {
+			continue
+		}
+		if !a.IsRecurring && !sameTime(e.Date, a.Date) {
+			continue
+		}
+		e.Slots = nil
+		availabilities = append(availabilities, e)
+	}
+	return availabilities, nil
+}
+
+func (r memoryAvailabilities) FindCovering(_ context.Context, providerID uint, start, end string) (models.Availability, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, a := range sortedValues(r.m.availabilities) {
+		if a.ProviderID == providerID &&
+			a.StartTime <= start && start <= a.EndTime &&
+			a.StartTime <= end && end <= a.EndTime {
+			a.Slots = nil
+			return a, nil
+		}
+	}
+	return models.Availability{}, ErrNotFound
+}
+
+// storeAvailability saves an availability with copies of its slots; the caller holds m.mu
+func (m *Memory) storeAvailability(availability models.Availability, slots []models.AvailabilitySlot) {
+	now := time.Now()
+	for i := range slots {
+		slots[i].ID = m.nextID("availability_slots")
+		slots[i].AvailabilityID = availability.ID
+		slots[i].CreatedAt, slots[i].UpdatedAt = now, now
+	}
+	availability.Provider = models.Provider{}
+	availability.Slots = append([]models.AvailabilitySlot(nil), slots...)
+	m.availabilities[availability.ID] = availability
+}
+
+func (r memoryAvailabilities) Create(_ context.Context, availability *models.Availability, slots []models.AvailabilitySlot) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	availability.ID = r.m.nextID("availabilities")
+	r.m.storeAvailability(*availability, slots)
+	r.m.outbox = append(r.m.outbox, events.AvailabilityEvent(*availability, "created"))
+	return nil
+}
+
+func (r memoryAvailabilities) Update(_ context.Context, availability *models.Availability, slots []models.AvailabilitySlot) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.availabilities[availability.ID]; !ok {
+		return ErrNotFound
+	}
+	r.m.storeAvailability(*availability, slots)
+	r.m.outbox = append(r.m.outbox, events.AvailabilityEvent(*availability, "updated"))
+	return nil
+}
+
+func (r memoryAvailabilities) Delete(_ context.Context, availability models.Availability) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	delete(r.m.availabilities, availability.ID)
+	r.m.outbox = append(r.m.outbox, events.AvailabilityEvent(availability, "deleted"))
+	return nil
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+// BookingFilter narrows a booking list; zero fields are ignored.
+// From and To bound the start time, To excluded.
+type BookingFilter struct {
+	Statuses        []string
+	PaymentStatuses []string
+	ProviderID      uint
+	PatientID       uint
+	From            *time.Time
+	To              *time.Time
+	// UpcomingAt keeps the pending and confirmed bookings starting at or after it;
+	// PastAt keeps the others, those started before it or no longer active
+	UpcomingAt *time.Time
+	PastAt     *time.Time
+}
+
+// BookingNames are the names a booking list displays, by id
+type BookingNames struct {
+	Patients  map[uint]models.User // with their name and phone number only
+	Providers map[uint]string      // provider id -> name of its user
+	Services  map[uint]string      // service id -> title
+}
+
+// BookingRepository stores bookings
+type BookingRepository interface {
+	FindByID(ctx context.Context, id uint) (models.Booking, error)
+	List(ctx context.Context, f BookingFilter, p listing.Params) ([]models.Booking, listing.Page, error)
+	// CountOverlapping counts the provider's pending and confirmed bookings overlapping [start, end)
+	CountOverlapping(ctx context.Context, providerID uint, start, end time.Time) (int64, error)
+	// Create saves a new booking and queues its booking.created webhooks in the same transaction
+	Create(ctx context.Context, booking *models.Booking) error
+	// Save updates the booking; when event is not empty, its webhooks are queued in the same transaction
+	Save(ctx context.Context, booking *models.Booking, event events.Type) error
+	// Agenda returns the provider's bookings starting in [from, to), by start time;
+	// cancelled ones only when includeCancelled is set
+	Agenda(ctx context.Context, providerID uint, from, to time.Time, includeCancelled bool) ([]models.Booking, error)
+	// LoadNames loads the patient, provider and service names of the bookings
+	LoadNames(ctx context.Context, bookings []models.Booking) (BookingNames, error)
+}
+
+// activeStatuses are the booking statuses that hold their time slot
+var activeStatuses = []models.StatusBooking{models.Pending, models.Confirmed}
+
+type gormBookings struct {
+	db *gorm.DB
+}
+
+// NewGormBookingRepository returns a BookingRepository backed by the database
+func NewGormBookingRepository(db *gorm.DB) BookingRepository {
+	return gormBookings{db}
+}
+
+func (r gormBookings) FindByID(ctx context.Context, id uint) (models.Booking, error) {
+	var booking models.Booking
+	err := r.db.WithContext(ctx).First(&booking, id).Error
+	return booking, notFound(err)
+}
+
+func (r gormBookings) List(ctx context.Context, f BookingFilter, p listing.Params) ([]models.Booking, listing.Page, error) {
+	tx := r.db.WithContext(ctx).Model(&models.Booking{})
+	if len(f.Statuses) > 0 {
+		tx = tx.Where("status IN ?", f.Statuses)
+	}
+	if len(f.PaymentStatuses) > 0 {
+		tx = tx.Where("payment_status IN ?", f.PaymentStatuses)
+	}
+	if f.ProviderID != 0 {
+		tx = tx.Where("provider_id = ?", f.ProviderID)
+	}
+	if f.PatientID != 0 {
+		tx = tx.Where("patient_id = ?", f.PatientID)
+	}
+	if f.From != nil {
+		tx = tx.Where("start_time >= ?", f.From)
+	}
+	if f.To != nil {
+		tx = tx.Where("start_time < ?", f.To)
+	}
+	if f.UpcomingAt != nil {
+		tx = tx.Where("start_time >= ? AND status IN ?", f.UpcomingAt, activeStatuses)
+	}
+	if f.PastAt != nil {
+		tx = tx.Where("start_time < ? OR status NOT IN ?", f.PastAt, activeStatuses)
+	}
+	return listing.Find[models.Booking](tx, p)
+}
+
+func (r gormBookings) CountOverlapping(ctx context.Context, providerID uint, start, end time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Booking{}).
+		Where("provider_id = ?", providerID).
+		Where("status IN ?", activeStatuses).
+		Where("start_time < ? AND end_time > ?", end, start).
+		Count(&count).Error
+	return count, err
+}
+
+func (r gormBookings) Create(ctx context.Context, booking *models.Booking) error {
+	return wakeWebhooks(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(booking).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, events.BookingEvent(events.BookingCreated, *booking))
+	}))
+}
+
+func (r gormBookings) Save(ctx context.Context, booking *models.Booking, event events.Type) error {
+	if event == "" {
+		return r.db.WithContext(ctx).Save(booking).Error
+	}
+	return wakeWebhooks(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(booking).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, events.BookingEvent(event, *booking))
+	}))
+}
+
+func (r gormBookings) Agenda(ctx context.Context, providerID uint, from, to time.Time, includeCancelled bool) ([]models.Booking, error) {
+	tx := r.db.WithContext(ctx).Where("provider_id = ?", providerID).
+		Where("start_time >= ? AND start_time < ?", from, to)
+	if !includeCancelled {
+		tx = tx.Where("status <> ?", models.Cancelled)
+	}
+
+	var bookings []models.Booking
+	err := tx.Order("start_time").Find(&bookings).Error
+	return bookings, err
+}
+
+// LoadNames runs three queries, whatever the number of bookings
+func (r gormBookings) LoadNames(ctx context.Context, bookings []models.Booking) (BookingNames, error) {
+	names := BookingNames{Patients: map[uint]models.User{}, Providers: map[uint]string{}, Services: map[uint]string{}}
+	if len(bookings) == 0 {
+		return names, nil
+	}
+
+	var patientIDs, providerIDs, serviceIDs []uint
+	for _, b := range bookings {
+		patientIDs = append(patientIDs, b.PatientID)
+		providerIDs = append(providerIDs, b.ProviderID)
+		serviceIDs = append(serviceIDs, b.ServiceID)
+	}
+
+	tx := r.db.WithContext(ctx)
+	var patients []models.User
+	if err := tx.Select("id", "name", "phone_number").Where("id IN ?", patientIDs).Find(&patients).Error; err != nil {
+		return names, err
+	}
+	var providers []models.Provider
+	if err := tx.Select("id", "user_id").Preload("User").Where("id IN ?", providerIDs).Find(&providers).Error; err != nil {
+		return names, err
+	}
+	var services []models.Service
+	if err := tx.Select("id", "title").Where("id IN ?", serviceIDs).Find(&services).Error; err != nil {
+		return names, err
+	}
+
+	for _, p := range patients {
+		names.Patients[p.ID] = p
+	}
+	for _, p := range providers {
+		if p.User != nil {
+			names.Providers[p.ID] = p.User.Name
+		}
+	}
+	for _, s := range services {
+		names.Services[s.ID] = s.Title
+	}
+	return names, nil
+}
+
+type memoryBookings struct {
+	m *Memory
+}
+
+func (r memoryBookings) FindByID(_ context.Context, id uint) (models.Booking, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	booking, ok := r.m.bookings[id]
+	if !ok {
+		return models.Booking{}, ErrNotFound
+	}
+	return booking, nil
+}
+
+func (r memoryBookings) List(_ context.Context, f BookingFilter, p listing.Params) ([]models.Booking, listing.Page, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var bookings []models.Booking
+	for _, b := range sortedValues(r.m.bookings) {
+		switch {
+		case len(f.Statuses) > 0 && !slices.Contains(f.Statuses, string(b.Status)),
+			len(f.PaymentStatuses) > 0 && !slices.Contains(f.PaymentStatuses, string(b.PaymentStatus)),
+			f.ProviderID != 0 && b.ProviderID != f.ProviderID,
+			f.PatientID != 0 && b.PatientID != f.PatientID,
+			f.From != nil && b.StartTime.Before(*f.From),
+			f.To != nil && !b.StartTime.Before(*f.To),
+			f.UpcomingAt != nil && (b.StartTime.Before(*f.UpcomingAt) || !slices.Contains(activeStatuses, b.Status)),
+			f.PastAt != nil && !b.StartTime.Before(*f.PastAt) && slices.Contains(activeStatuses, b.Status):
+			continue
+		}
+		bookings = append(bookings, b)
+	}
+
+	bookings, page, err := memoryPage(bookings, p,
+		func(b models.Booking) uint { return b.ID },
+		func(b models.Booking, key string) interface{} {
+			switch key {
+			case "start_time":
+				return b.StartTime
+			case "amount":
+				return b.Amount
+			case "status":
+				return b.Status
+			}
+			return b.CreatedAt
+		})
+	return bookings, page, err
+}
+
+func (r memoryBookings) CountOverlapping(_ context.Context, providerID uint, start, end time.Time) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var count int64
+	for _, b := range r.m.bookings {
+		if b.ProviderID == providerID && slices.Contains(activeStatuses, b.Status) &&
+			b.StartTime.Before(end) && b.EndTime.After(start) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r memoryBookings) Create(_ context.Context, booking *models.Booking) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	// column defaults
+	if booking.Status == "" {
+		booking.Status = models.Pending
+	}
+	if booking.PaymentStatus == "" {
+		booking.PaymentStatus = models.PaymentUnpaid
+	}
+
+	now := time.Now()
+	booking.ID = r.m.nextID("bookings")
+	booking.CreatedAt, booking.UpdatedAt = now, now
+	r.m.bookings[booking.ID] = *booking
+	r.m.outbox = append(r.m.outbox, events.BookingEvent(events.BookingCreated, *booking))
+	return nil
+}
+
+func (r memoryBookings) Save(_ context.Context, booking *models.Booking, event events.Type) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	now := time.Now()
+	if booking.ID == 0 {
+		booking.ID = r.m.nextID("bookings")
+		booking.CreatedAt = now
+	}
+	booking.UpdatedAt = now
+	r.m.bookings[booking.ID] = *booking
+	if event != "" {
+		r.m.outbox = append(r.m.outbox, events.BookingEvent(event, *booking))
+	}
+	return nil
+}
+
+func (r memoryBookings) Agenda(_ context.Context, providerID uint, from, to time.Time, includeCancelled bool) ([]models.Booking, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var bookings []models.Booking
+	for _, b := range sortedValues(r.m.bookings) {
+		if b.ProviderID == providerID && !b.StartTime.Before(from) && b.StartTime.Before(to) &&
+			(includeCancelled || b.Status != models.Cancelled) {
+			bookings = append(bookings, b)
+		}
+	}
+	sort.SliceStable(bookings, func(i, j int) bool { return bookings[i].StartTime.Before(bookings[j].StartTime) })
+	return bookings, nil
+}
+
+func (r memoryBookings) LoadNames(_ context.Context, bookings []models.Booking) (BookingNames, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	names := BookingNames{Patients: map[uint]models.User{}, Providers: map[uint]string{}, Services: map[uint]string{}}
+	for _, b := range bookings {
+		if patient, ok := r.m.users[b.PatientID]; ok {
+			names.Patients[patient.ID] = models.User{Model: patient.Model, Name: patient.Name, PhoneNumber: patient.PhoneNumber}
+		}
+		if provider, ok := r.m.providers[b.ProviderID]; ok {
+			if user, ok := r.m.users[provider.UserID]; ok {
+				names.Providers[provider.ID] = user.Name
+			}
+		}
+		if service, ok := r.m.services[b.ServiceID]; ok {
+			names.Services[service.ID] = service.Title
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CityRepository stores cities; they are read with the insurances operating there
+type CityRepository interface {
+	List(ctx context.Context, p listing.Params) ([]models.City, listing.Page, error)
+	FindByID(ctx context.Context, id uint) (models.City, error)
+	Create(ctx context.Context, city *models.City) error
+	Update(ctx context.Context, city *models.City) error
+	Delete(ctx context.Context, id uint) error
+}
+
+type gormCities struct {
+	db *gorm.DB
+}
+
+// NewGormCityRepository returns a CityRepository backed by the database
+func NewGormCityRepository(db *gorm.DB) CityRepository {
+	return gormCities{db}
+}
+
+func (r gormCities) List(ctx context.Context, p listing.Params) ([]models.City, listing.Page, error) {
+	return listing.Find[models.City](r.db.WithContext(ctx).Preload("Insurances"), p)
+}
+
+func (r gormCities) FindByID(ctx context.Context, id uint) (models.City, error) {
+	var city models.City
+	err := r.db.WithContext(ctx).Preload("Insurances").First(&city, id).Error
+	return city, notFound(err)
+}
+
+func (r gormCities) Create(ctx context.Context, city *models.City) error {
+	return r.db.WithContext(ctx).Create(city).Error
+}
+
+func (r gormCities) Update(ctx context.Context, city *models.City) error {
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Save(city).Error; err != nil {
+		return err
+	}
+	db.RefreshProviderSearchLogged(ctx) // city names are part of every provider's search document
+	return nil
+}
+
+func (r gormCities) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.City{}, id).Error
+}
+
+type memoryCities struct {
+	m *Memory
+}
+
+// withInsurances fills in the insurances of a city; the caller holds m.mu
+func (m *Memory) withInsurances(city models.City) models.City {
+	city.Insurances = []models.Insurance{}
+	for _, insurance := range sortedValues(m.insurances) {
+		if slices.Contains(m.insuranceCities[insurance.ID], city.ID) {
+			city.Insurances = append(city.Insurances, insurance)
+		}
+	}
+	return city
+}
+
+func (r memoryCities) List(_ context.Context, p listing.Params) ([]models.City, listing.Page, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	cities, page, err := memoryPage(sortedValues(r.m.cities), p,
+		func(c models.City) uint { return c.ID },
+		func(c models.City, _ string) interface{} { return c.Name })
+	for i := range cities {
+		cities[i] = r.m.withInsurances(cities[i])
+	}
+	return cities, page, err
+}
+
+func (r memoryCities) FindByID(_ context.Context, id uint) (models.City, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	city, ok := r.m.cities[id]
+	if !ok {
+		return models.City{}, ErrNotFound
+	}
+	return r.m.withInsurances(city), nil
+}
+
+// checkCityName enforces the unique city name; the caller holds m.mu
+func (m *Memory) checkCityName(city *models.City) error {
+	for _, existing := range m.cities {
+		if existing.Name == city.Name && existing.ID != city.ID {
+			return fmt.Errorf("city %s already exists", city.Name)
+		}
+	}
+	return nil
+}
+
+func (r memoryCities) Create(_ context.Context, city *models.City) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if err := r.m.checkCityName(city); err != nil {
+		return err
+	}
+	now := time.Now()
+	city.ID = r.m.nextID("cities")
+	city.CreatedAt, city.UpdatedAt = now, now
+
+	stored := *city
+	stored.Insurances = nil
+	r.m.cities[city.ID] = stored
+	return nil
+}
+
+func (r memoryCities) Update(_ context.Context, city *models.City) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.cities[city.ID]; !ok {
+		return ErrNotFound
+	}
+	if err := r.m.checkCityName(city); err != nil {
+		return err
+	}
+	city.UpdatedAt = time.Now()
+
+	stored := *city
+	stored.Insurances = nil
+	r.m.cities[city.ID] = stored
+	return nil
+}
+
+func (r memoryCities) Delete(_ context.Context, id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	delete(r.m.cities, id)
+	return nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InsuranceFilter narrows an insurance list: each set field must appear in the
+// lower-cased column, as in LOWER(name) LIKE '%value%'
+type InsuranceFilter struct {
+	Name     string
+	Coverage string
+	Email    string
+}
+
+// InsuranceRepository stores insurers; they are read with the cities they operate in
+type InsuranceRepository interface {
+	List(ctx context.Context, f InsuranceFilter, p listing.Params) ([]models.Insurance, listing.Page, error)
+	FindByID(ctx context.Context, id uint) (models.Insurance, error)
+	// Create saves the insurance operating in the given cities; unknown city ids are ignored
+	Create(ctx context.Context, insurance *models.Insurance, cityIDs []uint) error
+	// Update saves the insurance and, unless cityIDs is nil, replaces its cities
+	Update(ctx context.Context, insurance *models.Insurance, cityIDs []uint) error
+	Delete(ctx context.Context, id uint) error
+}
+
+type gormInsurances struct {
+	db *gorm.DB
+}
+
+// NewGormInsuranceRepository returns an InsuranceRepository backed by the database
+func NewGormInsuranceRepository(db *gorm.DB) InsuranceRepository {
+	return gormInsurances{db}
+}
+
+func (r gormInsurances) List(ctx context.Context, f InsuranceFilter, p listing.Params) ([]models.Insurance, listing.Page, error) {
+	query := r.db.WithContext(ctx).Preload("Cities")
+	if f.Name != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+f.Name+"%")
+	}
+	if f.Coverage != "" {
+		query = query.Where("LOWER(coverage) LIKE ?", "%"+f.Coverage+"%")
+	}
+	if f.Email != "" {
+		query = query.Where("LOWER(email) LIKE ?", "%"+f.Email+"%")
+	}
+	return listing.Find[models.Insurance](query, p)
+}
+
+func (r gormInsurances) FindByID(ctx context.Context, id uint) (models.Insurance, error) {
+	var insurance models.Insurance
+	err := r.db.WithContext(ctx).Preload("Cities").First(&insurance, id).Error
+	return insurance, notFound(err)
+}
+
+func (r gormInsurances) Create(ctx context.Context, insurance *models.Insurance, cityIDs []uint) error {
+	tx := r.db.WithContext(ctx)
+	if len(cityIDs) > 0 {
+		var cities []models.City
+		if err := tx.Find(&cities, cityIDs).Error; err != nil {
+			return err
+		}
+		insurance.Cities = cities
+	}
+	return tx.Create(insurance).Error
+}
+
+func (r gormInsurances) Update(ctx context.Context, insurance *models.Insurance, cityIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if cityIDs != nil {
+			var cities []models.City
+			if err := tx.Find(&cities, cityIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(insurance).Association("Cities").Replace(cities); err != nil {
+				return err
+			}
+		}
+		return tx.Omit(clause.Associations).Save(insurance).Error
+	})
+}
+
+func (r gormInsurances) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		insurance := models.Insurance{}
+		insurance.ID = id
+		if err := tx.Model(&insurance).Association("Cities").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&insurance).Error
+	})
+}
+
+type memoryInsurances struct {
+	m *Memory
+}
+
+// withCities fills in the cities of an insurance; the caller holds m.mu
+func (m *Memory) withCities(insurance models.Insurance) models.Insurance {
+	insurance.Cities = []models.City{}
+	for _, id := range m.insuranceCities[insurance.ID] {
+		if city, ok := m.cities[id]; ok {
+			insurance.Cities = append(insurance.Cities, city)
+		}
+	}
+	return insurance
+}
+
+// knownCities keeps the ids of existing cities, once each; the caller holds m.mu
+func (m *Memory) knownCities(cityIDs []uint) []uint {
+	known := []uint{}
+	for _, id := range cityIDs {
+		if _, ok := m.cities[id]; ok && !slices.Contains(known, id) {
+			known = append(known, id)
+		}
+	}
+	return known
+}
+
+// checkInsuranceEmail enforces the unique insurance email; the caller holds m.mu
+func (m *Memory) checkInsuranceEmail(insurance *models.Insurance) error {
+	for _, existing := range m.insurances {
+		if existing.Email == insurance.Email && existing.ID != insurance.ID {
+			return fmt.Errorf("insurance email %s already exists", insurance.Email)
+		}
+	}
+	return nil
+}
+
+func (r memoryInsurances) List(_ context.Context, f InsuranceFilter, p listing.Params) ([]models.Insurance, listing.Page, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var insurances []models.Insurance
+	for _, insurance := range sortedValues(r.m.insurances) {
+		if containsFold(insurance.Name, f.Name) && containsFold(insurance.Coverage, f.Coverage) &&
+			containsFold(insurance.Email, f.Email) {
+			insurances = append(insurances, insurance)
+		}
+	}
+
+	insurances, page, err := memoryPage(insurances, p,
+		func(i models.Insurance) uint { return i.ID },
+		func(i models.Insurance, key string) interface{} {
+			if key == "name" {
+				return i.Name
+			}
+			return i.CreatedAt
+		})
+	for i := range insurances {
+		insurances[i] = r.m.withCities(insurances[i])
+	}
+	return insurances, page, err
+}
+
+func (r memoryInsurances) FindByID(_ context.Context, id uint) (models.Insurance, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	insurance, ok := r.m.insurances[id]
+	if !ok {
+		return models.Insurance{}, ErrNotFound
+	}
+	return r.m.withCities(insurance), nil
+}
+
+func (r memoryInsurances) Create(_ context.Context, insurance *models.Insurance, cityIDs []uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if err := r.m.checkInsuranceEmail(insurance); err != nil {
+		return err
+	}
+	now := time.Now()
+	insurance.ID = r.m.nextID("insurances")
+	insurance.CreatedAt, insurance.UpdatedAt = now, now
+
+	stored := *insurance
+	stored.Cities, stored.Providers = nil, nil
+	r.m.insurances[insurance.ID] = stored
+	r.m.insuranceCities[insurance.ID] = r.m.knownCities(cityIDs)
+	if len(cityIDs) > 0 {
+		insurance.Cities = r.m.withCities(stored).Cities
+	}
+	return nil
+}
+
+func (r memoryInsurances) Update(_ context.Context, insurance *models.Insurance, cityIDs []uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.insurances[insurance.ID]; !ok {
+		return ErrNotFound
+	}
+	if err := r.m.checkInsuranceEmail(insurance); err != nil {
+		return err
+	}
+	insurance.UpdatedAt = time.Now()
+
+	stored := *insurance
+	stored.Cities, stored.Providers = nil, nil
+	r.m.insurances[insurance.ID] = stored
+	if cityIDs != nil {
+		r.m.insuranceCities[insurance.ID] = r.m.knownCities(cityIDs)
+		insurance.Cities = r.m.withCities(stored).Cities
+	}
+	return nil
+}
+
+func (r memoryInsurances) Delete(_ context.Context, id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	delete(r.m.insurances, id)
+	delete(r.m.insuranceCities, id)
+	for providerID, ids := range r.m.providerInsurances {
+		r.m.providerInsurances[providerID] = slices.DeleteFunc(ids, func(i uint) bool { return i == id })
+	}
+	return nil
+}
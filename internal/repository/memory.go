@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"cmp"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+// Memory keeps every aggregate in maps, for handler tests that run without a database.
+// Records are stored by value, so callers never share them with the store.
+type Memory struct {
+	mu sync.Mutex
+
+	lastID map[string]uint
+
+	users              map[uint]models.User
+	providers          map[uint]models.Provider
+	providerInsurances map[uint][]uint // provider id -> accepted insurance ids
+	specializations    map[uint]models.Specialization
+	services           map[uint]models.Service
+	availabilities     map[uint]models.Availability // with their slots
+	bookings           map[uint]models.Booking
+	insurances         map[uint]models.Insurance
+	insuranceCities    map[uint][]uint // insurance id -> city ids
+	cities             map[uint]models.City
+
+	outbox []events.Event // queued for webhooks by the writes, oldest first
+}
+
+// NewMemory returns an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{
+		lastID:             map[string]uint{},
+		users:              map[uint]models.User{},
+		providers:          map[uint]models.Provider{},
+		providerInsurances: map[uint][]uint{},
+		specializations:    map[uint]models.Specialization{},
+		services:           map[uint]models.Service{},
+		availabilities:     map[uint]models.Availability{},
+		bookings:           map[uint]models.Booking{},
+		insurances:         map[uint]models.Insurance{},
+		insuranceCities:    map[uint][]uint{},
+		cities:             map[uint]models.City{},
+	}
+}
+
+// Repositories returns the repositories backed by the store
+func (m *Memory) Repositories() Repositories {
+	return Repositories{
+		Users:          memoryUsers{m},
+		Providers:      memoryProviders{m},
+		Availabilities: memoryAvailabilities{m},
+		Bookings:       memoryBookings{m},
+		Insurances:     memoryInsurances{m},
+		Cities:         memoryCities{m},
+	}
+}
+
+// Outbox returns the events the repositories queued for webhook delivery, oldest first
+func (m *Memory) Outbox() []events.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]events.Event(nil), m.outbox...)
+}
+
+// AddSpecialization stores a specialization, which providers must reference
+func (m *Memory) AddSpecialization(specialization models.Specialization) models.Specialization {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if specialization.ID == 0 {
+		specialization.ID = m.nextID("specializations")
+	}
+	m.specializations[specialization.ID] = specialization
+	return specialization
+}
+
+// AddService stores a service offered by a provider, which bookings are made for
+func (m *Memory) AddService(service models.Service) models.Service {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if service.ID == 0 {
+		service.ID = m.nextID("services")
+	}
+	service.CreatedAt, service.UpdatedAt = time.Now(), time.Now()
+	service.Provider = models.Provider{}
+	m.services[service.ID] = service
+	return service
+}
+
+// nextID allocates the next id of a table; the caller holds m.mu
+func (m *Memory) nextID(table string) uint {
+	m.lastID[table]++
+	return m.lastID[table]
+}
+
+// sortedValues returns the values of a map ordered by id
+func sortedValues[T any](records map[uint]T) []T {
+	ids := make([]uint, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	values := make([]T, len(ids))
+	for i, id := range ids {
+		values[i] = records[id]
+	}
+	return values
+}
+
+// memoryPage orders items by the ?sort= key of p (then id) and cuts the requested page.
+// key returns the value of a sort key; "id" is always asked of id.
+func memoryPage[T any](items []T, p listing.Params, id func(T) uint, key func(T, string) interface{}) ([]T, listing.Page, error) {
+	value := func(item T) interface{} {
+		if p.Sort == "id" || p.Sort == "" {
+			return id(item)
+		}
+		return key(item, p.Sort)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		c := compareValues(value(items[i]), value(items[j]))
+		if c == 0 {
+			c = compareValues(id(items[i]), id(items[j]))
+		}
+		if p.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	return listing.Slice(items, p)
+}
+
+// compareValues orders two values of the same kind: strings, numbers or times
+func compareValues(a, b interface{}) int {
+	if ta, ok := a.(time.Time); ok {
+		return ta.Compare(b.(time.Time))
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.String:
+		return strings.Compare(va.String(), vb.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(va.Int(), vb.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(va.Uint(), vb.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(va.Float(), vb.Float())
+	}
+	return 0
+}
+
+// containsFold reports whether the lower-cased value contains part, like LOWER(col) LIKE '%part%'
+func containsFold(value, part string) bool {
+	return strings.Contains(strings.ToLower(value), part)
+}
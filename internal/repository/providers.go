@@ -0,0 +1,603 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/scripts"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProviderFilter narrows the providers search of GET /providers; zero fields are ignored
+type ProviderFilter struct {
+	Search           string // full-text query; matches are ranked by relevance
+	City             string // part of the city name
+	Insurance        string // part of the name of an accepted insurance
+	ServiceID        uint   // keeps the providers offering the service
+	SpecializationID uint
+	Near             *GeoFilter // keeps the providers within the radius, nearest first
+	AvailableDays    *DayRange  // keeps the providers with a recurring availability or one dated in the range
+}
+
+// GeoFilter is a position and a search radius around it, in km
+type GeoFilter struct {
+	Lat, Lng, RadiusKm float64
+}
+
+// DayRange is a range of calendar days, both included. Days are midnight UTC, as
+// availabilities.date stores them.
+type DayRange struct {
+	From, To time.Time
+}
+
+// Ranked reports whether the providers are ordered by distance or relevance rather
+// than by a column, which pages them by offset
+func (f ProviderFilter) Ranked() bool {
+	return f.Search != "" || f.Near != nil
+}
+
+// ProviderRepository stores provider profiles and the insurances they accept, and
+// reads the services they offer.
+type ProviderRepository interface {
+	FindByID(ctx context.Context, id uint) (models.Provider, error)
+	// FindProfile loads the provider with its user, specialization, city, insurances and availabilities
+	FindProfile(ctx context.Context, id uint) (models.Provider, error)
+	FindByUserID(ctx context.Context, userID uint) (models.Provider, error)
+	// FindService returns one of the services offered by providers
+	FindService(ctx context.Context, id uint) (models.Service, error)
+	SpecializationExists(ctx context.Context, id uint) (bool, error)
+	FindSpecialization(ctx context.Context, id uint) (models.Specialization, error)
+	// Create saves the provider with the insurances it accepts.
+	// A *LinkError reports an unknown city or insurance.
+	Create(ctx context.Context, provider *models.Provider, insuranceIDs []uint) error
+	// Update saves the provider and, unless insuranceIDs is nil, replaces its insurances.
+	// A *LinkError reports an unknown city or insurance.
+	Update(ctx context.Context, provider *models.Provider, insuranceIDs []uint) error
+	Delete(ctx context.Context, id uint) error
+	// List returns a page of the providers matching f, loaded like FindProfile.
+	// Ranked searches are paged by offset, the others by keyset on the ?sort= column.
+	List(ctx context.Context, f ProviderFilter, p listing.Params) ([]models.Provider, listing.Page, error)
+	// ListMatching returns the providers matching f in the order of List, unpaged;
+	// at most limit of them unless limit is 0
+	ListMatching(ctx context.Context, f ProviderFilter, limit int) ([]models.Provider, error)
+	// SearchHits returns the relevance and highlighted excerpt of the providers for a query
+	SearchHits(ctx context.Context, query string, providerIDs []uint) (map[uint]db.SearchHit, error)
+	// AddInsurance links the provider to an insurance it accepts; linking twice keeps one link
+	AddInsurance(ctx context.Context, providerID, insuranceID uint) error
+	// RemoveInsurance unlinks them; it returns false when the provider did not accept the insurance
+	RemoveInsurance(ctx context.Context, providerID, insuranceID uint) (bool, error)
+}
+
+type gormProviders struct {
+	db *gorm.DB
+}
+
+// NewGormProviderRepository returns a ProviderRepository backed by the database
+func NewGormProviderRepository(db *gorm.DB) ProviderRepository {
+	return gormProviders{db}
+}
+
+func (r gormProviders) FindByID(ctx context.Context, id uint) (models.Provider, error) {
+	var provider models.Provider
+	err := r.db.WithContext(ctx).First(&provider, id).Error
+	return provider, notFound(err)
+}
+
+func (r gormProviders) FindProfile(ctx context.Context, id uint) (models.Provider, error) {
+	var provider models.Provider
+	err := r.db.WithContext(ctx).Preload("User").
+		Preload("Specialization").
+		Preload("City").
+		Preload("Insurances").
+		Preload("Availabilities").
+		First(&provider, id).Error
+	return provider, notFound(err)
+}
+
+func (r gormProviders) FindByUserID(ctx context.Context, userID uint) (models.Provider, error) {
+	var provider models.Provider
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&provider).Error
+	return provider, notFound(err)
+}
+
+func (r gormProviders) FindService(ctx context.Context, id uint) (models.Service, error) {
+	var service models.Service
+	err := r.db.WithContext(ctx).First(&service, id).Error
+	return service, notFound(err)
+}
+
+func (r gormProviders) SpecializationExists(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Specialization{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+func (r gormProviders) FindSpecialization(ctx context.Context, id uint) (models.Specialization, error) {
+	var specialization models.Specialization
+	err := r.db.WithContext(ctx).First(&specialization, id).Error
+	return specialization, notFound(err)
+}
+
+// loadProviderLinks checks that the city and every insurance exist and returns the insurances.
+// Duplicates in insuranceIDs are ignored.
+func loadProviderLinks(tx *gorm.DB, cityID uint, insuranceIDs []uint) ([]*models.Insurance, error) {
+	var city models.City
+	if err := tx.First(&city, cityID).Error; err != nil {
+		return nil, &LinkError{fmt.Sprintf("city %d not found", cityID)}
+	}
+
+	insurances := []*models.Insurance{}
+	if len(insuranceIDs) == 0 {
+		return insurances, nil
+	}
+	if err := tx.Where("id IN ?", insuranceIDs).Find(&insurances).Error; err != nil {
+		return nil, err
+	}
+
+	found := map[uint]bool{}
+	for _, ins := range insurances {
+		found[ins.ID] = true
+	}
+	for _, id := range insuranceIDs {
+		if !found[id] {
+			return nil, &LinkError{fmt.Sprintf("insurance %d not found", id)}
+		}
+	}
+	return insurances, nil
+}
+
+func (r gormProviders) Create(ctx context.Context, provider *models.Provider, insuranceIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		insurances, err := loadProviderLinks(tx, provider.CityID, insuranceIDs)
+		if err != nil {
+			return err
+		}
+		provider.Insurances = insurances
+		if err := tx.Omit("Insurances.*").Create(provider).Error; err != nil {
+			return err
+		}
+		return db.RefreshProviderSearch(tx, provider.ID)
+	})
+}
+
+func (r gormProviders) Update(ctx context.Context, provider *models.Provider, insuranceIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		insurances, err := loadProviderLinks(tx, provider.CityID, insuranceIDs)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Insurances").Save(provider).Error; err != nil {
+			return err
+		}
+		if insuranceIDs != nil {
+			if err := tx.Model(provider).Omit("Insurances.*").Association("Insurances").Replace(insurances); err != nil {
+				return err
+			}
+		}
+		return db.RefreshProviderSearch(tx, provider.ID)
+	})
+}
+
+func (r gormProviders) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Provider{}, id).Error
+}
+
+// haversineSQL is the great-circle distance in km from (?, ?, ?) = (lat, lat, lng)
+const haversineSQL = `2 * 6371 * ASIN(LEAST(1, SQRT(
+	POWER(SIN(RADIANS(providers.lat - ?) / 2), 2) +
+	COS(RADIANS(?)) * COS(RADIANS(providers.lat)) * POWER(SIN(RADIANS(providers.lng - ?) / 2), 2))))`
+
+// search builds the query of the providers matching f, in the order of the search
+func (r gormProviders) search(ctx context.Context, f ProviderFilter) *gorm.DB {
+	tx := r.db.WithContext(ctx).Preload("User").
+		Preload("Specialization").
+		Preload("City").
+		Preload("Insurances").
+		Preload("Availabilities")
+
+	// Full-text search over name, specialization, city, services and bio (accent-insensitive)
+	if f.Search != "" {
+		tx = tx.Where("providers.search_vector @@ websearch_to_tsquery('"+db.SearchConfig+"', ?)", f.Search)
+	}
+
+	// Filter by city name
+	if f.City != "" {
+		tx = tx.Joins("JOIN cities c ON c.id = providers.city_id").
+			Where("LOWER(c.name) LIKE ?", "%"+strings.ToLower(f.City)+"%")
+	}
+
+	// Filter by insurance name (EXISTS keeps one row per provider)
+	if f.Insurance != "" {
+		tx = tx.Where(`EXISTS (SELECT 1 FROM provider_insurances pi
+			JOIN insurances i ON i.id = pi.insurance_id
+			WHERE pi.provider_id = providers.id AND LOWER(i.name) LIKE ?)`, "%"+strings.ToLower(f.Insurance)+"%")
+	}
+
+	if f.ServiceID != 0 {
+		tx = tx.Where(`EXISTS (SELECT 1 FROM services sv
+			WHERE sv.provider_id = providers.id AND sv.id = ? AND sv.deleted_at IS NULL)`, f.ServiceID)
+	}
+	if f.SpecializationID != 0 {
+		tx = tx.Where("providers.specialization_id = ?", f.SpecializationID)
+	}
+	if f.AvailableDays != nil {
+		// A half-open range of timestamps rather than a cast to date, which SQLite lacks
+		tx = tx.Where(`EXISTS (SELECT 1 FROM availabilities a WHERE a.provider_id = providers.id
+			AND (a.is_recurring = true OR (a.date >= ? AND a.date < ?)))`,
+			f.AvailableDays.From, f.AvailableDays.To.AddDate(0, 0, 1))
+	}
+
+	// Filter by distance: bounding box first (cheap), then the exact great-circle distance
+	if geo := f.Near; geo != nil {
+		minLat, maxLat, minLng, maxLng := scripts.BoundingBox(geo.Lat, geo.Lng, geo.RadiusKm)
+		tx = tx.Where("providers.lat BETWEEN ? AND ? AND providers.lng BETWEEN ? AND ?", minLat, maxLat, minLng, maxLng).
+			Where("NOT (providers.lat = 0 AND providers.lng = 0)"). // location never set
+			Where(haversineSQL+" <= ?", geo.Lat, geo.Lat, geo.Lng, geo.RadiusKm).
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: haversineSQL, Vars: []interface{}{geo.Lat, geo.Lat, geo.Lng}}})
+	}
+
+	// Most relevant first (after distance when searching nearby)
+	if f.Search != "" {
+		tx = tx.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank_cd(providers.search_vector, websearch_to_tsquery('" + db.SearchConfig + "', ?)) DESC",
+			Vars: []interface{}{f.Search},
+		}})
+	}
+	return tx
+}
+
+func (r gormProviders) List(ctx context.Context, f ProviderFilter, p listing.Params) ([]models.Provider, listing.Page, error) {
+	if f.Ranked() {
+		return listing.FindRanked[models.Provider](r.search(ctx, f), p)
+	}
+	return listing.Find[models.Provider](r.search(ctx, f), p)
+}
+
+func (r gormProviders) ListMatching(ctx context.Context, f ProviderFilter, limit int) ([]models.Provider, error) {
+	tx := r.search(ctx, f).Order("providers.id")
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+	var providers []models.Provider
+	err := tx.Find(&providers).Error
+	return providers, err
+}
+
+func (r gormProviders) SearchHits(ctx context.Context, query string, providerIDs []uint) (map[uint]db.SearchHit, error) {
+	return db.ProviderSearchHits(r.db.WithContext(ctx), query, providerIDs)
+}
+
+func (r gormProviders) AddInsurance(ctx context.Context, providerID, insuranceID uint) error {
+	return r.db.WithContext(ctx).Table("provider_insurances").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(map[string]interface{}{"provider_id": providerID, "insurance_id": insuranceID}).Error
+}
+
+func (r gormProviders) RemoveInsurance(ctx context.Context, providerID, insuranceID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Exec("DELETE FROM provider_insurances WHERE provider_id = ? AND insurance_id = ?", providerID, insuranceID)
+	return result.RowsAffected > 0, result.Error
+}
+
+type memoryProviders struct {
+	m *Memory
+}
+
+func (r memoryProviders) FindByID(_ context.Context, id uint) (models.Provider, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	provider, ok := r.m.providers[id]
+	if !ok {
+		return models.Provider{}, ErrNotFound
+	}
+	return provider, nil
+}
+
+func (r memoryProviders) FindProfile(_ context.Context, id uint) (models.Provider, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	provider, ok := r.m.providers[id]
+	if !ok {
+		return models.Provider{}, ErrNotFound
+	}
+	return r.m.withProfile(provider), nil
+}
+
+// withProfile loads the user, specialization, city, insurances and availabilities of
+// a provider; the caller holds m.mu
+func (m *Memory) withProfile(provider models.Provider) models.Provider {
+	if user, ok := m.users[provider.UserID]; ok {
+		provider.User = &user
+	}
+	if specialization, ok := m.specializations[provider.SpecializationID]; ok {
+		provider.Specialization = &specialization
+	}
+	if city, ok := m.cities[provider.CityID]; ok {
+		provider.City = &city
+	}
+	provider.Insurances = m.providerInsurancesOf(provider.ID)
+	for _, availability := range sortedValues(m.availabilities) {
+		if availability.ProviderID == provider.ID {
+			availability.Slots = nil
+			provider.Availabilities = append(provider.Availabilities, availability)
+		}
+	}
+	return provider
+}
+
+func (r memoryProviders) FindByUserID(_ context.Context, userID uint) (models.Provider, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, provider := range sortedValues(r.m.providers) {
+		if provider.UserID == userID {
+			return provider, nil
+		}
+	}
+	return models.Provider{}, ErrNotFound
+}
+
+func (r memoryProviders) FindService(_ context.Context, id uint) (models.Service, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	service, ok := r.m.services[id]
+	if !ok {
+		return models.Service{}, ErrNotFound
+	}
+	return service, nil
+}
+
+func (r memoryProviders) SpecializationExists(_ context.Context, id uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	_, ok := r.m.specializations[id]
+	return ok, nil
+}
+
+func (r memoryProviders) FindSpecialization(_ context.Context, id uint) (models.Specialization, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	specialization, ok := r.m.specializations[id]
+	if !ok {
+		return models.Specialization{}, ErrNotFound
+	}
+	return specialization, nil
+}
+
+// providerInsurancesOf returns the insurances a provider accepts; the caller holds m.mu
+func (m *Memory) providerInsurancesOf(providerID uint) []*models.Insurance {
+	insurances := []*models.Insurance{}
+	for _, id := range m.providerInsurances[providerID] {
+		if insurance, ok := m.insurances[id]; ok {
+			insurances = append(insurances, &insurance)
+		}
+	}
+	return insurances
+}
+
+// checkProviderLinks is loadProviderLinks for the store; the caller holds m.mu
+func (m *Memory) checkProviderLinks(cityID uint, insuranceIDs []uint) ([]uint, error) {
+	if _, ok := m.cities[cityID]; !ok {
+		return nil, &LinkError{fmt.Sprintf("city %d not found", cityID)}
+	}
+
+	ids := []uint{}
+	for _, id := range insuranceIDs {
+		if _, ok := m.insurances[id]; !ok {
+			return nil, &LinkError{fmt.Sprintf("insurance %d not found", id)}
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// storedProvider strips the associations loaded with a provider
+func storedProvider(provider models.Provider) models.Provider {
+	provider.User, provider.Specialization, provider.City = nil, nil, nil
+	provider.Insurances, provider.Availabilities = nil, nil
+	return provider
+}
+
+func (r memoryProviders) Create(_ context.Context, provider *models.Provider, insuranceIDs []uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	ids, err := r.m.checkProviderLinks(provider.CityID, insuranceIDs)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	provider.ID = r.m.nextID("providers")
+	provider.CreatedAt, provider.UpdatedAt = now, now
+	r.m.providers[provider.ID] = storedProvider(*provider)
+	r.m.providerInsurances[provider.ID] = ids
+	provider.Insurances = r.m.providerInsurancesOf(provider.ID)
+	return nil
+}
+
+func (r memoryProviders) Update(_ context.Context, provider *models.Provider, insuranceIDs []uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.providers[provider.ID]; !ok {
+		return ErrNotFound
+	}
+	ids, err := r.m.checkProviderLinks(provider.CityID, insuranceIDs)
+	if err != nil {
+		return err
+	}
+
+	provider.UpdatedAt = time.Now()
+	r.m.providers[provider.ID] = storedProvider(*provider)
+	if insuranceIDs != nil {
+		r.m.providerInsurances[provider.ID] = ids
+		provider.Insurances = r.m.providerInsurancesOf(provider.ID)
+	}
+	return nil
+}
+
+func (r memoryProviders) Delete(_ context.Context, id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	delete(r.m.providers, id)
+	delete(r.m.providerInsurances, id)
+	return nil
+}
+
+// matchesProvider reports whether a provider loaded by withProfile matches f; the caller
+// holds m.mu. Without full-text search, Search must be a case-insensitive part of the
+// provider's name, specialization, city, services or bio.
+func (m *Memory) matchesProvider(p models.Provider, f ProviderFilter) bool {
+	if f.Search != "" {
+		text := []string{p.Bio}
+		if p.User != nil {
+			text = append(text, p.User.Name)
+		}
+		if p.Specialization != nil {
+			text = append(text, p.Specialization.Name)
+		}
+		if p.City != nil {
+			text = append(text, p.City.Name)
+		}
+		for _, service := range m.services {
+			if service.ProviderID == p.ID {
+				text = append(text, service.Title, service.Description)
+			}
+		}
+		if !containsFold(strings.Join(text, " "), strings.ToLower(f.Search)) {
+			return false
+		}
+	}
+	if f.City != "" && (p.City == nil || !containsFold(p.City.Name, strings.ToLower(f.City))) {
+		return false
+	}
+	if f.Insurance != "" && !slices.ContainsFunc(p.Insurances, func(i *models.Insurance) bool {
+		return containsFold(i.Name, strings.ToLower(f.Insurance))
+	}) {
+		return false
+	}
+	if service, ok := m.services[f.ServiceID]; f.ServiceID != 0 && (!ok || service.ProviderID != p.ID) {
+		return false
+	}
+	if f.SpecializationID != 0 && p.SpecializationID != f.SpecializationID {
+		return false
+	}
+	if days := f.AvailableDays; days != nil && !slices.ContainsFunc(p.Availabilities, func(a models.Availability) bool {
+		return a.IsRecurring || (a.Date != nil && !a.Date.Before(days.From) && !a.Date.After(days.To))
+	}) {
+		return false
+	}
+	if geo := f.Near; geo != nil {
+		if p.Lat == 0 && p.Lng == 0 {
+			return false
+		}
+		return scripts.DistanceKm(geo.Lat, geo.Lng, p.Lat, p.Lng) <= geo.RadiusKm
+	}
+	return true
+}
+
+// searchProviders returns the providers matching f, nearest first when searching nearby
+// and by id otherwise; the caller holds m.mu
+func (m *Memory) searchProviders(f ProviderFilter) []models.Provider {
+	var providers []models.Provider
+	for _, provider := range sortedValues(m.providers) {
+		if provider = m.withProfile(provider); m.matchesProvider(provider, f) {
+			providers = append(providers, provider)
+		}
+	}
+	if geo := f.Near; geo != nil {
+		sort.SliceStable(providers, func(i, j int) bool {
+			return scripts.DistanceKm(geo.Lat, geo.Lng, providers[i].Lat, providers[i].Lng) <
+				scripts.DistanceKm(geo.Lat, geo.Lng, providers[j].Lat, providers[j].Lng)
+		})
+	}
+	return providers
+}
+
+func (r memoryProviders) List(_ context.Context, f ProviderFilter, p listing.Params) ([]models.Provider, listing.Page, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	providers := r.m.searchProviders(f)
+	if f.Ranked() {
+		return listing.Slice(providers, p)
+	}
+	return memoryPage(providers, p,
+		func(p models.Provider) uint { return p.ID },
+		func(p models.Provider, key string) interface{} {
+			switch key {
+			case "rating":
+				return p.Rating
+			case "review_count":
+				return p.ReviewCount
+			}
+			return p.CreatedAt
+		})
+}
+
+func (r memoryProviders) ListMatching(_ context.Context, f ProviderFilter, limit int) ([]models.Provider, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	providers := r.m.searchProviders(f)
+	if limit > 0 && len(providers) > limit {
+		providers = providers[:limit]
+	}
+	return providers, nil
+}
+
+// SearchHits ranks every matching provider 1, without excerpts
+func (r memoryProviders) SearchHits(_ context.Context, query string, providerIDs []uint) (map[uint]db.SearchHit, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	hits := map[uint]db.SearchHit{}
+	for _, id := range providerIDs {
+		provider, ok := r.m.providers[id]
+		if ok && r.m.matchesProvider(r.m.withProfile(provider), ProviderFilter{Search: query}) {
+			hits[id] = db.SearchHit{ID: id, Rank: 1}
+		}
+	}
+	return hits, nil
+}
+
+func (r memoryProviders) AddInsurance(_ context.Context, providerID, insuranceID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if !slices.Contains(r.m.providerInsurances[providerID], insuranceID) {
+		r.m.providerInsurances[providerID] = append(r.m.providerInsurances[providerID], insuranceID)
+	}
+	return nil
+}
+
+func (r memoryProviders) RemoveInsurance(_ context.Context, providerID, insuranceID uint) (bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	ids := r.m.providerInsurances[providerID]
+	i := slices.Index(ids, insuranceID)
+	if i < 0 {
+		return false, nil
+	}
+	r.m.providerInsurances[providerID] = slices.Delete(ids, i, i+1)
+	return true, nil
+}
@@ -0,0 +1,63 @@
+// Package repository is the data access layer of controllers: one interface per
+// aggregate (users, providers, availabilities, bookings, insurances, cities), backed
+// by GORM in production and by memory in handler tests.
+package repository
+
+import (
+	"errors"
+
+	"github.com/adriel-meb/appointly-backend/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// LinkError reports a reference to a record that does not exist, such as an unknown
+// city or insurance id given with a provider. Its message is meant for the client.
+type LinkError struct {
+	Message string
+}
+
+func (e *LinkError) Error() string {
+	return e.Message
+}
+
+// Repositories groups the repositories handed to controllers
+type Repositories struct {
+	Users          UserRepository
+	Providers      ProviderRepository
+	Availabilities AvailabilityRepository
+	Bookings       BookingRepository
+	Insurances     InsuranceRepository
+	Cities         CityRepository
+}
+
+// NewGorm returns the repositories backed by the database
+func NewGorm(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:          NewGormUserRepository(db),
+		Providers:      NewGormProviderRepository(db),
+		Availabilities: NewGormAvailabilityRepository(db),
+		Bookings:       NewGormBookingRepository(db),
+		Insurances:     NewGormInsuranceRepository(db),
+		Cities:         NewGormCityRepository(db),
+	}
+}
+
+// notFound maps GORM's missing record error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// wakeWebhooks has the webhooks worker send the deliveries queued by a transaction,
+// once it is committed; it returns the transaction's error
+func wakeWebhooks(err error) error {
+	if err == nil {
+		webhooks.Wake()
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/listing"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// UserRepository stores user accounts
+type UserRepository interface {
+	FindByID(ctx context.Context, id uint) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	List(ctx context.Context, p listing.Params) ([]models.User, listing.Page, error)
+	// Create saves a user and, when provider is not nil, its provider profile, all or nothing
+	Create(ctx context.Context, user *models.User, provider *models.Provider) error
+	// DeleteByEmail deletes the user with that email, or returns ErrNotFound
+	DeleteByEmail(ctx context.Context, email string) error
+}
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository returns a UserRepository backed by the database
+func NewGormUserRepository(db *gorm.DB) UserRepository {
+	return gormUsers{db}
+}
+
+func (r gormUsers) FindByID(ctx context.Context, id uint) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	return user, notFound(err)
+}
+
+func (r gormUsers) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	return user, notFound(err)
+}
+
+func (r gormUsers) List(ctx context.Context, p listing.Params) ([]models.User, listing.Page, error) {
+	return listing.Find[models.User](r.db.WithContext(ctx), p)
+}
+
+func (r gormUsers) Create(ctx context.Context, user *models.User, provider *models.Provider) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if provider == nil {
+			return nil
+		}
+		provider.UserID = user.ID
+		return tx.Create(provider).Error
+	})
+}
+
+func (r gormUsers) DeleteByEmail(ctx context.Context, email string) error {
+	result := r.db.WithContext(ctx).Where("email = ?", email).Delete(&models.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type memoryUsers struct {
+	m *Memory
+}
+
+func (r memoryUsers) FindByID(_ context.Context, id uint) (models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	user, ok := r.m.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r memoryUsers) FindByEmail(_ context.Context, email string) (models.User, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, user := range sortedValues(r.m.users) {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r memoryUsers) List(_ context.Context, p listing.Params) ([]models.User, listing.Page, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	users, page, err := memoryPage(sortedValues(r.m.users), p,
+		func(u models.User) uint { return u.ID },
+		func(u models.User, key string) interface{} {
+			switch key {
+			case "name":
+				return u.Name
+			case "email":
+				return u.Email
+			}
+			return u.CreatedAt
+		})
+	return users, page, err
+}
+
+func (r memoryUsers) Create(_ context.Context, user *models.User, provider *models.Provider) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for _, existing := range r.m.users {
+		if existing.Email == user.Email {
+			return fmt.Errorf("email %s is already used", user.Email)
+		}
+	}
+	if user.Role == "" {
+		user.Role = models.RolePatient
+	}
+
+	now := time.Now()
+	user.ID = r.m.nextID("users")
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.m.users[user.ID] = *user
+
+	if provider != nil {
+		provider.UserID = user.ID
+		provider.ID = r.m.nextID("providers")
+		provider.CreatedAt, provider.UpdatedAt = now, now
+		r.m.providers[provider.ID] = *provider
+	}
+	return nil
+}
+
+func (r memoryUsers) DeleteByEmail(_ context.Context, email string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	for id, user := range r.m.users {
+		if user.Email == email {
+			delete(r.m.users, id)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/db"
+	"github.com/adriel-meb/appointly-backend/internal/health"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/adriel-meb/appointly-backend/internal/tracing"
+)
+
+const (
+	// MaxAttempts is the number of tries before a delivery is marked failed
+	MaxAttempts = 6
+	// baseBackoff doubles after every failed attempt: 30s, 1m, 2m, 4m, 8m
+	baseBackoff    = 30 * time.Second
+	requestTimeout = 10 * time.Second
+)
+
+// Client sends the webhook requests; tests can point it at a local stub
+var Client = &http.Client{Timeout: requestTimeout}
+
+// Backoff returns the delay before the next try after `attempts` failed attempts
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return baseBackoff << (attempts - 1)
+}
+
+// attempt sends a delivery once and records the outcome
+func attempt(sub models.WebhookSubscription, delivery models.WebhookDelivery) models.WebhookDelivery {
+	statusCode, err := send(sub, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = err.Error()
+	default:
+		next := now.Add(Backoff(delivery.Attempts))
+		delivery.Status = models.DeliveryPending
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+	}
+
+	if err := db.DB.Save(&delivery).Error; err != nil {
+		slog.Error("webhooks: failed to record delivery", "delivery_id", delivery.ID, "error", err)
+	}
+	return delivery
+}
+
+// send POSTs the signed payload; any non-2xx answer counts as a failure
+func send(sub models.WebhookSubscription, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Appointly-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderSignature, SignatureHeader(sub.Secret, time.Now().Unix(), body))
+
+	resp, err := Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// RunWorker sends due deliveries every interval, and whenever it is woken,
+// until ctx is cancelled
+func RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	health.Beat("webhooks", interval)
+	defer health.Stop("webhooks")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			health.Beat("webhooks", interval)
+		case <-wake:
+		}
+
+		run, span := tracing.StartJob(ctx, "webhooks.send_due")
+		if err := sendDue(run, time.Now()); err != nil {
+			span.RecordError(err)
+			slog.ErrorContext(run, "webhooks worker", "error", err)
+		}
+		span.End()
+	}
+}
+
+// sendDue attempts every pending delivery whose (re)try time has passed
+func sendDue(ctx context.Context, now time.Time) error {
+	tx := db.DB.WithContext(ctx)
+	var due []models.WebhookDelivery
+	if err := tx.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at").Limit(100).Find(&due).Error; err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		// Claim the row so concurrent workers (other instances) skip it
+		claim := now.Add(requestTimeout)
+		result := tx.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND next_attempt_at = ?", d.ID, d.NextAttemptAt).
+			Update("next_attempt_at", claim)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		var sub models.WebhookSubscription
+		if err := tx.First(&sub, d.SubscriptionID).Error; err != nil || !sub.Active {
+			// Subscription deleted or paused: stop retrying
+			tx.Model(&d).Updates(map[string]any{
+				"status":          models.DeliveryFailed,
+				"next_attempt_at": nil,
+				"last_error":      "subscription inactive",
+			})
+			continue
+		}
+
+		d.NextAttemptAt = &claim
+		attempt(sub, d)
+	}
+	return nil
+}
+
+// Redeliver sends the payload of a logged delivery again immediately.
+// Like the original, the redelivery gets its own log entry and retry budget.
+func Redeliver(sub models.WebhookSubscription, original models.WebhookDelivery) (models.WebhookDelivery, error) {
+	claim := time.Now().Add(requestTimeout)
+	delivery := models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  &claim,
+	}
+	if err := db.DB.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	return attempt(sub, delivery), nil
+}
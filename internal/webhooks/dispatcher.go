@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/events"
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EventTypes lists the events partners can subscribe to
+var EventTypes = []events.Type{
+	events.BookingCreated,
+	events.BookingConfirmed,
+	events.BookingCancelled,
+	events.AvailabilityChanged,
+}
+
+// IsSupportedEvent reports whether t is one of EventTypes
+func IsSupportedEvent(t events.Type) bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// payload is the body POSTed to subscribers. ID identifies the event: it is shared by
+// every delivery and redelivery of the event, so receivers can discard duplicates.
+type payload struct {
+	ID         string      `json:"id"`
+	Type       events.Type `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       any         `json:"data"`
+	ProviderID uint        `json:"provider_id"`
+}
+
+// Enqueue logs a pending delivery of the event for every subscription interested in it.
+// Call it with the transaction writing the change, so the deliveries are committed
+// with the change or not at all, and Wake the worker once it is committed.
+func Enqueue(tx *gorm.DB, e events.Event) error {
+	if !IsSupportedEvent(e.Type) {
+		return nil
+	}
+
+	var subs []models.WebhookSubscription
+	query := tx.Where("active = ?", true)
+	if e.ProviderID != 0 {
+		query = query.Where("provider_id IS NULL OR provider_id = ?", e.ProviderID)
+	} else {
+		query = query.Where("provider_id IS NULL")
+	}
+	if err := query.Find(&subs).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = now
+	}
+	eventID := uuid.NewString()
+	body, err := json.Marshal(payload{
+		ID:         eventID,
+		Type:       e.Type,
+		OccurredAt: e.OccurredAt,
+		Data:       e.Data,
+		ProviderID: e.ProviderID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, s := range subs {
+		if !subscribesTo(s, e.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: s.ID,
+			EventID:        eventID,
+			EventType:      string(e.Type),
+			Payload:        string(body),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.Create(&deliveries).Error
+}
+
+// wake holds a pending request for the worker to run before its next tick
+var wake = make(chan struct{}, 1)
+
+// Wake asks RunWorker to send the deliveries committed by Enqueue right away
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// subscribesTo reports whether a subscription lists the event type
+func subscribesTo(s models.WebhookSubscription, t events.Type) bool {
+	for _, part := range strings.Split(s.EventTypes, ",") {
+		if events.Type(strings.TrimSpace(part)) == t {
+			return true
+		}
+	}
+	return false
+}
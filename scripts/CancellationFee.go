@@ -0,0 +1,43 @@
+package scripts
+
+import (
+	"math"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+// DefaultCancellationPolicy applies to providers who did not define one:
+// free until 24h before, 50% fee afterwards, no refund for no-shows.
+func DefaultCancellationPolicy(providerID uint) models.CancellationPolicy {
+	return models.CancellationPolicy{
+		ProviderID:                 providerID,
+		FullRefundHours:            24,
+		LateCancellationFeePercent: 50,
+		NoShowFeePercent:           100,
+	}
+}
+
+// CancellationFee returns the part of amount kept when a booking starting at
+// start is cancelled at now under policy. Cancelling once the appointment has
+// started costs as much as not showing up.
+func CancellationFee(policy models.CancellationPolicy, amount float64, start, now time.Time) float64 {
+	notice := start.Sub(now)
+	if notice <= 0 {
+		return NoShowFee(policy, amount)
+	}
+	if notice >= time.Duration(policy.FullRefundHours)*time.Hour {
+		return 0
+	}
+	return roundAmount(amount * policy.LateCancellationFeePercent / 100)
+}
+
+// NoShowFee returns the part of amount kept when the patient did not show up
+func NoShowFee(policy models.CancellationPolicy, amount float64) float64 {
+	return roundAmount(amount * policy.NoShowFeePercent / 100)
+}
+
+// roundAmount rounds to the cent
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
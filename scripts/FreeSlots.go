@@ -0,0 +1,103 @@
+package scripts
+
+import (
+	"strings"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+// BusinessTimezone is the zone availability times ("09:00") are expressed in
+const BusinessTimezone = "Africa/Libreville"
+
+// BusinessLocation returns the location of BusinessTimezone, or UTC+1 when tzdata is missing
+func BusinessLocation() *time.Location {
+	if loc, err := time.LoadLocation(BusinessTimezone); err == nil {
+		return loc
+	}
+	return time.FixedZone("WAT", 3600)
+}
+
+// Interval is a busy period of a provider (booking, imported calendar event...)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// EarliestFreeSlot returns the first start within [from, to] where an appointment of
+// duration fits one of the availabilities without overlapping a busy interval.
+// Candidate starts are spaced by duration from the start of each availability.
+func EarliestFreeSlot(availabilities []models.Availability, busy []Interval, from, to time.Time, duration time.Duration, loc *time.Location) (time.Time, bool) {
+	if duration <= 0 || !to.After(from) {
+		return time.Time{}, false
+	}
+
+	from, to = from.In(loc), to.In(loc)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		var best time.Time
+		for _, availability := range availabilities {
+			if !availableOn(availability, day) {
+				continue
+			}
+			start, okStart := clockOn(day, availability.StartTime)
+			end, okEnd := clockOn(day, availability.EndTime)
+			if !okStart || !okEnd {
+				continue
+			}
+
+			for slot := start; !slot.Add(duration).After(end); slot = slot.Add(duration) {
+				if slot.Before(from) || slot.Add(duration).After(to) {
+					continue
+				}
+				if overlapsAny(slot, slot.Add(duration), busy) {
+					continue
+				}
+				if best.IsZero() || slot.Before(best) {
+					best = slot
+				}
+				break
+			}
+		}
+		if !best.IsZero() {
+			return best, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// availableOn tells whether an availability applies to the given local day
+func availableOn(availability models.Availability, day time.Time) bool {
+	if availability.IsRecurring {
+		return availability.DayOfWeek != nil &&
+			strings.EqualFold(string(*availability.DayOfWeek), day.Weekday().String())
+	}
+	if availability.Date == nil {
+		return false
+	}
+	// Dates are stored as midnight UTC of the calendar day
+	y, m, d := availability.Date.UTC().Date()
+	dy, dm, dd := day.Date()
+	return y == dy && m == dm && d == dd
+}
+
+// clockOn places an "HH:MM" time on a day
+func clockOn(day time.Time, clock string) (time.Time, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), true
+}
+
+// overlapsAny reports whether [start, end) intersects one of the intervals
+func overlapsAny(start, end time.Time, busy []Interval) bool {
+	for _, b := range busy {
+		if start.Before(b.End) && end.After(b.Start) {
+			return true
+		}
+	}
+	return false
+}
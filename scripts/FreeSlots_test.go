@@ -0,0 +1,53 @@
+package scripts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+)
+
+func TestEarliestFreeSlot(t *testing.T) {
+	loc := time.FixedZone("WAT", 3600)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2030, 1, day, hour, minute, 0, 0, loc)
+	}
+	monday := models.Monday
+	dated := time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC) // Tuesday, stored as midnight UTC
+	recurring := models.Availability{IsRecurring: true, DayOfWeek: &monday, StartTime: "09:00", EndTime: "10:00"}
+	oneOff := models.Availability{Date: &dated, StartTime: "14:00", EndTime: "15:00"}
+
+	tests := []struct {
+		name     string
+		busy     []Interval
+		from, to time.Time
+		want     time.Time
+	}{
+		{"recurring on the first day", nil, at(7, 0, 0), at(9, 0, 0), at(7, 9, 0)},
+		{"from inside the availability", nil, at(7, 9, 10), at(9, 0, 0), at(7, 9, 30)},
+		{"busy first slot", []Interval{{at(7, 9, 0), at(7, 9, 30)}}, at(7, 0, 0), at(9, 0, 0), at(7, 9, 30)},
+		{"busy day falls to the dated availability", []Interval{{at(7, 8, 0), at(7, 11, 0)}}, at(7, 0, 0), at(9, 0, 0), at(8, 14, 0)},
+		{"dated availability only", nil, at(8, 0, 0), at(9, 0, 0), at(8, 14, 0)},
+		{"slot must end in the window", nil, at(7, 0, 0), at(7, 9, 20), time.Time{}},
+		{"everything busy", []Interval{{at(7, 0, 0), at(9, 0, 0)}}, at(7, 0, 0), at(9, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		got, ok := EarliestFreeSlot([]models.Availability{recurring, oneOff}, tt.busy, tt.from.UTC(), tt.to.UTC(), 30*time.Minute, loc)
+		if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+			t.Errorf("%s: expected %s, got %s (%v)", tt.name, tt.want, got, ok)
+		}
+	}
+}
+
+func TestEarliestFreeSlotRejectsEmptyWindow(t *testing.T) {
+	monday := models.Monday
+	availability := models.Availability{IsRecurring: true, DayOfWeek: &monday, StartTime: "09:00", EndTime: "10:00"}
+	from := time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	if _, ok := EarliestFreeSlot([]models.Availability{availability}, nil, from, from, 30*time.Minute, time.UTC); ok {
+		t.Fatal("expected no slot in an empty window")
+	}
+	if _, ok := EarliestFreeSlot([]models.Availability{availability}, nil, from, from.Add(24*time.Hour), 0, time.UTC); ok {
+		t.Fatal("expected no slot without a duration")
+	}
+}
@@ -0,0 +1,53 @@
+package scripts
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarthRadiusKm is the mean Earth radius used for great-circle distances
+const EarthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle (haversine) distance between two points
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBox returns a lat/lng box containing every point within radiusKm of the center.
+// It is a cheap index-friendly prefilter; the exact distance is checked afterwards.
+func BoundingBox(lat, lng, radiusKm float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := radiusKm / EarthRadiusKm * 180 / math.Pi
+	minLat, maxLat = math.Max(lat-dLat, -90), math.Min(lat+dLat, 90)
+
+	// Near the poles or across the antimeridian the box spans every longitude
+	cos := math.Cos(lat * math.Pi / 180)
+	if cos < 0.01 || maxLat >= 90 || minLat <= -90 {
+		return minLat, maxLat, -180, 180
+	}
+	// Widest longitude offset of the circle, reached north of the center latitude
+	sin := math.Sin(radiusKm/EarthRadiusKm) / cos
+	if sin >= 1 {
+		return minLat, maxLat, -180, 180
+	}
+	dLng := math.Asin(sin) * 180 / math.Pi
+	if lng-dLng < -180 || lng+dLng > 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, lng - dLng, lng + dLng
+}
+
+// FormatDistance renders a distance for display: "850 m", "2.4 km", "120 km"
+func FormatDistance(km float64) string {
+	switch {
+	case km < 1:
+		return fmt.Sprintf("%d m", int(math.Round(km*1000)))
+	case km < 100:
+		return fmt.Sprintf("%.1f km", km)
+	default:
+		return fmt.Sprintf("%d km", int(math.Round(km)))
+	}
+}
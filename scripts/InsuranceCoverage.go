@@ -0,0 +1,8 @@
+package scripts
+
+// InsuranceSplit splits a price between the insurer and the patient.
+// The patient share is derived from the insured one so both always add up to price.
+func InsuranceSplit(price, coveragePercent float64) (insured, patient float64) {
+	insured = roundAmount(price * coveragePercent / 100)
+	return insured, roundAmount(price - insured)
+}
@@ -0,0 +1,44 @@
+package scripts
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHoursEnd reports whether now falls inside the daily quiet window
+// [start, end) evaluated in loc, and if so when that window ends.
+// Windows may wrap around midnight ("22:00" -> "07:00").
+func QuietHoursEnd(startStr, endStr string, loc *time.Location, now time.Time) (bool, time.Time, error) {
+	start, err := time.Parse("15:04", startStr)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid quiet hours start: %v", err)
+	}
+	end, err := time.Parse("15:04", endStr)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid quiet hours end: %v", err)
+	}
+	if start.Equal(end) {
+		return false, time.Time{}, nil
+	}
+
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+	startMin := start.Hour()*60 + start.Minute()
+	endMin := end.Hour()*60 + end.Minute()
+
+	var inside bool
+	if startMin < endMin {
+		inside = minutes >= startMin && minutes < endMin
+	} else {
+		inside = minutes >= startMin || minutes < endMin
+	}
+	if !inside {
+		return false, time.Time{}, nil
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return true, until, nil
+}
@@ -0,0 +1,50 @@
+package scripts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursEndWrapsPastMidnight(t *testing.T) {
+	loc := time.FixedZone("WAT", 3600)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2030, 1, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		inside bool
+		until  time.Time
+	}{
+		{"before the window", at(7, 21, 59), false, time.Time{}},
+		{"evening, ends the next morning", at(7, 23, 30), true, at(8, 7, 0)},
+		{"after midnight, ends the same morning", at(8, 2, 0), true, at(8, 7, 0)},
+		{"end excluded", at(8, 7, 0), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		inside, until, err := QuietHoursEnd("22:00", "07:00", loc, tt.now.UTC())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if inside != tt.inside || !until.Equal(tt.until) {
+			t.Errorf("%s: expected %v until %s, got %v until %s", tt.name, tt.inside, tt.until, inside, until)
+		}
+	}
+}
+
+func TestQuietHoursEndWithinADay(t *testing.T) {
+	now := time.Date(2030, 1, 7, 13, 0, 0, 0, time.UTC)
+	inside, until, err := QuietHoursEnd("12:00", "14:00", time.UTC, now)
+	if err != nil || !inside || !until.Equal(time.Date(2030, 1, 7, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected quiet until 14:00, got %v until %s (%v)", inside, until, err)
+	}
+
+	// an empty window is never quiet
+	if inside, _, err := QuietHoursEnd("08:00", "08:00", time.UTC, now); err != nil || inside {
+		t.Errorf("expected no quiet hours, got %v (%v)", inside, err)
+	}
+	if _, _, err := QuietHoursEnd("25:00", "07:00", time.UTC, now); err == nil {
+		t.Error("expected an invalid start to be refused")
+	}
+}
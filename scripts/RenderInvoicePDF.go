@@ -0,0 +1,123 @@
+package scripts
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/adriel-meb/appointly-backend/internal/models"
+	"github.com/go-pdf/fpdf"
+)
+
+// FormatAmount renders an amount with its currency, French style: "15 000 XAF", "12,50 EUR"
+func FormatAmount(amount float64, currency string) string {
+	cents := int64(math.Round(amount * 100))
+	digits := strconv.FormatInt(cents/100, 10)
+
+	var grouped []byte
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped = append(grouped, ' ')
+		}
+		grouped = append(grouped, digits[i])
+	}
+
+	result := string(grouped)
+	if rest := cents % 100; rest != 0 {
+		result += fmt.Sprintf(",%02d", rest)
+	}
+	return result + " " + currency
+}
+
+// RenderInvoicePDF renders an invoice as a one-page A4 PDF
+func RenderInvoicePDF(invoice models.Invoice) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Facture "+invoice.Number, true)
+	pdf.SetCreator("Appointly", true)
+	pdf.AddPage()
+
+	// Core fonts are cp1252: translate UTF-8 so accents ("Médecin") render
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// Header
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 10, tr("FACTURE"), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr("N° "+invoice.Number), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, tr("Date d'émission : "+invoice.IssuedAt.Format("02/01/2006")), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	// Provider and patient blocks side by side
+	top := pdf.GetY()
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(90, 6, tr("Praticien"), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range []string{invoice.ProviderName, invoice.ProviderSpecialization, invoice.ProviderAddress, invoice.ProviderCity} {
+		if line != "" {
+			pdf.CellFormat(90, 5, tr(line), "", 2, "L", false, 0, "")
+		}
+	}
+	bottom := pdf.GetY()
+
+	pdf.SetXY(110, top)
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(90, 6, tr("Patient"), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(90, 5, tr(invoice.PatientName), "", 2, "L", false, 0, "")
+	pdf.CellFormat(90, 5, tr(invoice.PatientEmail), "", 2, "L", false, 0, "")
+	if invoice.PatientPhone != nil {
+		pdf.CellFormat(90, 5, tr(*invoice.PatientPhone), "", 2, "L", false, 0, "")
+	}
+	if pdf.GetY() > bottom {
+		bottom = pdf.GetY()
+	}
+	pdf.SetXY(10, bottom+10)
+
+	// Service table
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(95, 8, tr("Prestation"), "1", 0, "L", true, 0, "")
+	pdf.CellFormat(50, 8, tr("Date"), "1", 0, "L", true, 0, "")
+	pdf.CellFormat(45, 8, tr("Montant"), "1", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	service := invoice.ServiceTitle
+	if invoice.DurationMinutes > 0 {
+		service = fmt.Sprintf("%s (%d min)", service, invoice.DurationMinutes)
+	}
+	pdf.CellFormat(95, 8, tr(service), "1", 0, "L", false, 0, "")
+	pdf.CellFormat(50, 8, invoice.ServiceDate.Format("02/01/2006 15:04"), "1", 0, "L", false, 0, "")
+	pdf.CellFormat(45, 8, tr(FormatAmount(invoice.Amount, invoice.Currency)), "1", 1, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(145, 8, tr("Total"), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(45, 8, tr(FormatAmount(invoice.Amount, invoice.Currency)), "1", 1, "R", false, 0, "")
+
+	// Insurer and patient shares
+	if invoice.InsurerName != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(145, 8, tr("Pris en charge par "+invoice.InsurerName+" (adhérent "+invoice.MemberNumber+")"), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(45, 8, tr(FormatAmount(invoice.InsuredAmount, invoice.Currency)), "1", 1, "R", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(145, 8, tr("Reste à charge du patient"), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(45, 8, tr(FormatAmount(invoice.PatientAmount, invoice.Currency)), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "", 10)
+	status := "Non payée"
+	switch invoice.PaymentStatus {
+	case models.PaymentPaid:
+		status = "Payée"
+	case models.PaymentPartiallyRefunded, models.PaymentRefunded:
+		status = "Payée (remboursement effectué)"
+	}
+	pdf.CellFormat(0, 6, tr("Statut du paiement : "+status), "", 1, "L", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,20 +1,33 @@
 package scripts
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+
 	"github.com/adriel-meb/appointly-backend/internal/models"
 )
 
-func SendNotifications(notificationType models.NotificationType, message string) error {
+// Attachment is a file sent along with an email notification
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+func SendNotifications(ctx context.Context, notificationType models.NotificationType, message string, attachments ...Attachment) error {
 
 	// Send notification asynchronously
 	switch notificationType {
 	case models.Email:
-		fmt.Println(".......Email NOTIFICATION SENT......", message)
+		slog.InfoContext(ctx, "email notification sent", "message", message)
+		for _, a := range attachments {
+			slog.InfoContext(ctx, "email attachment sent", "file", a.Filename, "content_type", a.ContentType, "bytes", len(a.Content))
+		}
 	case models.SMS:
-		fmt.Println(".......SMS NOTIFICATION SENT........")
+		slog.InfoContext(ctx, "SMS notification sent", "message", message)
 	case models.Push:
-		fmt.Println(".......Push NOTIFICATION SENT........")
+		slog.InfoContext(ctx, "push notification sent", "message", message)
 	default:
 		return fmt.Errorf("unsupported notification type: %s", notificationType)
 